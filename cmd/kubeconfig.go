@@ -12,6 +12,7 @@ import (
 	authv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -223,6 +224,8 @@ var setupCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		saName, _ := cmd.Flags().GetString("service-account-name")
 		namespace, _ := cmd.Flags().GetString("namespace")
+		allowMigrations, _ := cmd.Flags().GetBool("allow-migrations")
+		allowPowerActions, _ := cmd.Flags().GetBool("allow-power-actions")
 
 		// Use defaults if not provided
 		if saName == "" {
@@ -291,9 +294,44 @@ var setupCmd = &cobra.Command{
 					Resources: []string{"pods", "persistentvolumeclaims"},
 					Verbs:     []string{"get", "list", "watch"},
 				},
+				// Nodes, for readiness, cordons, capacity and placement
+				{
+					APIGroups: []string{""},
+					Resources: []string{"nodes"},
+					Verbs:     []string{"get", "list", "watch"},
+				},
+				// Instancetypes and preferences, resolved for the CPU, memory
+				// and storage class of stopped VMs
+				{
+					APIGroups: []string{"instancetype.kubevirt.io"},
+					Resources: []string{
+						"virtualmachineinstancetypes", "virtualmachineclusterinstancetypes",
+						"virtualmachinepreferences", "virtualmachineclusterpreferences",
+					},
+					Verbs: []string{"get"},
+				},
 			},
 		}
-		if _, err := crClient.Get(context.Background(), crName, metav1.GetOptions{}); err != nil {
+		// Starting and cancelling live migrations from the API is opt-in
+		if allowMigrations {
+			clusterRole.Rules = append(clusterRole.Rules, rbacv1.PolicyRule{
+				APIGroups: []string{"kubevirt.io"},
+				Resources: []string{"virtualmachineinstancemigrations"},
+				Verbs:     []string{"create", "delete"},
+			})
+		}
+		// VM power actions use the KubeVirt subresource API and are opt-in too
+		if allowPowerActions {
+			clusterRole.Rules = append(clusterRole.Rules, rbacv1.PolicyRule{
+				APIGroups: []string{"subresources.kubevirt.io"},
+				Resources: []string{
+					"virtualmachines/start", "virtualmachines/stop", "virtualmachines/restart",
+					"virtualmachineinstances/pause", "virtualmachineinstances/unpause",
+				},
+				Verbs: []string{"update"},
+			})
+		}
+		if existing, err := crClient.Get(context.Background(), crName, metav1.GetOptions{}); err != nil {
 			if apierrors.IsNotFound(err) {
 				if _, err := crClient.Create(context.Background(), clusterRole, metav1.CreateOptions{}); err != nil {
 					return fmt.Errorf("failed to create ClusterRole %s: %w", crName, err)
@@ -302,6 +340,14 @@ var setupCmd = &cobra.Command{
 			} else {
 				return fmt.Errorf("failed to get ClusterRole %s: %w", crName, err)
 			}
+		} else if !equality.Semantic.DeepEqual(existing.Rules, clusterRole.Rules) {
+			// The rules follow the flags of the latest run, so that opting in
+			// to (or out of) migrations and power actions takes effect
+			existing.Rules = clusterRole.Rules
+			if _, err := crClient.Update(context.Background(), existing, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to update the rules of ClusterRole %s: %w", crName, err)
+			}
+			fmt.Printf("Updated the rules of ClusterRole %s\n", crName)
 		} else {
 			fmt.Printf("ClusterRole %s already exists\n", crName)
 		}
@@ -351,4 +397,6 @@ func init() {
 
 	setupCmd.Flags().String("service-account-name", defaultSAName, "ServiceAccount name to create")
 	setupCmd.Flags().String("namespace", defaultNamespace, "Namespace to create the ServiceAccount in")
+	setupCmd.Flags().Bool("allow-migrations", false, "Also grant create/delete on VirtualMachineInstanceMigrations so the API can start and cancel migrations (updates an existing ClusterRole; leaving it out on a re-run revokes them)")
+	setupCmd.Flags().Bool("allow-power-actions", false, "Also grant the KubeVirt start/stop/restart/pause/unpause subresources so the API can change VM power state (updates an existing ClusterRole; leaving it out on a re-run revokes them)")
 }
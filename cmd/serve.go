@@ -1,12 +1,21 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/eventbus"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/placement"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/rebalancer"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/replay"
 	"github.com/cldmnky/summit-connect-stockholm-2025/internal/server"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/simulator"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
 )
 
 var serveCmd = &cobra.Command{
@@ -23,11 +32,33 @@ VM Watcher:
 When enabled with --watch-vms, the server will monitor KubeVirt VMs across all clusters
 defined in config/datacenters.yaml and automatically update the database when VMs change.
 
+Simulation:
+With --simulate, every cluster in config/datacenters.yaml is replaced by an in-process
+fake cluster with synthetic VMs, so the watcher, migrations and power actions can be
+demoed without any network access. Simulated VMs boot, crash and come back on their own
+and migrations step through realistic phases, failing at the configured rate.
+
+Record and replay:
+--record <file> writes every event the cluster watchers receive to an NDJSON file.
+--replay <file> serves the clusters from such a recording instead, feeding the events
+through the normal conversion and store path at real time, faster (--replay-speed) or
+one event per press of Enter (--replay-step).
+
+Multiple replicas:
+Each replica only sees the events of its own watcher. Give every replica the base URLs
+of all the others with --peer, and a shared --bus-secret (required), to post events to each other
+so the SSE and WebSocket clients of any replica see them all.
+
 Examples:
   summit-connect serve backend                    # Start backend server on port 3001
   summit-connect serve backend -p 8080            # Start backend server on port 8080
   summit-connect serve backend --watch-vms        # Start with VM watcher enabled
-  summit-connect serve backend -w -p 8080         # Start on port 8080 with VM watcher`,
+  summit-connect serve backend -w -p 8080         # Start on port 8080 with VM watcher
+  summit-connect serve backend --simulate         # Start with simulated clusters
+  summit-connect serve backend --simulate --sim-migration-duration 5s --sim-migration-failure-rate 0
+  summit-connect serve backend -w --record demo.ndjson   # Record what the watchers see
+  summit-connect serve backend --replay demo.ndjson --replay-speed 10
+  summit-connect serve backend -w --peer http://replica-b:3001 --bus-secret s3cret`,
 	Args:      cobra.ExactArgs(1),
 	ValidArgs: []string{"backend"},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -46,12 +77,33 @@ Examples:
 			configPath, _ := cmd.Flags().GetString("config")
 			// VM watcher flag
 			watchVMs, _ := cmd.Flags().GetBool("watch-vms")
+			// Simulated clusters run through the VM watcher
+			simulate, _ := cmd.Flags().GetBool("simulate")
+			// Replayed clusters too
+			replayPath, _ := cmd.Flags().GetString("replay")
+			if simulate && replayPath != "" {
+				log.Fatalf("--simulate and --replay can't be combined")
+			}
+			if simulate || replayPath != "" {
+				watchVMs = true
+			}
 
 			if dbPath != "" {
 				os.Setenv("SUMMIT_DB", dbPath)
 			}
+			// The bus replaces the event hub, before anything broadcasts
+			if peers, _ := cmd.Flags().GetStringArray("peer"); len(peers) > 0 {
+				if secret, _ := cmd.Flags().GetString("bus-secret"); secret == "" {
+					log.Fatalf("--peer needs a --bus-secret, so that only the replicas can post events to each other")
+				}
+				if err := server.StartEventBus(eventBusOptionsFromFlags(cmd, peers)); err != nil {
+					log.Fatalf("invalid event bus flags: %v", err)
+				}
+				log.Printf("Sharing events with %d peer replicas", len(peers))
+			}
 			log.Printf("Starting backend API server on port %d", port)
 			log.Printf("VM watcher enabled: %v", watchVMs)
+			log.Printf("Cluster simulation enabled: %v", simulate)
 
 			// When VM watcher is enabled, we want to initialize with datacenter structure
 			// but let the watcher populate the actual VMs from KubeVirt clusters
@@ -72,10 +124,37 @@ Examples:
 
 				log.Printf("Initializing VM watcher with config: %s", datacenterConfigPath)
 				// Initialize VM watcher to populate real VMs
-				if err := server.InitVMWatcher(datacenterConfigPath); err != nil {
+				watcherOptions := watcher.DefaultOptions()
+				watcherOptions.MigrationStatusRetention, _ = cmd.Flags().GetDuration("migration-status-retention")
+				if simulate {
+					watcherOptions.ClientFactory = simulator.New(simulatorOptions(cmd)).ClientFactory
+				}
+				var player *replay.Player
+				if replayPath != "" {
+					events, err := watcher.LoadRecording(replayPath)
+					if err != nil {
+						log.Fatalf("failed to load replay: %v", err)
+					}
+					player = replay.New(events, replayOptions(cmd))
+					watcherOptions.ClientFactory = player.ClientFactory
+				}
+				if recordPath, _ := cmd.Flags().GetString("record"); recordPath != "" {
+					recorder, err := watcher.NewRecorder(recordPath)
+					if err != nil {
+						log.Fatalf("failed to start recording: %v", err)
+					}
+					log.Printf("Recording cluster watch events to %s", recordPath)
+					watcherOptions.Recorder = recorder
+				}
+				if err := server.InitVMWatcher(datacenterConfigPath, watcherOptions); err != nil {
 					log.Fatalf("failed to init VM watcher: %v", err)
 				}
 				log.Printf("VM watcher initialization completed")
+
+				if player != nil {
+					step, _ := cmd.Flags().GetBool("replay-step")
+					go runReplay(player, replayPath, step)
+				}
 			} else {
 				// Without VM watcher, use traditional initialization
 				// If a config path was provided use that (Viper will handle it). Otherwise leave seedPath empty
@@ -89,6 +168,28 @@ Examples:
 				}
 			}
 
+			overcommitRatio, _ := cmd.Flags().GetFloat64("overcommit-ratio")
+			server.SetOvercommitRatio(overcommitRatio)
+
+			affinityLabel, _ := cmd.Flags().GetString("affinity-label")
+			server.SetPlacementOptions(placement.Options{AffinityLabel: affinityLabel})
+			strategy, _ := cmd.Flags().GetString("placement-strategy")
+			if err := server.SetPlacementStrategy(strategy); err != nil {
+				log.Fatalf("invalid --placement-strategy: %v", err)
+			}
+
+			if rebalance, _ := cmd.Flags().GetBool("rebalance"); rebalance {
+				config, err := rebalancerConfigFromFlags(cmd)
+				if err != nil {
+					log.Fatalf("invalid rebalancer flags: %v", err)
+				}
+				if err := server.StartRebalancer(config); err != nil {
+					log.Fatalf("failed to start rebalancer: %v", err)
+				}
+			}
+
+			server.StartScheduler()
+			server.StartWebhooks()
 			server.StartBackendServer(port)
 		default:
 			cmd.Help()
@@ -102,4 +203,108 @@ func init() {
 	serveCmd.Flags().StringP("db", "d", "/tmp/summit-connect.db", "Path to BoltDB file to use for persistence")
 	serveCmd.Flags().StringP("config", "c", "", "Optional config file (yaml/json/env) used to seed the DB via viper")
 	serveCmd.Flags().BoolP("watch-vms", "w", false, "Enable VM watcher to monitor KubeVirt VMs across clusters")
+	serveCmd.Flags().Float64("overcommit-ratio", 1, "How far migrations may allocate past a datacenter's or cluster's capacity (1 = no overcommit)")
+	serveCmd.Flags().String("placement-strategy", placement.DefaultStrategy, "Default strategy for automatic migrations (balance-count, balance-cpu, balance-memory, random, round-robin, affinity, anti-affinity)")
+	serveCmd.Flags().String("affinity-label", placement.DefaultOptions().AffinityLabel, "VM label grouping VMs for the affinity and anti-affinity strategies")
+	rebalancerDefaults := rebalancer.DefaultConfig()
+	serveCmd.Flags().Bool("rebalance", false, "Start the background rebalancer, which migrates VMs while the datacenters are out of balance")
+	serveCmd.Flags().Duration("rebalance-interval", time.Duration(rebalancerDefaults.Interval), "Time between rebalancer checks")
+	serveCmd.Flags().Int("rebalance-threshold", rebalancerDefaults.Threshold, "Spread in VM count between the fullest and emptiest datacenter that triggers rebalancing")
+	serveCmd.Flags().Int("rebalance-max-concurrent", rebalancerDefaults.MaxConcurrent, "Most migrations in flight at once while rebalancing")
+	serveCmd.Flags().Duration("rebalance-cooldown", time.Duration(rebalancerDefaults.Cooldown), "How long a VM stays put after migrating before the rebalancer moves it again")
+	serveCmd.Flags().StringArray("rebalance-blackout", nil, "Window in which the rebalancer starts no migrations, as \"[days ]HH:MM-HH:MM\" (e.g. \"sat,sun 00:00-24:00\"); repeatable")
+	serveCmd.Flags().Duration("migration-status-retention", watcher.DefaultOptions().MigrationStatusRetention, "How long a finished migration's status is kept on the VM before it is cleared")
+
+	simDefaults := simulator.DefaultOptions()
+	serveCmd.Flags().Bool("simulate", false, "Replace the configured clusters with in-process simulated clusters (implies --watch-vms)")
+	serveCmd.Flags().Int("sim-vms-per-cluster", simDefaults.VMsPerCluster, "Number of VMs each simulated cluster starts with")
+	serveCmd.Flags().Duration("sim-migration-duration", simDefaults.MigrationDuration, "How long a simulated migration takes")
+	serveCmd.Flags().Float64("sim-migration-failure-rate", simDefaults.MigrationFailureRate, "Probability (0-1) that a simulated migration fails")
+	serveCmd.Flags().Duration("sim-crash-interval", simDefaults.CrashInterval, "Mean time between simulated VM crashes and boots per cluster (0 disables them)")
+	serveCmd.Flags().Int64("sim-seed", 0, "Random seed for the simulation (0 uses the current time)")
+
+	serveCmd.Flags().StringArray("peer", nil, "Base URL of another replica to share events with, e.g. http://replica-b:3001; repeatable")
+	serveCmd.Flags().String("replica-id", "", "Name of this replica among its peers (default: the hostname)")
+	serveCmd.Flags().String("bus-secret", "", "Token the replicas send each other with their events; required with --peer")
+
+	serveCmd.Flags().String("record", "", "Record every cluster watch event to this NDJSON file")
+	serveCmd.Flags().String("replay", "", "Serve the clusters from a recording made with --record (implies --watch-vms)")
+	serveCmd.Flags().Float64("replay-speed", 1, "Replay speed: 1 is real time, 10 ten times faster, 0 as fast as possible")
+	serveCmd.Flags().Bool("replay-step", false, "Replay one event each time Enter is pressed")
+}
+
+// simulatorOptions reads the simulation flags
+func simulatorOptions(cmd *cobra.Command) simulator.Options {
+	options := simulator.DefaultOptions()
+	options.VMsPerCluster, _ = cmd.Flags().GetInt("sim-vms-per-cluster")
+	options.MigrationDuration, _ = cmd.Flags().GetDuration("sim-migration-duration")
+	options.MigrationFailureRate, _ = cmd.Flags().GetFloat64("sim-migration-failure-rate")
+	options.CrashInterval, _ = cmd.Flags().GetDuration("sim-crash-interval")
+	options.Seed, _ = cmd.Flags().GetInt64("sim-seed")
+	return options
+}
+
+// replayOptions reads the replay flags
+func replayOptions(cmd *cobra.Command) replay.Options {
+	var options replay.Options
+	options.Speed, _ = cmd.Flags().GetFloat64("replay-speed")
+	options.Step, _ = cmd.Flags().GetBool("replay-step")
+	return options
+}
+
+// runReplay plays a recording, stepping on Enter in step mode
+func runReplay(player *replay.Player, path string, step bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if step {
+		go func() {
+			log.Printf("Step mode: press Enter to replay the next event")
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				player.Next(ctx)
+			}
+		}()
+	}
+
+	if err := player.Run(ctx); err != nil {
+		log.Printf("Replay of %s stopped: %v", path, err)
+	}
+}
+
+// eventBusOptionsFromFlags returns the event bus options of the --peer,
+// --replica-id and --bus-secret flags
+func eventBusOptionsFromFlags(cmd *cobra.Command, peers []string) eventbus.Options {
+	options := eventbus.DefaultOptions()
+	options.Peers = peers
+	options.Self, _ = cmd.Flags().GetString("replica-id")
+	if options.Self == "" {
+		options.Self, _ = os.Hostname()
+	}
+	options.Secret, _ = cmd.Flags().GetString("bus-secret")
+	return options
+}
+
+// rebalancerConfigFromFlags builds the rebalancer configuration from the
+// --rebalance-* flags; the strategy follows --placement-strategy
+func rebalancerConfigFromFlags(cmd *cobra.Command) (rebalancer.Config, error) {
+	config := rebalancer.DefaultConfig()
+	config.Strategy = ""
+
+	interval, _ := cmd.Flags().GetDuration("rebalance-interval")
+	config.Interval = rebalancer.Duration(interval)
+	config.Threshold, _ = cmd.Flags().GetInt("rebalance-threshold")
+	config.MaxConcurrent, _ = cmd.Flags().GetInt("rebalance-max-concurrent")
+	cooldown, _ := cmd.Flags().GetDuration("rebalance-cooldown")
+	config.Cooldown = rebalancer.Duration(cooldown)
+
+	blackouts, _ := cmd.Flags().GetStringArray("rebalance-blackout")
+	for _, blackout := range blackouts {
+		window, err := rebalancer.ParseWindow(blackout)
+		if err != nil {
+			return config, err
+		}
+		config.Blackouts = append(config.Blackouts, window)
+	}
+	return config, nil
 }
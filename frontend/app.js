@@ -10,6 +10,8 @@ class StockholmDatacentersMap {
         this.migrationOverlays = new Map(); // Track migration overlay elements
         this.forceGraphs = new Map(); // Track force-directed graphs for each datacenter
         this.migrations = []; // Track migration data
+        this.clusterNodes = new Map(); // cluster name -> Map of node name -> node
+        this.utilization = new Map(); // datacenter id -> utilization from /api/v1/status
         this.currentLayer = 'satellite';
         this.layers = {};
         
@@ -561,7 +563,8 @@ class StockholmDatacentersMap {
             if (vm.ip) kubeVirtPieces.push(`IP: ${vm.ip}`);
             if (vm.nodeName) kubeVirtPieces.push(`Node: ${vm.nodeName}`);
             if (vm.ready !== undefined) kubeVirtPieces.push(`Ready: ${vm.ready ? '✓' : '✗'}`);
-            if (vm.age) kubeVirtPieces.push(`Age: ${vm.age}`);
+            const vmAge = this.formatVMAge(vm);
+            if (vmAge) kubeVirtPieces.push(`Age: ${vmAge}`);
 
             let statusDisplay = vmStatus;
             if (vm.migrationStatus === "migrating" || vm.status === "migrating" || vm.status === "waitingforreceiver") {
@@ -669,18 +672,25 @@ class StockholmDatacentersMap {
         const runningVMs = vmsList.filter(vm => vm.status === 'running' || vm.phase === 'Running').length;
         const totalVMs = vmsList.length;
         
+        const maintenance = datacenter.maintenance;
+        const failure = datacenter.failure;
+        const statusClass = failure ? ' down' : (maintenance ? ' maintenance' : '');
+        const statusText = failure ? (failure.state === 'recovering' ? 'Recovering' : 'Down') : (maintenance ? 'Maintenance' : 'Active');
+        const statusReason = (failure && failure.reason) || (maintenance && maintenance.reason);
+        
         card.innerHTML = `
             <div class="datacenter-header" onclick="window.app.focusOnDatacenter('${datacenter.id}')">
                 <h4 class="datacenter-title">${datacenter.name}</h4>
-                <div class="datacenter-status">
+                <div class="datacenter-status${statusClass}"${statusReason ? ` title="${statusReason}"` : ''}>
                     <div class="status-indicator"></div>
-                    <span>Active</span>
+                    <span>${statusText}</span>
                 </div>
             </div>
             <div class="datacenter-meta">
                 📍 ${datacenter.location}<br>
                 💻 ${totalVMs} VMs (${runningVMs} running)<br>
                 ⚙️ ${clusters.length} cluster${clusters.length !== 1 ? 's' : ''}
+                ${this.formatUtilization(datacenter.id)}
             </div>
         `;
 
@@ -716,6 +726,28 @@ class StockholmDatacentersMap {
         return card;
     }
 
+    // Utilization line for a datacenter card; empty when its capacity is unknown
+    formatUtilization(datacenterId) {
+        const utilization = this.utilization.get(datacenterId);
+        if (!utilization || !utilization.capacity) return '';
+        const parts = [];
+        if (utilization.capacity.cpu > 0) parts.push(`CPU ${Math.round(utilization.cpuPercent)}%`);
+        if (utilization.capacity.memory > 0) parts.push(`RAM ${Math.round(utilization.memoryPercent)}%`);
+        if (utilization.capacity.disk > 0) parts.push(`Disk ${Math.round(utilization.diskPercent)}%`);
+        return parts.length > 0 ? `<br>📊 ${parts.join(' • ')}` : '';
+    }
+
+    async loadUtilization() {
+        try {
+            const resp = await fetch('/api/v1/status', { cache: 'no-store' });
+            if (!resp.ok) return;
+            const status = await resp.json();
+            this.utilization = new Map((status.datacenter_utilization || []).map(u => [u.datacenterId, u]));
+        } catch (error) {
+            console.warn('Error loading utilization:', error);
+        }
+    }
+
     createClusterCard(clusterName, vms, datacenterId) {
         const clusterCard = document.createElement('div');
         clusterCard.className = 'cluster-card';
@@ -765,9 +797,84 @@ class StockholmDatacentersMap {
         }
         
         clusterCard.appendChild(vmsList);
+
+        const nodes = this.clusterNodes.get(clusterName);
+        if (nodes === undefined) {
+            this.loadClusterNodes(clusterName);
+        } else if (nodes.size > 0) {
+            clusterCard.insertBefore(this.createNodePlacement(nodes, vms), vmsList);
+        }
         return clusterCard;
     }
 
+    // Per-node placement strip: one chip per node with the VMs running on
+    // it. Nodes that are the source or target of an active migration are
+    // highlighted.
+    createNodePlacement(nodes, vms) {
+        const active = this.migrations.filter(m => !m.completed);
+        const sources = new Set(active.map(m => m.sourceNode).filter(Boolean));
+        const targets = new Set(active.map(m => m.targetNode).filter(Boolean));
+
+        const strip = document.createElement('div');
+        strip.className = 'node-placement';
+        Array.from(nodes.values())
+            .sort((a, b) => a.name.localeCompare(b.name))
+            .forEach(node => {
+                const placed = vms.filter(vm => vm.nodeName === node.name);
+                const classes = ['node-chip'];
+                if (!node.ready) classes.push('not-ready');
+                if (!node.schedulable) classes.push('cordoned');
+                if (sources.has(node.name)) classes.push('migration-source');
+                if (targets.has(node.name)) classes.push('migration-target');
+
+                const chip = document.createElement('div');
+                chip.className = classes.join(' ');
+                chip.title = [
+                    node.name,
+                    node.zone ? `Zone: ${node.zone}` : '',
+                    `${node.allocatableCpu} CPU • ${Math.round(node.allocatableMemory / 1024)}GB RAM allocatable`,
+                    node.ready ? (node.schedulable ? 'Ready' : 'Ready, cordoned') : 'Not ready',
+                    placed.length > 0 ? `VMs: ${placed.map(vm => vm.name).join(', ')}` : 'No VMs',
+                ].filter(Boolean).join('\n');
+                chip.innerHTML = `
+                    <span class="node-chip-name">${node.name}</span>
+                    <span class="node-chip-vms">${placed.map(vm => `<span class="node-chip-vm ${this.getVMStatusClass(vm.status || vm.phase || "unknown", vm)}"></span>`).join('')}</span>
+                `;
+                strip.appendChild(chip);
+            });
+        return strip;
+    }
+
+    async loadClusterNodes(clusterName) {
+        // Mark as loading so concurrent renders don't fetch again
+        this.clusterNodes.set(clusterName, new Map());
+        try {
+            const resp = await fetch(`/api/v1/clusters/${encodeURIComponent(clusterName)}/nodes`, { cache: 'no-store' });
+            if (!resp.ok) return;
+            const nodes = await resp.json();
+            if (!Array.isArray(nodes) || nodes.length === 0) return;
+            this.clusterNodes.set(clusterName, new Map(nodes.map(node => [node.name, node])));
+            this.renderDatacenterView(this.currentPopupDcId);
+        } catch (error) {
+            console.warn(`Error loading nodes of cluster ${clusterName}:`, error);
+        }
+    }
+
+    applyNodeEvent(type, payload) {
+        const clusterName = payload.cluster;
+        if (!clusterName) return;
+        if (!this.clusterNodes.has(clusterName)) {
+            this.clusterNodes.set(clusterName, new Map());
+        }
+        const nodes = this.clusterNodes.get(clusterName);
+        if (type === 'node:removed') {
+            nodes.delete(payload.nodeName);
+        } else if (payload.node) {
+            nodes.set(payload.node.name, payload.node);
+        }
+        this.renderDatacenterView(this.currentPopupDcId);
+    }
+
     createVMItem(vm) {
         const vmItem = document.createElement('div');
         vmItem.className = 'vm-item';
@@ -793,18 +900,68 @@ class StockholmDatacentersMap {
                 <div class="vm-details">
                     ${resources.join(' • ')}
                     ${vm.nodeName ? ` • ${vm.nodeName}` : ''}
-                    ${vm.age ? ` • ${vm.age}` : ''}
+                    ${this.formatVMAge(vm) ? ` • ${this.formatVMAge(vm)}` : ''}
                 </div>
             </div>
             <div class="vm-status">
                 <div class="vm-status-icon ${statusClass}"></div>
                 <span>${vmStatusText}</span>
             </div>
+            ${this.renderVMPowerActions(vm)}
         `;
+
+        vmItem.querySelectorAll('.vm-power-action').forEach(button => {
+            button.addEventListener('click', (event) => {
+                event.stopPropagation();
+                this.runVMPowerAction(vm.id, button.dataset.action);
+            });
+        });
         
         return vmItem;
     }
 
+    renderVMPowerActions(vm) {
+        // Offer only the actions that apply to the VM's current state
+        const actionsByStatus = {
+            stopped: ['start'],
+            running: ['stop', 'restart', 'pause'],
+            paused: ['unpause', 'stop'],
+        };
+        const actions = actionsByStatus[vm.status] || [];
+        if (actions.length === 0 || vm.migrationStatus === 'migrating') return '';
+        return `
+            <div class="vm-power-actions">
+                ${actions.map(action => `<button class="vm-power-action" data-action="${action}" title="${action} ${vm.name}">${action}</button>`).join('')}
+            </div>
+        `;
+    }
+
+    async runVMPowerAction(vmId, action) {
+        try {
+            const response = await fetch(`/api/v1/vms/${encodeURIComponent(vmId)}/${action}`, { method: 'POST' });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            console.log(`Requested ${action} of VM ${vmId}`);
+        } catch (error) {
+            console.error(`Failed to ${action} VM ${vmId}:`, error);
+            alert(`Failed to ${action} VM: ${error.message}`);
+        }
+    }
+
+    formatVMAge(vm) {
+        // Age is derived client-side from the creation timestamp so it never goes stale
+        if (!vm.createdAt) return '';
+        const ageMs = Date.now() - new Date(vm.createdAt).getTime();
+        if (isNaN(ageMs) || ageMs < 0) return '';
+        const minutes = Math.floor(ageMs / 60000);
+        if (minutes < 60) return `${minutes}m`;
+        const hours = Math.floor(minutes / 60);
+        if (hours < 24) return `${hours}h`;
+        return `${Math.floor(hours / 24)}d`;
+    }
+
     getVMStatusClass(status, vm) {
         if (vm.migrationStatus === 'migrating' || vm.status === 'migrating' || vm.status === 'waitingforreceiver') {
             return 'migrating';
@@ -881,13 +1038,34 @@ class StockholmDatacentersMap {
         this._sseEnabled = true;
         this._sseConnected = false;
         this._sseSource = null;
+        // ID of the last event received, so a reconnect replays what was missed
+        this._sseLastEventId = null;
+        // Events the map reacts to; the server only sends these, named
+        // after their type
+        this._sseEvents = [
+            'node:added', 'node:updated', 'node:removed',
+            'vm:added', 'vm:updated', 'vm:removed', 'vm:migrated', 'vm:power',
+            'migration:added', 'migration:updated', 'migration:removed', 'migration:logical',
+            'rebalancer:started', 'rebalancer:stopped', 'rebalancer:decision',
+            'schedule:created', 'schedule:updated', 'schedule:deleted', 'schedule:run',
+            'datacenter:maintenance', 'operation:created', 'operation:updated',
+            'failover:started', 'failover:recovering', 'failover:recovered',
+            'failover:datacenter-failed', 'failover:vm-restarted', 'failover:vm-unavailable',
+            'failover:vm-unrecoverable', 'failover:failover-complete', 'failover:datacenter-recovering',
+            'failover:vm-failed-back', 'failover:datacenter-recovered',
+        ];
         this.connectSSE();
     }
 
     connectSSE() {
         if (!this._sseEnabled) return;
         try {
-            const es = new EventSource(this._sseUrl);
+            // EventSource only sends Last-Event-ID when it reconnects by
+            // itself; we reconnect with a new one, so resume with ?since=
+            const resuming = this._sseLastEventId !== null;
+            let url = `${this._sseUrl}?types=${encodeURIComponent(this._sseEvents.join(','))}`;
+            if (resuming) url += `&since=${encodeURIComponent(this._sseLastEventId)}`;
+            const es = new EventSource(url);
             this._sseSource = es;
 
             es.onopen = () => {
@@ -900,25 +1078,34 @@ class StockholmDatacentersMap {
                 }
                 // Reset backoff
                 this._sseBackoff = 1000;
-                // Do an immediate fetch to ensure we're synced
-                this.fetchAndMergeDatacenters();
+                // Do an immediate fetch to ensure we're synced, unless the
+                // server replays the events we missed
+                if (!resuming) this.fetchAndMergeDatacenters();
             };
 
-            es.onmessage = (e) => {
+            // The server could not replay the events we missed
+            es.addEventListener('resync-required', (e) => {
+                console.log('[SSE] resync required, refreshing data');
+                if (e.lastEventId) this._sseLastEventId = e.lastEventId;
+                this.fetchAndMergeDatacenters();
+            });
+
+            const onEvent = (e) => {
+                if (e.lastEventId) this._sseLastEventId = e.lastEventId;
                 try {
                     const msg = JSON.parse(e.data);
-                    // For simplicity: on any VM/migration event ask client to refresh
-                    if (msg && msg.type) {
-                        const t = msg.type;
-                        if (t.startsWith('vm:') || t.startsWith('migration:') || t === 'datacenters:updated' || t === 'refresh') {
-                            console.log('[SSE] event received, refreshing data:', t);
-                            this.fetchAndMergeDatacenters();
-                        }
+                    if (e.type.startsWith('node:')) {
+                        this.applyNodeEvent(e.type, (msg && msg.payload) || {});
+                    } else {
+                        // For simplicity: on any other event ask client to refresh
+                        console.log('[SSE] event received, refreshing data:', e.type);
+                        this.fetchAndMergeDatacenters();
                     }
                 } catch (err) {
                     console.warn('[SSE] invalid event payload', err, e.data);
                 }
             };
+            for (const name of this._sseEvents) es.addEventListener(name, onEvent);
 
             es.onerror = (err) => {
                 console.warn('[SSE] error or disconnected', err);
@@ -968,6 +1155,7 @@ class StockholmDatacentersMap {
             const resp = await fetch('/api/v1/datacenters', { cache: 'no-store' });
             if (!resp.ok) throw new Error('Failed to fetch datacenters from API');
             const data = await resp.json();
+            await this.loadUtilization();
             const newDCs = data.datacenters || [];
 
             console.log('[DEBUG] Fetched data:', newDCs.length, 'datacenters');
@@ -2564,6 +2752,7 @@ class StockholmDatacentersMap {
                     </div>
                     <div class="migration-time">${timeAgo}</div>
                     ${duration ? `<div class="migration-duration">${duration}</div>` : ''}
+                    ${!migration.completed ? `<button class="migration-abort" title="Abort migration" onclick="event.stopPropagation(); app.abortMigration('${migration.migrationId || migration.id}')">Abort</button>` : ''}
                 </div>
             `;
         }).join('');
@@ -2608,6 +2797,23 @@ class StockholmDatacentersMap {
         }
     }
 
+    async abortMigration(migrationId) {
+        if (!confirm(`Abort migration ${migrationId}?`)) return;
+        try {
+            const response = await fetch(`/api/v1/migrations/${encodeURIComponent(migrationId)}/abort`, { method: 'POST' });
+            const data = await response.json();
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            console.log(`Requested abort of migration ${migrationId}:`, data.aborted);
+            await this.loadMigrations();
+            this.renderMigrationList();
+        } catch (error) {
+            console.error(`Failed to abort migration ${migrationId}:`, error);
+            alert(`Failed to abort migration: ${error.message}`);
+        }
+    }
+
     calculateMigrationDuration(migration) {
         if (!migration.startTime) return null;
         
@@ -19,6 +19,10 @@ import (
 const (
 	defaultBucket    = "datacenters"
 	migrationsBucket = "migrations"
+	nodesBucket      = "nodes"
+	operationsBucket = "operations"
+	schedulesBucket  = "schedules"
+	webhooksBucket   = "webhooks"
 	defaultKey       = "collection"
 )
 
@@ -54,6 +58,22 @@ func NewStore(dbPath string, jsonSeedPath string) (models.Store, error) {
 			return err
 		}
 		_, err = tx.CreateBucketIfNotExists([]byte(migrationsBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(nodesBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(operationsBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(schedulesBucket))
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(webhooksBucket))
 		return err
 	})
 	if err != nil {
@@ -127,13 +147,15 @@ func (s *Store) InitializeFromVMWatcherConfig(configPath string) error {
 
 	// Define a temporary structure to read the VM watcher config
 	type WatcherDatacenter struct {
-		ID          string    `yaml:"id"`
-		Name        string    `yaml:"name"`
-		Location    string    `yaml:"location"`
-		Coordinates []float64 `yaml:"coordinates"`
+		ID          string            `yaml:"id"`
+		Name        string            `yaml:"name"`
+		Location    string            `yaml:"location"`
+		Coordinates []float64         `yaml:"coordinates"`
+		Capacity    *models.Resources `yaml:"capacity"`
 		Clusters    []struct {
-			Name       string `yaml:"name"`
-			Kubeconfig string `yaml:"kubeconfig"`
+			Name       string            `yaml:"name"`
+			Kubeconfig string            `yaml:"kubeconfig"`
+			Capacity   *models.Resources `yaml:"capacity"`
 		} `yaml:"clusters"`
 	}
 
@@ -158,17 +180,26 @@ func (s *Store) InitializeFromVMWatcherConfig(configPath string) error {
 	var datacenters []models.Datacenter
 	for _, wdc := range watcherConfig.Datacenters {
 		var clusterNames []string
+		var clusterCapacity map[string]models.Resources
 		for _, cluster := range wdc.Clusters {
 			clusterNames = append(clusterNames, cluster.Name)
+			if cluster.Capacity != nil {
+				if clusterCapacity == nil {
+					clusterCapacity = make(map[string]models.Resources)
+				}
+				clusterCapacity[cluster.Name] = *cluster.Capacity
+			}
 		}
 
 		datacenter := models.Datacenter{
-			ID:          wdc.ID,
-			Name:        wdc.Name,
-			Location:    wdc.Location,
-			Coordinates: wdc.Coordinates,
-			Clusters:    clusterNames,
-			VMs:         []models.VM{}, // Empty - will be populated by VM watcher
+			ID:              wdc.ID,
+			Name:            wdc.Name,
+			Location:        wdc.Location,
+			Coordinates:     wdc.Coordinates,
+			Clusters:        clusterNames,
+			VMs:             []models.VM{}, // Empty - will be populated by VM watcher
+			Capacity:        wdc.Capacity,
+			ClusterCapacity: clusterCapacity,
 		}
 		datacenters = append(datacenters, datacenter)
 	}
@@ -334,6 +365,55 @@ func (s *Store) UpdateDatacenter(id string, name *string, location *string, coor
 	return nil, fmt.Errorf("datacenter %s not found", id)
 }
 
+// SetDatacenterMaintenance sets or, with nil, clears a datacenter's maintenance
+func (s *Store) SetDatacenterMaintenance(id string, maintenance *models.Maintenance) (*models.Datacenter, error) {
+	s.mu.Lock()
+	for i := range s.data.Datacenters {
+		if s.data.Datacenters[i].ID == id {
+			s.data.Datacenters[i].Maintenance = maintenance
+			dc := s.data.Datacenters[i]
+			buf, err := json.Marshal(s.data)
+			s.mu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			if err := s.writeToDB(buf); err != nil {
+				return nil, err
+			}
+			return &dc, nil
+		}
+	}
+	s.mu.Unlock()
+	return nil, fmt.Errorf("datacenter %s not found", id)
+}
+
+// GetVM returns a copy of a VM in a datacenter
+func (s *Store) GetVM(dcID, vmID string) (*models.VM, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, dc := range s.data.Datacenters {
+		if dc.ID == dcID {
+			for _, vm := range dc.VMs {
+				if vm.ID == vmID {
+					// deep copy via JSON like GetDatacenters
+					buf, err := json.Marshal(vm)
+					if err != nil {
+						return nil, err
+					}
+					var copy models.VM
+					if err := json.Unmarshal(buf, &copy); err != nil {
+						return nil, err
+					}
+					return &copy, nil
+				}
+			}
+			return nil, fmt.Errorf("vm %s not found in datacenter %s", vmID, dcID)
+		}
+	}
+	return nil, fmt.Errorf("datacenter %s not found", dcID)
+}
+
 // UpdateVM updates fields of a VM in a datacenter (legacy method for backward compatibility)
 func (s *Store) UpdateVM(dcID, vmID string, name *string, status *string, cpu *int, memory *int, disk *int, cluster *string) (*models.VM, error) {
 	start := time.Now()
@@ -403,13 +483,25 @@ func (s *Store) UpdateVMComplete(dcID, vmID string, updatedVM *models.VM) (*mode
 					vm.CPU = updatedVM.CPU
 					vm.Memory = updatedVM.Memory
 					vm.Disk = updatedVM.Disk
+					vm.Disks = updatedVM.Disks
 					vm.Cluster = updatedVM.Cluster
 					vm.Namespace = updatedVM.Namespace
 					vm.Phase = updatedVM.Phase
 					vm.IP = updatedVM.IP
+					vm.IPs = updatedVM.IPs
 					vm.NodeName = updatedVM.NodeName
 					vm.Ready = updatedVM.Ready
-					vm.Age = updatedVM.Age
+					vm.CreatedAt = updatedVM.CreatedAt
+					vm.Labels = updatedVM.Labels
+					vm.Annotations = updatedVM.Annotations
+					vm.RunStrategy = updatedVM.RunStrategy
+					vm.Interfaces = updatedVM.Interfaces
+					vm.Conditions = updatedVM.Conditions
+					vm.GuestOS = updatedVM.GuestOS
+					vm.LastMigratedAt = updatedVM.LastMigratedAt
+					vm.MigrationStatus = updatedVM.MigrationStatus
+					vm.MigrationSource = updatedVM.MigrationSource
+					vm.MigrationTarget = updatedVM.MigrationTarget
 
 					copy := *vm
 					// marshal and write
@@ -842,3 +934,278 @@ func (s *Store) RemoveMigration(migrationID string) error {
 		return b.Delete([]byte(migrationID))
 	})
 }
+
+// Node tracking methods
+
+// nodeKey is the bucket key of a node: node names are only unique per cluster
+func nodeKey(cluster, name string) []byte {
+	return []byte(cluster + "/" + name)
+}
+
+// UpsertNode adds or replaces a node
+func (s *Store) UpsertNode(node models.Node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node.UpdatedAt = time.Now()
+	node.VMs = nil
+
+	buf, err := json.Marshal(node)
+	if err != nil {
+		return fmt.Errorf("failed to marshal node: %w", err)
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(nodesBucket))
+		if b == nil {
+			return fmt.Errorf("nodes bucket not found")
+		}
+		return b.Put(nodeKey(node.Cluster, node.Name), buf)
+	})
+}
+
+// RemoveNode removes a node
+func (s *Store) RemoveNode(cluster, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(nodesBucket))
+		if b == nil {
+			return fmt.Errorf("nodes bucket not found")
+		}
+		return b.Delete(nodeKey(cluster, name))
+	})
+}
+
+// GetNodes retrieves the nodes of a cluster, or of all clusters when
+// cluster is empty, with the VMs placed on each
+func (s *Store) GetNodes(cluster string) ([]models.Node, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var nodes []models.Node
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(nodesBucket))
+		if b == nil {
+			return fmt.Errorf("nodes bucket not found")
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var node models.Node
+			if err := json.Unmarshal(v, &node); err != nil {
+				log.Printf("Failed to unmarshal node %s: %v", string(k), err)
+				return nil // Continue to next node
+			}
+			if cluster == "" || node.Cluster == cluster {
+				node.VMs = s.data.VMsOnNode(node.Cluster, node.Name)
+				nodes = append(nodes, node)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// GetUtilization computes the utilization of every datacenter
+func (s *Store) GetUtilization() ([]models.DatacenterUtilization, error) {
+	nodes, err := s.GetNodes("")
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return models.ComputeUtilization(s.data, nodes), nil
+}
+
+// SaveOperation adds or replaces an operation
+func (s *Store) SaveOperation(op models.Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("failed to marshal operation: %w", err)
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(operationsBucket))
+		if b == nil {
+			return fmt.Errorf("operations bucket not found")
+		}
+		return b.Put([]byte(op.ID), buf)
+	})
+}
+
+// GetOperation retrieves an operation by ID
+func (s *Store) GetOperation(id string) (*models.Operation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var op models.Operation
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(operationsBucket))
+		if b == nil {
+			return fmt.Errorf("operations bucket not found")
+		}
+		v := b.Get([]byte(id))
+		if v == nil {
+			return fmt.Errorf("operation %s not found", id)
+		}
+		return json.Unmarshal(v, &op)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+// GetOperations retrieves all operations
+func (s *Store) GetOperations() ([]models.Operation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var operations []models.Operation
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(operationsBucket))
+		if b == nil {
+			return fmt.Errorf("operations bucket not found")
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var op models.Operation
+			if err := json.Unmarshal(v, &op); err != nil {
+				log.Printf("Failed to unmarshal operation %s: %v", string(k), err)
+				return nil // Continue to next operation
+			}
+			operations = append(operations, op)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return operations, nil
+}
+
+// SaveSchedule adds or replaces a schedule
+func (s *Store) SaveSchedule(schedule models.Schedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, err := json.Marshal(schedule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %w", err)
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(schedulesBucket))
+		if b == nil {
+			return fmt.Errorf("schedules bucket not found")
+		}
+		return b.Put([]byte(schedule.ID), buf)
+	})
+}
+
+// GetSchedules retrieves all schedules
+func (s *Store) GetSchedules() ([]models.Schedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var schedules []models.Schedule
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(schedulesBucket))
+		if b == nil {
+			return fmt.Errorf("schedules bucket not found")
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var schedule models.Schedule
+			if err := json.Unmarshal(v, &schedule); err != nil {
+				log.Printf("Failed to unmarshal schedule %s: %v", string(k), err)
+				return nil // Continue to next schedule
+			}
+			schedules = append(schedules, schedule)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// RemoveSchedule removes a schedule
+func (s *Store) RemoveSchedule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(schedulesBucket))
+		if b == nil {
+			return fmt.Errorf("schedules bucket not found")
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// SaveWebhook adds or replaces a webhook
+func (s *Store) SaveWebhook(webhook models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, err := json.Marshal(webhook)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(webhooksBucket))
+		if b == nil {
+			return fmt.Errorf("webhooks bucket not found")
+		}
+		return b.Put([]byte(webhook.ID), buf)
+	})
+}
+
+// GetWebhooks retrieves all webhooks
+func (s *Store) GetWebhooks() ([]models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var webhooks []models.Webhook
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(webhooksBucket))
+		if b == nil {
+			return fmt.Errorf("webhooks bucket not found")
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var webhook models.Webhook
+			if err := json.Unmarshal(v, &webhook); err != nil {
+				log.Printf("Failed to unmarshal webhook %s: %v", string(k), err)
+				return nil // Continue to next webhook
+			}
+			webhooks = append(webhooks, webhook)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// RemoveWebhook removes a webhook
+func (s *Store) RemoveWebhook(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(webhooksBucket))
+		if b == nil {
+			return fmt.Errorf("webhooks bucket not found")
+		}
+		return b.Delete([]byte(id))
+	})
+}
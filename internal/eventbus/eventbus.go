@@ -0,0 +1,360 @@
+// Package eventbus shares the events of a replica with its peers, so that
+// the clients of every replica see the events of all of them. Each replica
+// keeps its own event hub and POSTs the events that happen on it, in
+// batches, to the other replicas, retrying with exponential backoff while a
+// peer is down. Events received from a peer are only broadcast locally, so
+// every replica must list all the others.
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// Path is where a replica receives events, below a peer's base URL
+const Path = "/api/v1/bus/events"
+
+// HeaderToken carries the shared secret of the replicas
+const HeaderToken = "X-Summit-Bus-Token"
+
+// batchSize is the most events posted to a peer at once
+const batchSize = 100
+
+// ErrUnauthorized is returned for a batch without the shared secret
+var ErrUnauthorized = errors.New("invalid bus token")
+
+// Options configure a bus
+type Options struct {
+	// Self names this replica; it must be unique among the peers
+	Self string
+	// Peers are the base URLs of the other replicas
+	Peers []string
+	// Secret is the token the replicas send each other; without it every
+	// batch is rejected
+	Secret string
+	// QueueSize is how many events wait per peer before new ones are
+	// dropped
+	QueueSize int
+	// Backoff is the wait before retrying a failed post; it doubles after
+	// every failure up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout bounds a single post
+	Timeout time.Duration
+}
+
+// DefaultOptions returns the default bus options
+func DefaultOptions() Options {
+	return Options{
+		QueueSize:  1024,
+		Backoff:    500 * time.Millisecond,
+		MaxBackoff: 30 * time.Second,
+		Timeout:    5 * time.Second,
+	}
+}
+
+// Batch is what a replica posts to its peers
+type Batch struct {
+	Origin string `json:"origin"`
+	// Boot changes with every start of the origin, which numbers its
+	// events from 1 again
+	Boot   string  `json:"boot"`
+	Events []Event `json:"events"`
+}
+
+// Event is an event of a batch
+type Event struct {
+	Seq     uint64          `json:"seq"` // Number of the event at its origin
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Bus is an event hub that also shares its events with peer replicas
+type Bus struct {
+	*watcher.EventHub
+	options Options
+	client  *http.Client
+	boot    string
+	peers   []*peer
+
+	mu  sync.Mutex // Orders the events queued for the peers
+	seq uint64
+
+	receiveMu sync.Mutex
+	received  map[string]origin // Latest event received, by origin
+
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+var _ watcher.EventBus = (*Bus)(nil)
+
+// origin is what a bus has received from a peer
+type origin struct {
+	boot string
+	seq  uint64
+}
+
+// peer is a replica events are posted to
+type peer struct {
+	url   string
+	queue chan Event
+
+	mu        sync.Mutex
+	sent      uint64
+	failed    uint64
+	dropped   uint64
+	lastError string
+}
+
+// New creates a bus with its own hub. Events are queued for the peers
+// from the start and posted once Start is called.
+func New(options Options) *Bus {
+	defaults := DefaultOptions()
+	if options.QueueSize <= 0 {
+		options.QueueSize = defaults.QueueSize
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = defaults.Backoff
+	}
+	if options.MaxBackoff < options.Backoff {
+		options.MaxBackoff = options.Backoff
+	}
+
+	b := &Bus{
+		EventHub: watcher.NewEventHub(),
+		options:  options,
+		client:   &http.Client{Timeout: options.Timeout},
+		boot:     randomHex(8),
+		received: make(map[string]origin),
+	}
+	for _, url := range options.Peers {
+		b.peers = append(b.peers, &peer{
+			url:   strings.TrimSuffix(url, "/") + Path,
+			queue: make(chan Event, options.QueueSize),
+		})
+	}
+	return b
+}
+
+// Validate checks bus options
+func Validate(options Options) error {
+	if options.Self == "" {
+		return fmt.Errorf("a bus needs the name of its replica")
+	}
+	if options.Secret == "" {
+		return fmt.Errorf("a bus needs a secret shared by the replicas")
+	}
+	for _, url := range options.Peers {
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return fmt.Errorf("peer %q is not an http or https URL", url)
+		}
+	}
+	return nil
+}
+
+// BroadcastEvent broadcasts an event to the local clients and queues it
+// for the peers
+func (b *Bus) BroadcastEvent(typ string, payload interface{}) {
+	b.EventHub.BroadcastEvent(typ, payload)
+	if len(b.peers) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Event %s not shared with peers: %v", typ, err)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	event := Event{Seq: b.seq, Type: typ, Payload: data}
+	for _, p := range b.peers {
+		select {
+		case p.queue <- event:
+		default:
+			p.mu.Lock()
+			p.dropped++
+			p.mu.Unlock()
+		}
+	}
+}
+
+// Receive broadcasts the events of a batch posted by a peer to the local
+// clients. Events received before, such as those of a retried post, are
+// skipped.
+func (b *Bus) Receive(token string, body []byte) error {
+	if b.options.Secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(b.options.Secret)) != 1 {
+		return ErrUnauthorized
+	}
+	var batch Batch
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return fmt.Errorf("invalid batch: %w", err)
+	}
+	if batch.Origin == "" {
+		return fmt.Errorf("invalid batch: origin is required")
+	}
+	if batch.Origin == b.options.Self {
+		return nil
+	}
+
+	b.receiveMu.Lock()
+	defer b.receiveMu.Unlock()
+	last := b.received[batch.Origin]
+	if last.boot != batch.Boot {
+		last = origin{boot: batch.Boot}
+	}
+	for _, event := range batch.Events {
+		if event.Seq <= last.seq {
+			continue
+		}
+		last.seq = event.Seq
+		var payload interface{}
+		if len(event.Payload) > 0 {
+			if err := json.Unmarshal(event.Payload, &payload); err != nil {
+				log.Printf("Event %s from %s has an invalid payload: %v", event.Type, batch.Origin, err)
+			}
+		}
+		b.EventHub.BroadcastFrom(batch.Origin, event.Type, payload)
+	}
+	b.received[batch.Origin] = last
+	return nil
+}
+
+// Stats returns the state of the hub, its clients and the peers
+func (b *Bus) Stats() watcher.HubStats {
+	stats := b.EventHub.Stats()
+	for _, p := range b.peers {
+		p.mu.Lock()
+		stats.Peers = append(stats.Peers, watcher.PeerStats{
+			URL:       p.url,
+			Sent:      p.sent,
+			Failed:    p.failed,
+			Dropped:   p.dropped,
+			Queued:    len(p.queue),
+			LastError: p.lastError,
+		})
+		p.mu.Unlock()
+	}
+	return stats
+}
+
+// Start starts posting the queued events to the peers
+func (b *Bus) Start() {
+	b.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	for _, p := range b.peers {
+		b.done.Add(1)
+		go func(p *peer) {
+			defer b.done.Done()
+			b.send(ctx, p)
+		}(p)
+	}
+}
+
+// Stop stops posting; events still queued stay queued
+func (b *Bus) Stop() {
+	if b.cancel == nil {
+		return
+	}
+	b.cancel()
+	b.done.Wait()
+	b.cancel = nil
+}
+
+// send posts the events queued for a peer until ctx is done
+func (b *Bus) send(ctx context.Context, p *peer) {
+	for {
+		var batch []Event
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-p.queue:
+			batch = append(batch, event)
+		}
+	fill:
+		for len(batch) < batchSize {
+			select {
+			case event := <-p.queue:
+				batch = append(batch, event)
+			default:
+				break fill
+			}
+		}
+
+		backoff := b.options.Backoff
+		for {
+			err := b.post(ctx, p.url, batch)
+			p.mu.Lock()
+			if err == nil {
+				p.sent += uint64(len(batch))
+				p.lastError = ""
+			} else {
+				p.failed++
+				p.lastError = err.Error()
+			}
+			p.mu.Unlock()
+			if err == nil {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > b.options.MaxBackoff {
+				backoff = b.options.MaxBackoff
+			}
+		}
+	}
+}
+
+// post sends a batch to a peer
+func (b *Bus) post(ctx context.Context, url string, events []Event) error {
+	body, err := json.Marshal(Batch{Origin: b.options.Self, Boot: b.boot, Events: events})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderToken, b.options.Secret)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("peer answered %s", resp.Status)
+	}
+	return nil
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,13 @@
+package eventbus_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestEventBus(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Event Bus Suite")
+}
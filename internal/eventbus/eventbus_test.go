@@ -0,0 +1,236 @@
+package eventbus_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/eventbus"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// replica is an in-process server with a bus, which answers 503 while
+// down
+type replica struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	bus    *eventbus.Bus
+	down   bool
+}
+
+func (r *replica) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	bus, down := r.bus, r.down
+	r.mu.Unlock()
+	if down || req.URL.Path != eventbus.Path {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(req.Body)
+	switch err := bus.Receive(req.Header.Get(eventbus.HeaderToken), body); {
+	case errors.Is(err, eventbus.ErrUnauthorized):
+		w.WriteHeader(http.StatusUnauthorized)
+	case err != nil:
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (r *replica) setDown(down bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.down = down
+}
+
+var _ = Describe("Bus", func() {
+	options := func(name string) eventbus.Options {
+		options := eventbus.DefaultOptions()
+		options.Self = name
+		options.Secret = "s3cret"
+		options.Backoff = 10 * time.Millisecond
+		options.MaxBackoff = 20 * time.Millisecond
+		return options
+	}
+
+	// replicas starts servers whose buses all list each other
+	replicas := func(names ...string) []*replica {
+		result := []*replica{}
+		for range names {
+			r := &replica{}
+			r.server = httptest.NewServer(r)
+			DeferCleanup(r.server.Close)
+			result = append(result, r)
+		}
+		for i, name := range names {
+			opts := options(name)
+			for j, other := range result {
+				if j != i {
+					opts.Peers = append(opts.Peers, other.server.URL)
+				}
+			}
+			bus := eventbus.New(opts)
+			bus.Start()
+			DeferCleanup(bus.Stop)
+			result[i].mu.Lock()
+			result[i].bus = bus
+			result[i].mu.Unlock()
+		}
+		return result
+	}
+
+	// events returns the types of the events a client got so far
+	events := func(ch chan watcher.Event) func() []string {
+		var (
+			mu    sync.Mutex
+			types []string
+		)
+		go func() {
+			for event := range ch {
+				mu.Lock()
+				types = append(types, event.Origin+"/"+event.Type)
+				mu.Unlock()
+			}
+		}()
+		return func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string{}, types...)
+		}
+	}
+
+	register := func(bus *eventbus.Bus) chan watcher.Event {
+		ch := bus.Register()
+		DeferCleanup(bus.Unregister, ch)
+		return ch
+	}
+
+	It("shares events between replicas", func() {
+		rs := replicas("a", "b", "c")
+		onA, onB, onC := events(register(rs[0].bus)), events(register(rs[1].bus)), events(register(rs[2].bus))
+
+		rs[0].bus.BroadcastEvent("vm:added", map[string]interface{}{"datacenter": "dc-solna"})
+		rs[1].bus.BroadcastEvent("vm:removed", map[string]interface{}{"datacenter": "dc-kista"})
+
+		Eventually(onA).Should(ConsistOf("/vm:added", "b/vm:removed"))
+		Eventually(onB).Should(ConsistOf("a/vm:added", "/vm:removed"))
+		Eventually(onC).Should(ConsistOf("a/vm:added", "b/vm:removed"))
+		// Received events are not passed on again
+		Consistently(onC, 100*time.Millisecond).Should(HaveLen(2))
+	})
+
+	It("keeps payloads and datacenters of received events", func() {
+		rs := replicas("a", "b")
+		ch := rs[1].bus.RegisterFiltered(watcher.Filter{Datacenters: []string{"dc-solna"}})
+		DeferCleanup(rs[1].bus.Unregister, ch)
+
+		rs[0].bus.BroadcastEvent("vm:migrated", map[string]interface{}{"datacenters": []string{"dc-kista", "dc-solna"}, "vmId": "vm-1"})
+
+		var event watcher.Event
+		Eventually(ch).Should(Receive(&event))
+		Expect(event.Origin).To(Equal("a"))
+		Expect(event.ID).To(Equal(uint64(1)))
+
+		var data map[string]interface{}
+		Expect(json.Unmarshal([]byte(event.Data), &data)).To(Succeed())
+		Expect(data).To(HaveKeyWithValue("origin", "a"))
+		Expect(data).To(HaveKeyWithValue("payload", HaveKeyWithValue("vmId", "vm-1")))
+	})
+
+	It("retries while a peer is down", func() {
+		rs := replicas("a", "b")
+		onB := events(register(rs[1].bus))
+		rs[1].setDown(true)
+
+		rs[0].bus.BroadcastEvent("vm:added", nil)
+		rs[0].bus.BroadcastEvent("vm:updated", nil)
+		Eventually(func() uint64 { return rs[0].bus.Stats().Peers[0].Failed }).Should(BeNumerically(">=", 2))
+		Expect(rs[0].bus.Stats().Peers[0].LastError).To(ContainSubstring("503"))
+
+		rs[1].setDown(false)
+		Eventually(onB).Should(Equal([]string{"a/vm:added", "a/vm:updated"}))
+		Eventually(func() watcher.PeerStats { return rs[0].bus.Stats().Peers[0] }).Should(And(
+			HaveField("Sent", uint64(2)),
+			HaveField("LastError", ""),
+		))
+	})
+
+	It("drops events for a peer when its queue is full", func() {
+		opts := options("a")
+		opts.Peers = []string{"http://127.0.0.1:1"}
+		opts.QueueSize = 2
+		bus := eventbus.New(opts)
+
+		for i := 0; i < 5; i++ {
+			bus.BroadcastEvent("vm:updated", nil)
+		}
+		peer := bus.Stats().Peers[0]
+		Expect(peer.Queued).To(Equal(2))
+		Expect(peer.Dropped).To(Equal(uint64(3)))
+		Expect(bus.LastID()).To(Equal(uint64(5)))
+	})
+
+	Describe("Receive", func() {
+		var bus *eventbus.Bus
+
+		BeforeEach(func() {
+			bus = eventbus.New(options("b"))
+		})
+
+		batch := func(origin, boot string, seqs ...uint64) []byte {
+			b := eventbus.Batch{Origin: origin, Boot: boot}
+			for _, seq := range seqs {
+				b.Events = append(b.Events, eventbus.Event{Seq: seq, Type: "vm:updated", Payload: json.RawMessage(`{}`)})
+			}
+			body, err := json.Marshal(b)
+			Expect(err).NotTo(HaveOccurred())
+			return body
+		}
+
+		It("skips events received before", func() {
+			Expect(bus.Receive("s3cret", batch("a", "1", 1, 2))).To(Succeed())
+			Expect(bus.Receive("s3cret", batch("a", "1", 1, 2, 3))).To(Succeed())
+			Expect(bus.LastID()).To(Equal(uint64(3)))
+		})
+
+		It("starts over when the origin restarts", func() {
+			Expect(bus.Receive("s3cret", batch("a", "1", 1, 2))).To(Succeed())
+			Expect(bus.Receive("s3cret", batch("a", "2", 1))).To(Succeed())
+			Expect(bus.LastID()).To(Equal(uint64(3)))
+		})
+
+		It("ignores its own events", func() {
+			Expect(bus.Receive("s3cret", batch("b", "1", 1))).To(Succeed())
+			Expect(bus.LastID()).To(BeZero())
+		})
+
+		It("rejects batches without the secret", func() {
+			Expect(bus.Receive("wrong", batch("a", "1", 1))).To(MatchError(eventbus.ErrUnauthorized))
+			Expect(bus.Receive("s3cret", []byte("not json"))).To(HaveOccurred())
+			Expect(bus.Receive("s3cret", batch("", "1", 1))).To(HaveOccurred())
+			Expect(bus.LastID()).To(BeZero())
+		})
+	})
+
+	It("rejects every batch without a secret of its own", func() {
+		opts := options("b")
+		opts.Secret = ""
+		bus := eventbus.New(opts)
+		Expect(bus.Receive("", []byte(`{"origin":"a","boot":"1","events":[{"seq":1,"type":"vm:updated"}]}`))).To(MatchError(eventbus.ErrUnauthorized))
+		Expect(bus.LastID()).To(BeZero())
+	})
+
+	It("validates options", func() {
+		Expect(eventbus.Validate(eventbus.Options{Self: "a", Secret: "s3cret", Peers: []string{"http://b:3001"}})).To(Succeed())
+		Expect(eventbus.Validate(eventbus.Options{Secret: "s3cret", Peers: []string{"http://b:3001"}})).NotTo(Succeed())
+		Expect(eventbus.Validate(eventbus.Options{Self: "a", Peers: []string{"http://b:3001"}})).NotTo(Succeed())
+		Expect(eventbus.Validate(eventbus.Options{Self: "a", Secret: "s3cret", Peers: []string{"b:3001"}})).NotTo(Succeed())
+	})
+})
@@ -14,6 +14,10 @@ type MockStore struct {
 	mu          sync.RWMutex
 	data        *models.DatacenterCollection
 	migrations  map[string]models.Migration
+	nodes       map[string]models.Node
+	operations  map[string]models.Operation
+	schedules   map[string]models.Schedule
+	webhooks    map[string]models.Webhook
 	initialized bool
 	shouldError bool
 	errorMsg    string
@@ -24,6 +28,36 @@ func NewMockStore() *MockStore {
 	return &MockStore{
 		data:       &models.DatacenterCollection{Datacenters: []models.Datacenter{}},
 		migrations: make(map[string]models.Migration),
+		nodes:      make(map[string]models.Node),
+		operations: make(map[string]models.Operation),
+		schedules:  make(map[string]models.Schedule),
+		webhooks:   make(map[string]models.Webhook),
+	}
+}
+
+// SetDatacenterCapacity configures the capacity of a datacenter and,
+// optionally, of its clusters
+func (m *MockStore) SetDatacenterCapacity(dcID string, capacity *models.Resources, clusters map[string]models.Resources) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.data.Datacenters {
+		if m.data.Datacenters[i].ID == dcID {
+			m.data.Datacenters[i].Capacity = capacity
+			m.data.Datacenters[i].ClusterCapacity = clusters
+		}
+	}
+}
+
+// SetDatacenterClusters sets the clusters of a datacenter
+func (m *MockStore) SetDatacenterClusters(dcID string, clusters ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.data.Datacenters {
+		if m.data.Datacenters[i].ID == dcID {
+			m.data.Datacenters[i].Clusters = clusters
+		}
 	}
 }
 
@@ -100,6 +134,7 @@ func (m *MockStore) InitializeWithSampleData() {
 
 	// Initialize empty migrations map - tests will add their own migrations
 	m.migrations = make(map[string]models.Migration)
+	m.nodes = make(map[string]models.Node)
 }
 
 // GetDatacenters implements Store.GetDatacenters
@@ -118,6 +153,21 @@ func (m *MockStore) GetDatacenters() *models.DatacenterCollection {
 			Clusters:    make([]string, len(dc.Clusters)),
 			VMs:         make([]models.VM, len(dc.VMs)),
 		}
+		if dc.Capacity != nil {
+			capacity := *dc.Capacity
+			newDC.Capacity = &capacity
+		}
+		if dc.ClusterCapacity != nil {
+			newDC.ClusterCapacity = make(map[string]models.Resources, len(dc.ClusterCapacity))
+			for cluster, capacity := range dc.ClusterCapacity {
+				newDC.ClusterCapacity[cluster] = capacity
+			}
+		}
+		if dc.Maintenance != nil {
+			maintenance := *dc.Maintenance
+			maintenance.Evacuated = append([]models.EvacuatedVM(nil), dc.Maintenance.Evacuated...)
+			newDC.Maintenance = &maintenance
+		}
 		copy(newDC.Coordinates, dc.Coordinates)
 		copy(newDC.Clusters, dc.Clusters)
 		copy(newDC.VMs, dc.VMs)
@@ -153,6 +203,48 @@ func (m *MockStore) UpdateDatacenter(id string, name *string, location *string,
 	return nil, fmt.Errorf("datacenter %s not found", id)
 }
 
+// SetDatacenterMaintenance implements Store.SetDatacenterMaintenance
+func (m *MockStore) SetDatacenterMaintenance(id string, maintenance *models.Maintenance) (*models.Datacenter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shouldError {
+		return nil, errors.New(m.errorMsg)
+	}
+
+	for i := range m.data.Datacenters {
+		if m.data.Datacenters[i].ID == id {
+			m.data.Datacenters[i].Maintenance = maintenance
+			dc := m.data.Datacenters[i]
+			return &dc, nil
+		}
+	}
+
+	return nil, fmt.Errorf("datacenter %s not found", id)
+}
+
+// GetVM implements Store.GetVM
+func (m *MockStore) GetVM(dcID, vmID string) (*models.VM, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.shouldError {
+		return nil, errors.New(m.errorMsg)
+	}
+
+	for _, dc := range m.data.Datacenters {
+		if dc.ID == dcID {
+			for _, vm := range dc.VMs {
+				if vm.ID == vmID {
+					return &vm, nil
+				}
+			}
+			return nil, fmt.Errorf("vm %s not found in datacenter %s", vmID, dcID)
+		}
+	}
+	return nil, fmt.Errorf("datacenter %s not found", dcID)
+}
+
 // UpdateVM implements Store.UpdateVM
 func (m *MockStore) UpdateVM(dcID, vmID string, name *string, status *string, cpu *int, memory *int, disk *int, cluster *string) (*models.VM, error) {
 	m.mu.Lock()
@@ -454,3 +546,199 @@ func (m *MockStore) RemoveMigration(migrationID string) error {
 	delete(m.migrations, migrationID)
 	return nil
 }
+
+// UpsertNode implements Store.UpsertNode
+func (m *MockStore) UpsertNode(node models.Node) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shouldError {
+		return errors.New(m.errorMsg)
+	}
+
+	node.UpdatedAt = time.Now()
+	node.VMs = nil
+	m.nodes[node.Cluster+"/"+node.Name] = node
+	return nil
+}
+
+// RemoveNode implements Store.RemoveNode
+func (m *MockStore) RemoveNode(cluster, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shouldError {
+		return errors.New(m.errorMsg)
+	}
+
+	delete(m.nodes, cluster+"/"+name)
+	return nil
+}
+
+// GetNodes implements Store.GetNodes
+func (m *MockStore) GetNodes(cluster string) ([]models.Node, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.shouldError {
+		return nil, errors.New(m.errorMsg)
+	}
+
+	var nodes []models.Node
+	for _, node := range m.nodes {
+		if cluster == "" || node.Cluster == cluster {
+			node.VMs = m.data.VMsOnNode(node.Cluster, node.Name)
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes, nil
+}
+
+// GetUtilization implements Store.GetUtilization
+func (m *MockStore) GetUtilization() ([]models.DatacenterUtilization, error) {
+	nodes, err := m.GetNodes("")
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return models.ComputeUtilization(m.data, nodes), nil
+}
+
+// SaveOperation implements Store.SaveOperation
+func (m *MockStore) SaveOperation(op models.Operation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shouldError {
+		return errors.New(m.errorMsg)
+	}
+
+	op.Steps = append([]models.OperationStep(nil), op.Steps...)
+	m.operations[op.ID] = op
+	return nil
+}
+
+// GetOperation implements Store.GetOperation
+func (m *MockStore) GetOperation(id string) (*models.Operation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.shouldError {
+		return nil, errors.New(m.errorMsg)
+	}
+
+	op, exists := m.operations[id]
+	if !exists {
+		return nil, fmt.Errorf("operation %s not found", id)
+	}
+	op.Steps = append([]models.OperationStep(nil), op.Steps...)
+	return &op, nil
+}
+
+// GetOperations implements Store.GetOperations
+func (m *MockStore) GetOperations() ([]models.Operation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.shouldError {
+		return nil, errors.New(m.errorMsg)
+	}
+
+	operations := make([]models.Operation, 0, len(m.operations))
+	for _, op := range m.operations {
+		op.Steps = append([]models.OperationStep(nil), op.Steps...)
+		operations = append(operations, op)
+	}
+	return operations, nil
+}
+
+// SaveSchedule implements Store.SaveSchedule
+func (m *MockStore) SaveSchedule(schedule models.Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shouldError {
+		return errors.New(m.errorMsg)
+	}
+
+	schedule.History = append([]models.ScheduleRun(nil), schedule.History...)
+	m.schedules[schedule.ID] = schedule
+	return nil
+}
+
+// GetSchedules implements Store.GetSchedules
+func (m *MockStore) GetSchedules() ([]models.Schedule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.shouldError {
+		return nil, errors.New(m.errorMsg)
+	}
+
+	schedules := make([]models.Schedule, 0, len(m.schedules))
+	for _, schedule := range m.schedules {
+		schedule.History = append([]models.ScheduleRun(nil), schedule.History...)
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// RemoveSchedule implements Store.RemoveSchedule
+func (m *MockStore) RemoveSchedule(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shouldError {
+		return errors.New(m.errorMsg)
+	}
+
+	delete(m.schedules, id)
+	return nil
+}
+
+// SaveWebhook implements Store.SaveWebhook
+func (m *MockStore) SaveWebhook(webhook models.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shouldError {
+		return errors.New(m.errorMsg)
+	}
+
+	webhook.Events = append([]string(nil), webhook.Events...)
+	m.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+// GetWebhooks implements Store.GetWebhooks
+func (m *MockStore) GetWebhooks() ([]models.Webhook, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.shouldError {
+		return nil, errors.New(m.errorMsg)
+	}
+
+	webhooks := make([]models.Webhook, 0, len(m.webhooks))
+	for _, webhook := range m.webhooks {
+		webhook.Events = append([]string(nil), webhook.Events...)
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// RemoveWebhook implements Store.RemoveWebhook
+func (m *MockStore) RemoveWebhook(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shouldError {
+		return errors.New(m.errorMsg)
+	}
+
+	delete(m.webhooks, id)
+	return nil
+}
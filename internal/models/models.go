@@ -14,8 +14,10 @@ type Store interface {
 	// Datacenter operations
 	GetDatacenters() *DatacenterCollection
 	UpdateDatacenter(id string, name *string, location *string, coordinates *[]float64) (*Datacenter, error)
+	SetDatacenterMaintenance(id string, maintenance *Maintenance) (*Datacenter, error)
 
 	// VM operations
+	GetVM(dcID, vmID string) (*VM, error)
 	UpdateVM(dcID, vmID string, name *string, status *string, cpu *int, memory *int, disk *int, cluster *string) (*VM, error)
 	UpdateVMComplete(dcID, vmID string, updatedVM *VM) (*VM, error)
 	AddVM(dcID string, vm VM) (*VM, error)
@@ -32,6 +34,29 @@ type Store interface {
 	GetActiveMigrations() ([]Migration, error)
 	GetMigrationsByDirection(direction string) ([]Migration, error)
 	RemoveMigration(migrationID string) error
+
+	// Node operations
+	UpsertNode(node Node) error
+	RemoveNode(cluster, name string) error
+	GetNodes(cluster string) ([]Node, error)
+
+	// Capacity accounting
+	GetUtilization() ([]DatacenterUtilization, error)
+
+	// Operation operations
+	SaveOperation(op Operation) error
+	GetOperation(id string) (*Operation, error)
+	GetOperations() ([]Operation, error)
+
+	// Schedule operations
+	SaveSchedule(schedule Schedule) error
+	GetSchedules() ([]Schedule, error)
+	RemoveSchedule(id string) error
+
+	// Webhook operations
+	SaveWebhook(webhook Webhook) error
+	GetWebhooks() ([]Webhook, error)
+	RemoveWebhook(id string) error
 }
 
 // VM represents a virtual machine
@@ -41,20 +66,61 @@ type VM struct {
 	Status         string     `json:"status"`
 	CPU            int        `json:"cpu"`
 	Memory         int        `json:"memory"`
-	Disk           int        `json:"disk"`
+	Disk           int        `json:"disk"`            // Total disk size in GB
+	Disks          []Disk     `json:"disks,omitempty"` // Individual disks
 	LastMigratedAt *time.Time `json:"_lastMigratedAt,omitempty"`
 	// Migration tracking
 	MigrationStatus string `json:"migrationStatus,omitempty"` // "migrating", "completed", ""
 	MigrationSource string `json:"migrationSource,omitempty"` // Source cluster for migration
 	MigrationTarget string `json:"migrationTarget,omitempty"` // Target cluster for migration
 	// Kubernetes / KubeVirt fields
-	Cluster   string `json:"cluster,omitempty"`
-	Namespace string `json:"namespace,omitempty"`
-	Phase     string `json:"phase,omitempty"`
-	IP        string `json:"ip,omitempty"`
-	NodeName  string `json:"nodeName,omitempty"`
-	Ready     bool   `json:"ready,omitempty"`
-	Age       string `json:"age,omitempty"`
+	Cluster     string            `json:"cluster,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Phase       string            `json:"phase,omitempty"`
+	IP          string            `json:"ip,omitempty"`
+	IPs         []string          `json:"ips,omitempty"` // All interface IPs reported by the VMI
+	NodeName    string            `json:"nodeName,omitempty"`
+	Ready       bool              `json:"ready,omitempty"`
+	CreatedAt   *time.Time        `json:"createdAt,omitempty"` // VirtualMachine creation timestamp
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	RunStrategy string            `json:"runStrategy,omitempty"` // Always, RerunOnFailure, Manual, Halted, ...
+	Interfaces  []Interface       `json:"interfaces,omitempty"`
+	Conditions  []VMCondition     `json:"conditions,omitempty"`
+	// Guest agent information
+	GuestOS *GuestOSInfo `json:"guestOS,omitempty"`
+}
+
+// Interface represents a network interface of a running VM
+type Interface struct {
+	Name string   `json:"name"`
+	MAC  string   `json:"mac,omitempty"`
+	IPs  []string `json:"ips,omitempty"`
+}
+
+// VMCondition represents a VirtualMachine status condition
+type VMCondition struct {
+	Type               string     `json:"type"`
+	Status             string     `json:"status"`
+	Reason             string     `json:"reason,omitempty"`
+	Message            string     `json:"message,omitempty"`
+	LastTransitionTime *time.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// Disk represents a single disk attached to a VM
+type Disk struct {
+	Name         string `json:"name"`
+	SizeGB       int    `json:"sizeGb"`
+	StorageClass string `json:"storageClass,omitempty"`
+	VolumeType   string `json:"volumeType"` // persistentVolumeClaim, dataVolume, containerDisk, ...
+}
+
+// GuestOSInfo represents the guest operating system reported by the guest agent
+type GuestOSInfo struct {
+	Name          string `json:"name,omitempty"`
+	Version       string `json:"version,omitempty"`
+	ID            string `json:"id,omitempty"`
+	KernelRelease string `json:"kernelRelease,omitempty"`
 }
 
 // Datacenter represents a datacenter with its VMs
@@ -65,6 +131,72 @@ type Datacenter struct {
 	Coordinates []float64 `json:"coordinates"`
 	Clusters    []string  `json:"clusters,omitempty"`
 	VMs         []VM      `json:"vms"`
+	// Capacity is the configured capacity of the datacenter. When unset it
+	// is the sum of its cluster capacities.
+	Capacity *Resources `json:"capacity,omitempty"`
+	// ClusterCapacity is the configured capacity of clusters, by name.
+	// Clusters without one are sized from their nodes' allocatable resources.
+	ClusterCapacity map[string]Resources `json:"clusterCapacity,omitempty"`
+	// Maintenance is set while the datacenter is under maintenance
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+	// Failure is set while the datacenter is down in a failure simulation
+	Failure *Failure `json:"failure,omitempty"`
+}
+
+// AcceptsVMs reports whether VMs may be placed in the datacenter, which is
+// not the case during maintenance or a failure
+func (dc Datacenter) AcceptsVMs() bool {
+	return dc.Maintenance == nil && dc.Failure == nil
+}
+
+// Failure describes a simulated datacenter failure
+type Failure struct {
+	Since  time.Time `json:"since"`
+	State  string    `json:"state"` // failed or recovering
+	Reason string    `json:"reason,omitempty"`
+}
+
+// TimelineEntry is one event of a failover
+type TimelineEntry struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	VMID    string    `json:"vmId,omitempty"`
+	VMName  string    `json:"vmName,omitempty"`
+	FromDC  string    `json:"from,omitempty"`
+	ToDC    string    `json:"to,omitempty"`
+	Message string    `json:"message"`
+}
+
+// RecoveryPlan decides the order and place in which the VMs of a failed
+// datacenter are restarted. The first rule whose selector matches a VM's
+// labels applies; a VM's recovery-priority label overrides the priority.
+type RecoveryPlan struct {
+	Rules []RecoveryRule `json:"rules,omitempty"`
+}
+
+// RecoveryRule sets the restart priority and preferred target of VMs
+type RecoveryRule struct {
+	Selector string `json:"selector"`         // Label selector, e.g. "tier=db"
+	Priority int    `json:"priority"`         // Higher restarts first
+	Target   string `json:"target,omitempty"` // Preferred datacenter
+}
+
+// Maintenance describes a datacenter's maintenance. A datacenter under
+// maintenance is not picked as a migration target.
+type Maintenance struct {
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason,omitempty"`
+	// OperationID is the evacuation started on entering maintenance
+	OperationID string `json:"operationId,omitempty"`
+	// Evacuated lists the VMs moved out, so they can be brought back
+	Evacuated []EvacuatedVM `json:"evacuated,omitempty"`
+}
+
+// EvacuatedVM records where an evacuated VM went
+type EvacuatedVM struct {
+	VMID   string `json:"vmId"`
+	VMName string `json:"vmName"`
+	ToDC   string `json:"to"`
 }
 
 // DatacenterCollection represents the root structure
@@ -72,47 +204,392 @@ type DatacenterCollection struct {
 	Datacenters []Datacenter `json:"datacenters"`
 }
 
+// VMsOnNode returns the names of the VMs placed on a node of a cluster
+func (c *DatacenterCollection) VMsOnNode(cluster, node string) []string {
+	vms := []string{}
+	for _, dc := range c.Datacenters {
+		for _, vm := range dc.VMs {
+			if vm.Cluster == cluster && vm.NodeName == node {
+				vms = append(vms, vm.Name)
+			}
+		}
+	}
+	return vms
+}
+
+// Resources is an amount of compute and storage in the units VMs use: CPU
+// in whole cores, memory in MB and disk in GB
+type Resources struct {
+	CPU    int `json:"cpu"`
+	Memory int `json:"memory"`
+	Disk   int `json:"disk"`
+}
+
+// Add returns the sum of two amounts
+func (r Resources) Add(other Resources) Resources {
+	return Resources{CPU: r.CPU + other.CPU, Memory: r.Memory + other.Memory, Disk: r.Disk + other.Disk}
+}
+
+// AllocatedResources returns what a VM holds in its datacenter. Disks are
+// always allocated; CPU and memory only while the VM is not stopped.
+func (vm VM) AllocatedResources() Resources {
+	allocated := Resources{Disk: vm.Disk}
+	if vm.Status != "stopped" {
+		allocated.CPU = vm.CPU
+		allocated.Memory = vm.Memory
+	}
+	return allocated
+}
+
+// Utilization relates allocated resources to capacity. Percentages are 0
+// for dimensions without a known capacity.
+type Utilization struct {
+	Capacity      Resources `json:"capacity"`
+	Allocated     Resources `json:"allocated"`
+	CPUPercent    float64   `json:"cpuPercent"`
+	MemoryPercent float64   `json:"memoryPercent"`
+	DiskPercent   float64   `json:"diskPercent"`
+}
+
+// NewUtilization computes the utilization of a capacity
+func NewUtilization(capacity, allocated Resources) Utilization {
+	percent := func(used, total int) float64 {
+		if total <= 0 {
+			return 0
+		}
+		return float64(used) * 100 / float64(total)
+	}
+	return Utilization{
+		Capacity:      capacity,
+		Allocated:     allocated,
+		CPUPercent:    percent(allocated.CPU, capacity.CPU),
+		MemoryPercent: percent(allocated.Memory, capacity.Memory),
+		DiskPercent:   percent(allocated.Disk, capacity.Disk),
+	}
+}
+
+// DatacenterUtilization is the utilization of a datacenter and its clusters
+type DatacenterUtilization struct {
+	DatacenterID string `json:"datacenterId"`
+	Utilization
+	Clusters map[string]Utilization `json:"clusters,omitempty"`
+}
+
+// ComputeUtilization derives the utilization of every datacenter from its
+// VMs, its configured capacity and the allocatable resources of the nodes
+func ComputeUtilization(c *DatacenterCollection, nodes []Node) []DatacenterUtilization {
+	nodeCapacity := make(map[string]Resources)
+	for _, node := range nodes {
+		nodeCapacity[node.Cluster] = nodeCapacity[node.Cluster].Add(Resources{CPU: node.AllocatableCPU, Memory: node.AllocatableMemory})
+	}
+
+	result := make([]DatacenterUtilization, 0, len(c.Datacenters))
+	for _, dc := range c.Datacenters {
+		clusterCapacity := make(map[string]Resources)
+		clusterAllocated := make(map[string]Resources)
+		for _, cluster := range dc.Clusters {
+			if configured, ok := dc.ClusterCapacity[cluster]; ok {
+				clusterCapacity[cluster] = configured
+			} else {
+				clusterCapacity[cluster] = nodeCapacity[cluster]
+			}
+			clusterAllocated[cluster] = Resources{}
+		}
+
+		var capacity, allocated Resources
+		for _, vm := range dc.VMs {
+			allocated = allocated.Add(vm.AllocatedResources())
+			if _, ok := clusterAllocated[vm.Cluster]; ok {
+				clusterAllocated[vm.Cluster] = clusterAllocated[vm.Cluster].Add(vm.AllocatedResources())
+			}
+		}
+		if dc.Capacity != nil {
+			capacity = *dc.Capacity
+		} else {
+			for _, cluster := range dc.Clusters {
+				capacity = capacity.Add(clusterCapacity[cluster])
+			}
+		}
+
+		utilization := DatacenterUtilization{
+			DatacenterID: dc.ID,
+			Utilization:  NewUtilization(capacity, allocated),
+		}
+		if len(dc.Clusters) > 0 {
+			utilization.Clusters = make(map[string]Utilization, len(dc.Clusters))
+			for _, cluster := range dc.Clusters {
+				utilization.Clusters[cluster] = NewUtilization(clusterCapacity[cluster], clusterAllocated[cluster])
+			}
+		}
+		result = append(result, utilization)
+	}
+	return result
+}
+
+// Node represents a Kubernetes node of a watched cluster
+type Node struct {
+	Name         string `json:"name"`
+	Cluster      string `json:"cluster"`
+	DatacenterID string `json:"datacenterId"`
+	Ready        bool   `json:"ready"`
+	// Schedulable is false for cordoned nodes
+	Schedulable       bool              `json:"schedulable"`
+	AllocatableCPU    int               `json:"allocatableCpu"`    // Whole cores
+	AllocatableMemory int               `json:"allocatableMemory"` // MB
+	Zone              string            `json:"zone,omitempty"`
+	Roles             []string          `json:"roles,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	// VMs lists the names of the VMs running on the node. It is derived
+	// from the VM records when nodes are read.
+	VMs       []string  `json:"vms"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
 // MigrateRequest represents a VM migration request
 type MigrateRequest struct {
-	VMID   string `json:"vmId"`
-	FromDC string `json:"fromDC"`
-	ToDC   string `json:"toDC"`
+	VMID          string `json:"vmId"`
+	FromDC        string `json:"fromDC"`
+	ToDC          string `json:"toDC"`
+	TargetCluster string `json:"targetCluster,omitempty"` // Optional cluster in ToDC (watcher mode)
+	Force         bool   `json:"force,omitempty"`         // Skip the capacity admission check
+}
+
+// BulkMigrateRequest moves a set of VMs to one datacenter. The VMs are
+// named by vmIds or picked by selector.
+type BulkMigrateRequest struct {
+	VMIDs    []string    `json:"vmIds,omitempty"`
+	Selector *VMSelector `json:"selector,omitempty"`
+	ToDC     string      `json:"toDC"`
+	// TargetCluster optionally picks the cluster in toDC (watcher mode)
+	TargetCluster string `json:"targetCluster,omitempty"`
+	// Concurrency bounds how many VMs are moved at once
+	Concurrency int `json:"concurrency,omitempty"`
+	// OnFailure is continue, the default, or stop to skip the VMs not yet
+	// started once one fails
+	OnFailure string `json:"onFailure,omitempty"`
+	// Order is listed, name, smallest or largest; vmIds default to listed
+	// and selectors to name
+	Order  string `json:"order,omitempty"`
+	Force  bool   `json:"force,omitempty"`
+	DryRun bool   `json:"dryRun,omitempty"`
+}
+
+// VMSelector picks VMs by where they run, their labels and their name.
+// Empty fields match every VM.
+type VMSelector struct {
+	Datacenter string `json:"datacenter,omitempty"`
+	Cluster    string `json:"cluster,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	// Labels is a label selector, e.g. "app=web,tier!=db"
+	Labels string `json:"labels,omitempty"`
+	// Name is a glob pattern, e.g. "web-*"
+	Name string `json:"name,omitempty"`
 }
 
 // MigrateResponse represents the response from a migration
 type MigrateResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	VM      *VM    `json:"vm,omitempty"`
+	Success     bool   `json:"success"`
+	Message     string `json:"message"`
+	VM          *VM    `json:"vm,omitempty"`
+	MigrationID string `json:"migrationId,omitempty"` // Started live migration (watcher mode)
+	OperationID string `json:"operationId,omitempty"` // Operation tracking the migration
+}
+
+// Operation states
+const (
+	OperationPending   = "pending"
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+	OperationCancelled = "cancelled"
+)
+
+// Operation is a long-running task, such as an evacuation, made of steps
+type Operation struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`             // What the operation does, e.g. "evacuation"
+	Target string `json:"target,omitempty"` // What it acts on, e.g. a datacenter ID
+	State  string `json:"state"`
+	// Progress is the share of finished steps, in percent
+	Progress   int             `json:"progress"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	StartedAt  *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt *time.Time      `json:"finishedAt,omitempty"`
+	Steps      []OperationStep `json:"steps"`
+	Result     interface{}     `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// OperationStep is one unit of work of an operation, such as moving one VM
+type OperationStep struct {
+	Name       string     `json:"name"`
+	State      string     `json:"state"` // One of the operation states
+	Message    string     `json:"message,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// OperationFinished reports whether an operation or step state is final
+func OperationFinished(state string) bool {
+	return state == OperationSucceeded || state == OperationFailed || state == OperationCancelled
+}
+
+// Schedule job types
+const (
+	JobMigrate = "migrate" // Migrate one VM
+	JobAuto    = "auto"    // Auto-migrate with a placement strategy
+	JobBulk    = "bulk"    // Bulk migrate
+)
+
+// Schedule runs a migration job once or on a cron schedule
+type Schedule struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Cron is the recurrence of the schedule, e.g. "*/15 * * * *" or
+	// "@every 10m"; At is the time of a one-off schedule
+	Cron      string      `json:"cron,omitempty"`
+	At        *time.Time  `json:"at,omitempty"`
+	Job       ScheduleJob `json:"job"`
+	Paused    bool        `json:"paused"`
+	CreatedAt time.Time   `json:"createdAt"`
+	// NextRun is unset once a one-off schedule has run
+	NextRun *time.Time `json:"nextRun,omitempty"`
+	// History lists the recent runs, newest first
+	History []ScheduleRun `json:"history"`
+}
+
+// ScheduleJob is the migration a schedule starts
+type ScheduleJob struct {
+	Type string `json:"type"` // migrate, auto or bulk
+	// Migrate moves one VM; fromDC may be left out to move the VM from
+	// wherever it runs
+	Migrate *MigrateRequest `json:"migrate,omitempty"`
+	// Strategy is the placement strategy of an auto job; empty uses the
+	// server's default
+	Strategy string              `json:"strategy,omitempty"`
+	Bulk     *BulkMigrateRequest `json:"bulk,omitempty"`
+}
+
+// Schedule run states
+const (
+	RunStarted = "started" // The job started an operation
+	RunSkipped = "skipped" // There was nothing to do
+	RunFailed  = "failed"  // The job could not be started
+)
+
+// ScheduleRun is one run of a schedule
+type ScheduleRun struct {
+	Time        time.Time `json:"time"`
+	State       string    `json:"state"`
+	OperationID string    `json:"operationId,omitempty"`
+	Message     string    `json:"message,omitempty"`
+}
+
+// Webhook is an endpoint that receives the events of the event hub
+type Webhook struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+	// Events are glob patterns of the event types delivered, e.g.
+	// "migration:*" or "vm:power"; empty delivers every event
+	Events []string `json:"events"`
+	// Secret signs the deliveries; it is only shown when the webhook is
+	// created
+	Secret    string    `json:"secret,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // Migration represents a VM migration in progress or completed
 type Migration struct {
-	ID               string                `json:"id"`               // Migration CR name
-	VMID             string                `json:"vmId"`             // VM being migrated
-	VMName           string                `json:"vmName"`           // VM name
-	Namespace        string                `json:"namespace"`        // Kubernetes namespace
-	Cluster          string                `json:"cluster"`          // Cluster where migration is happening
-	DatacenterID     string                `json:"datacenterId"`     // Datacenter ID
-	Phase            string                `json:"phase"`            // Current phase (Pending, Running, Succeeded, Failed)
-	Direction        string                `json:"direction"`        // Migration direction: "outgoing" (source), "incoming" (target), "unknown"
-	SourceCluster    string                `json:"sourceCluster"`    // Source cluster name (derived from migration direction)
-	TargetCluster    string                `json:"targetCluster"`    // Target cluster name (derived from migration direction)
-	SourceNode       string                `json:"sourceNode"`       // Source node name
-	TargetNode       string                `json:"targetNode"`       // Target node name
-	SourcePod        string                `json:"sourcePod"`        // Source pod name
-	TargetPod        string                `json:"targetPod"`        // Target pod name
-	StartTime        *time.Time            `json:"startTime"`        // Migration start time
-	EndTime          *time.Time            `json:"endTime"`          // Migration end time
-	PhaseTransitions []MigrationTransition `json:"phaseTransitions"` // Phase transition history
-	CreatedAt        time.Time             `json:"createdAt"`        // When migration CR was created
-	UpdatedAt        time.Time             `json:"updatedAt"`        // Last update time
-	Completed        bool                  `json:"completed"`        // Whether migration is completed
-	Labels           map[string]string     `json:"labels,omitempty"` // Migration labels (plan, migration ID, etc.)
+	ID                 string                `json:"id"`                           // Migration CR name
+	VMID               string                `json:"vmId"`                         // VM being migrated
+	VMName             string                `json:"vmName"`                       // VM name
+	Namespace          string                `json:"namespace"`                    // Kubernetes namespace
+	Cluster            string                `json:"cluster"`                      // Cluster where migration is happening
+	DatacenterID       string                `json:"datacenterId"`                 // Datacenter ID
+	Phase              string                `json:"phase"`                        // Current phase (Pending, Running, Succeeded, Failed)
+	Direction          string                `json:"direction"`                    // Migration direction: "outgoing" (source), "incoming" (target), "unknown"
+	SourceCluster      string                `json:"sourceCluster"`                // Source cluster name (derived from migration direction)
+	TargetCluster      string                `json:"targetCluster"`                // Target cluster name (derived from migration direction)
+	SourceDatacenterID string                `json:"sourceDatacenterId,omitempty"` // Source datacenter (from correlation)
+	TargetDatacenterID string                `json:"targetDatacenterId,omitempty"` // Target datacenter (from correlation)
+	SourceNode         string                `json:"sourceNode"`                   // Source node name
+	TargetNode         string                `json:"targetNode"`                   // Target node name
+	SourcePod          string                `json:"sourcePod"`                    // Source pod name
+	TargetPod          string                `json:"targetPod"`                    // Target pod name
+	StartTime          *time.Time            `json:"startTime"`                    // Migration start time
+	EndTime            *time.Time            `json:"endTime"`                      // Migration end time
+	PhaseTransitions   []MigrationTransition `json:"phaseTransitions"`             // Phase transition history
+	CreatedAt          time.Time             `json:"createdAt"`                    // When migration CR was created
+	UpdatedAt          time.Time             `json:"updatedAt"`                    // Last update time
+	Completed          bool                  `json:"completed"`                    // Whether migration is completed
+	Labels             map[string]string     `json:"labels,omitempty"`             // Migration labels (plan, migration ID, etc.)
 	// Migration coordination fields
 	SendToURL     string `json:"sendToUrl,omitempty"`     // spec.sendTo.connectURL (source cluster)
 	ReceiveFromID string `json:"receiveFromId,omitempty"` // spec.receive.migrationID (target cluster)
 	MigrationID   string `json:"migrationId,omitempty"`   // Forklift migration ID for correlation
+	// Migration outcome and configuration
+	Failed                    bool                     `json:"failed"`                              // Whether the migration failed
+	FailureReason             string                   `json:"failureReason,omitempty"`             // Why the migration failed
+	AbortRequested            bool                     `json:"abortRequested"`                      // Whether an abort was requested
+	AbortStatus               string                   `json:"abortStatus,omitempty"`               // Abort status (Aborting, Succeeded, Failed)
+	Mode                      string                   `json:"mode,omitempty"`                      // Migration mode (PreCopy, PostCopy, Paused)
+	MigrationPolicyName       string                   `json:"migrationPolicyName,omitempty"`       // MigrationPolicy applied to the migration
+	MigrationConfiguration    *MigrationConfiguration  `json:"migrationConfiguration,omitempty"`    // Effective migration configuration
+	TargetNodeDomainReadyTime *time.Time               `json:"targetNodeDomainReadyTime,omitempty"` // When the target domain became ready
+	Conditions                []MigrationCondition     `json:"conditions,omitempty"`                // Raw migration status conditions
+	PhaseDurations            []MigrationPhaseDuration `json:"phaseDurations,omitempty"`            // Time spent in each phase
+}
+
+// MigrationConfiguration is the effective configuration a migration ran with
+type MigrationConfiguration struct {
+	AllowAutoConverge                 *bool   `json:"allowAutoConverge,omitempty"`
+	AllowPostCopy                     *bool   `json:"allowPostCopy,omitempty"`
+	AllowWorkloadDisruption           *bool   `json:"allowWorkloadDisruption,omitempty"`
+	BandwidthPerMigration             string  `json:"bandwidthPerMigration,omitempty"`
+	CompletionTimeoutPerGiB           *int64  `json:"completionTimeoutPerGiB,omitempty"`
+	ProgressTimeout                   *int64  `json:"progressTimeout,omitempty"`
+	ParallelMigrationsPerCluster      *uint32 `json:"parallelMigrationsPerCluster,omitempty"`
+	ParallelOutboundMigrationsPerNode *uint32 `json:"parallelOutboundMigrationsPerNode,omitempty"`
+	UnsafeMigrationOverride           *bool   `json:"unsafeMigrationOverride,omitempty"`
+	DisableTLS                        *bool   `json:"disableTLS,omitempty"`
+	Network                           string  `json:"network,omitempty"`
+}
+
+// MigrationCondition is a status condition reported on a migration
+type MigrationCondition struct {
+	Type               string     `json:"type"`
+	Status             string     `json:"status"`
+	Reason             string     `json:"reason,omitempty"`
+	Message            string     `json:"message,omitempty"`
+	LastTransitionTime *time.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// MigrationPhaseDuration is the time a migration spent in one phase
+type MigrationPhaseDuration struct {
+	Phase           string  `json:"phase"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Current         bool    `json:"current,omitempty"` // Still in this phase; duration is up to now
+}
+
+// LogicalMigration is a decentralized live migration correlated from its
+// outgoing (source cluster) and incoming (target cluster) migration records
+type LogicalMigration struct {
+	MigrationID        string     `json:"migrationId"`
+	VMName             string     `json:"vmName"`
+	Namespace          string     `json:"namespace"`
+	SourceCluster      string     `json:"sourceCluster"`
+	TargetCluster      string     `json:"targetCluster"`
+	SourceDatacenterID string     `json:"sourceDatacenterId"`
+	TargetDatacenterID string     `json:"targetDatacenterId"`
+	Phase              string     `json:"phase"`     // Overall phase across both sides
+	Completed          bool       `json:"completed"` // Whether the migration as a whole has finished
+	StartTime          *time.Time `json:"startTime"`
+	EndTime            *time.Time `json:"endTime"`
+	DurationSeconds    float64    `json:"durationSeconds"`    // Elapsed time, up to now while running
+	Outgoing           *Migration `json:"outgoing,omitempty"` // Source cluster record
+	Incoming           *Migration `json:"incoming,omitempty"` // Target cluster record
 }
 
 // MigrationTransition represents a phase transition in a migration
@@ -0,0 +1,315 @@
+// Package placement decides which VM to move and where. An Engine
+// enumerates every admissible move of a running VM to another datacenter
+// and lets a Strategy score it; the best scoring move wins. Every score
+// carries the reasons behind it, so a decision can be explained.
+package placement
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+// DefaultStrategy is the strategy used when none is requested
+const DefaultStrategy = "balance-count"
+
+// Strategy scores candidate moves. Higher scores are better; moves the
+// strategy doesn't want are returned as not eligible.
+type Strategy interface {
+	Name() string
+	Description() string
+	Score(state *State, candidate Candidate) (score float64, reasons []string, eligible bool)
+}
+
+// Committer is implemented by strategies that remember their decisions,
+// such as round-robin. Commit is called once a decision has been carried out.
+type Committer interface {
+	Commit(decision *Decision)
+}
+
+// Candidate is a possible move of a VM to another datacenter
+type Candidate struct {
+	VM     models.VM
+	FromDC string
+	ToDC   string
+}
+
+// Score is the rating of one candidate move
+type Score struct {
+	VMID     string   `json:"vmId"`
+	VMName   string   `json:"vmName"`
+	FromDC   string   `json:"from"`
+	ToDC     string   `json:"to"`
+	Score    float64  `json:"score"`
+	Eligible bool     `json:"eligible"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// Decision is the move chosen by a strategy with the scoring behind it
+type Decision struct {
+	Strategy string   `json:"strategy"`
+	VMID     string   `json:"vmId"`
+	VMName   string   `json:"vmName"`
+	FromDC   string   `json:"from"`
+	ToDC     string   `json:"to"`
+	Score    float64  `json:"score"`
+	Reasons  []string `json:"reasons"`
+	// Scores lists every candidate considered, best first
+	Scores []Score `json:"scores"`
+}
+
+// AdmitFunc reports why a candidate move is not allowed, for example
+// because the target lacks capacity
+type AdmitFunc func(candidate Candidate) error
+
+// Options tunes the engine
+type Options struct {
+	// AffinityLabel is the VM label whose value groups VMs for the
+	// affinity and anti-affinity strategies
+	AffinityLabel string
+	// Seed seeds the random strategy; zero uses the current time
+	Seed int64
+}
+
+// DefaultOptions returns the default engine options
+func DefaultOptions() Options {
+	return Options{AffinityLabel: "app"}
+}
+
+// Engine holds the available strategies
+type Engine struct {
+	mu         sync.Mutex
+	strategies map[string]Strategy
+}
+
+// NewEngine creates an engine with the built-in strategies
+func NewEngine(options Options) *Engine {
+	seed := options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if options.AffinityLabel == "" {
+		options.AffinityLabel = DefaultOptions().AffinityLabel
+	}
+
+	e := &Engine{strategies: make(map[string]Strategy)}
+	e.Register(&countBalance{})
+	e.Register(&resourceBalance{name: "balance-cpu", resource: "CPU", unit: " cores", amount: func(r models.Resources) int { return r.CPU }})
+	e.Register(&resourceBalance{name: "balance-memory", resource: "memory", unit: "MB", amount: func(r models.Resources) int { return r.Memory }})
+	e.Register(&random{rng: rand.New(rand.NewSource(seed))})
+	e.Register(&roundRobin{})
+	e.Register(&labelAffinity{label: options.AffinityLabel})
+	e.Register(&labelAffinity{label: options.AffinityLabel, anti: true})
+	return e
+}
+
+// Register adds a strategy, replacing any with the same name
+func (e *Engine) Register(strategy Strategy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.strategies[strategy.Name()] = strategy
+}
+
+// Strategies returns the available strategies by name with their descriptions
+func (e *Engine) Strategies() map[string]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	strategies := make(map[string]string, len(e.strategies))
+	for name, strategy := range e.strategies {
+		strategies[name] = strategy.Description()
+	}
+	return strategies
+}
+
+// Decide scores every admissible move and returns the best one. The
+// decision is nil when no candidate is eligible; the scores are returned
+// either way so the outcome can be explained.
+func (e *Engine) Decide(strategyName string, state *State, admit AdmitFunc) (*Decision, []Score, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if strategyName == "" {
+		strategyName = DefaultStrategy
+	}
+	strategy, ok := e.strategies[strategyName]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown placement strategy %q", strategyName)
+	}
+
+	var scores []Score
+	for _, candidate := range state.Candidates() {
+		score := Score{
+			VMID:   candidate.VM.ID,
+			VMName: candidate.VM.Name,
+			FromDC: candidate.FromDC,
+			ToDC:   candidate.ToDC,
+		}
+		if admit != nil {
+			if err := admit(candidate); err != nil {
+				score.Reasons = []string{err.Error()}
+				scores = append(scores, score)
+				continue
+			}
+		}
+		score.Score, score.Reasons, score.Eligible = strategy.Score(state, candidate)
+		scores = append(scores, score)
+	}
+
+	// Best first; candidates keep their stable order on ties
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Eligible != scores[j].Eligible {
+			return scores[i].Eligible
+		}
+		return scores[i].Score > scores[j].Score
+	})
+
+	if len(scores) == 0 || !scores[0].Eligible {
+		return nil, scores, nil
+	}
+	best := scores[0]
+	return &Decision{
+		Strategy: strategyName,
+		VMID:     best.VMID,
+		VMName:   best.VMName,
+		FromDC:   best.FromDC,
+		ToDC:     best.ToDC,
+		Score:    best.Score,
+		Reasons:  best.Reasons,
+		Scores:   scores,
+	}, scores, nil
+}
+
+// Commit tells the decision's strategy that it has been carried out
+func (e *Engine) Commit(decision *Decision) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if committer, ok := e.strategies[decision.Strategy].(Committer); ok {
+		committer.Commit(decision)
+	}
+}
+
+// State is the placement view of the datacenters
+type State struct {
+	Datacenters []models.Datacenter
+	Utilization map[string]models.DatacenterUtilization
+}
+
+// NewState builds the placement state from the store's data
+func NewState(datacenters *models.DatacenterCollection, utilization []models.DatacenterUtilization) *State {
+	state := &State{
+		Datacenters: append([]models.Datacenter(nil), datacenters.Datacenters...),
+		Utilization: make(map[string]models.DatacenterUtilization, len(utilization)),
+	}
+	for _, u := range utilization {
+		state.Utilization[u.DatacenterID] = u
+	}
+	return state
+}
+
+// Candidates lists every move of a running VM that is not already
+// migrating to each other datacenter that accepts VMs, in datacenter and
+// VM order
+func (s *State) Candidates() []Candidate {
+	var candidates []Candidate
+	for _, from := range s.Datacenters {
+		for _, vm := range from.VMs {
+			if vm.Status != "running" || vm.MigrationStatus == "migrating" {
+				continue
+			}
+			for _, to := range s.Datacenters {
+				if to.ID != from.ID && to.AcceptsVMs() {
+					candidates = append(candidates, Candidate{VM: vm, FromDC: from.ID, ToDC: to.ID})
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// Target picks the datacenter for a VM that has to leave its own, as on
+// evacuation: the admissible datacenter accepting VMs with the fewest VMs
+func (s *State) Target(vm models.VM, fromDC string, admit AdmitFunc) (string, error) {
+	target, fewest := "", 0
+	var rejected []string
+	for _, dc := range s.Datacenters {
+		if dc.ID == fromDC || !dc.AcceptsVMs() {
+			continue
+		}
+		if admit != nil {
+			if err := admit(Candidate{VM: vm, FromDC: fromDC, ToDC: dc.ID}); err != nil {
+				rejected = append(rejected, err.Error())
+				continue
+			}
+		}
+		if target == "" || len(dc.VMs) < fewest {
+			target, fewest = dc.ID, len(dc.VMs)
+		}
+	}
+	if target == "" {
+		if len(rejected) > 0 {
+			return "", fmt.Errorf("no datacenter can take VM %s: %s", vm.Name, strings.Join(rejected, "; "))
+		}
+		return "", fmt.Errorf("no datacenter can take VM %s", vm.Name)
+	}
+	return target, nil
+}
+
+// datacenter returns a datacenter by ID
+func (s *State) datacenter(id string) *models.Datacenter {
+	for i := range s.Datacenters {
+		if s.Datacenters[i].ID == id {
+			return &s.Datacenters[i]
+		}
+	}
+	return nil
+}
+
+// Imbalance is the spread in VM count between the fullest and the emptiest
+// datacenter
+func (s *State) Imbalance() int {
+	if len(s.Datacenters) == 0 {
+		return 0
+	}
+	most, least := len(s.Datacenters[0].VMs), len(s.Datacenters[0].VMs)
+	for _, dc := range s.Datacenters[1:] {
+		most = max(most, len(dc.VMs))
+		least = min(least, len(dc.VMs))
+	}
+	return most - least
+}
+
+// Move applies a decision to the state, so that further decisions can be
+// planned on top of it. The VM is marked as migrating.
+func (s *State) Move(decision *Decision) {
+	from, to := s.datacenter(decision.FromDC), s.datacenter(decision.ToDC)
+	if from == nil || to == nil {
+		return
+	}
+	for i, vm := range from.VMs {
+		if vm.ID != decision.VMID {
+			continue
+		}
+		// Copy the VM slices; they may be shared with the caller's collection
+		from.VMs = append(append([]models.VM{}, from.VMs[:i]...), from.VMs[i+1:]...)
+		vm.MigrationStatus = "migrating"
+		to.VMs = append(append([]models.VM{}, to.VMs...), vm)
+
+		allocated := vm.AllocatedResources()
+		if u, ok := s.Utilization[from.ID]; ok {
+			u.Utilization = models.NewUtilization(u.Capacity, u.Allocated.Add(models.Resources{CPU: -allocated.CPU, Memory: -allocated.Memory, Disk: -allocated.Disk}))
+			s.Utilization[from.ID] = u
+		}
+		if u, ok := s.Utilization[to.ID]; ok {
+			u.Utilization = models.NewUtilization(u.Capacity, u.Allocated.Add(allocated))
+			s.Utilization[to.ID] = u
+		}
+		return
+	}
+}
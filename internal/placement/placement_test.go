@@ -0,0 +1,253 @@
+package placement_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/placement"
+)
+
+// vm returns a running VM with 2 CPU and 4096 MB
+func vm(id, app string) models.VM {
+	v := models.VM{ID: id, Name: id, Status: "running", CPU: 2, Memory: 4096}
+	if app != "" {
+		v.Labels = map[string]string{"app": app}
+	}
+	return v
+}
+
+// newState builds a placement state with utilization computed from the VMs
+func newState(datacenters ...models.Datacenter) *placement.State {
+	collection := &models.DatacenterCollection{Datacenters: datacenters}
+	return placement.NewState(collection, models.ComputeUtilization(collection, nil))
+}
+
+var _ = Describe("Engine", func() {
+	var engine *placement.Engine
+
+	BeforeEach(func() {
+		engine = placement.NewEngine(placement.Options{Seed: 1})
+	})
+
+	It("lists the built-in strategies", func() {
+		Expect(engine.Strategies()).To(HaveKey("balance-count"))
+		Expect(engine.Strategies()).To(HaveKey("balance-cpu"))
+		Expect(engine.Strategies()).To(HaveKey("balance-memory"))
+		Expect(engine.Strategies()).To(HaveKey("random"))
+		Expect(engine.Strategies()).To(HaveKey("round-robin"))
+		Expect(engine.Strategies()).To(HaveKey("affinity"))
+		Expect(engine.Strategies()).To(HaveKey("anti-affinity"))
+	})
+
+	It("rejects unknown strategies", func() {
+		_, _, err := engine.Decide("nope", newState(), nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("only considers running VMs that are not migrating", func() {
+		stopped := vm("stopped", "")
+		stopped.Status = "stopped"
+		migrating := vm("migrating", "")
+		migrating.MigrationStatus = "migrating"
+		state := newState(
+			models.Datacenter{ID: "dc-a", VMs: []models.VM{stopped, migrating, vm("vm-1", "")}},
+			models.Datacenter{ID: "dc-b"},
+		)
+
+		candidates := state.Candidates()
+		Expect(candidates).To(HaveLen(1))
+		Expect(candidates[0].VM.ID).To(Equal("vm-1"))
+		Expect(candidates[0].ToDC).To(Equal("dc-b"))
+	})
+
+	It("never targets datacenters under maintenance", func() {
+		state := newState(
+			models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("vm-1", ""), vm("vm-2", ""), vm("vm-3", "")}},
+			models.Datacenter{ID: "dc-b", Maintenance: &models.Maintenance{}},
+		)
+
+		Expect(state.Candidates()).To(BeEmpty())
+		_, err := state.Target(vm("vm-1", ""), "dc-a", nil)
+		Expect(err).To(MatchError("no datacenter can take VM vm-1"))
+	})
+
+	It("sends evacuated VMs to the admissible datacenter with the fewest VMs", func() {
+		state := newState(
+			models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("vm-1", ""), vm("vm-2", "")}},
+			models.Datacenter{ID: "dc-b", VMs: []models.VM{vm("vm-3", "")}},
+			models.Datacenter{ID: "dc-c"},
+			models.Datacenter{ID: "dc-d"},
+		)
+		admit := func(candidate placement.Candidate) error {
+			if candidate.ToDC == "dc-d" {
+				return errors.New("dc-d is full")
+			}
+			return nil
+		}
+
+		target, err := state.Target(vm("vm-1", ""), "dc-a", admit)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target).To(Equal("dc-c"))
+
+		state.Move(&placement.Decision{VMID: "vm-1", FromDC: "dc-a", ToDC: target})
+		target, err = state.Target(vm("vm-2", ""), "dc-a", admit)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target).To(Equal("dc-b"))
+	})
+
+	It("reports rejected candidates with the admission error", func() {
+		state := newState(
+			models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("vm-1", ""), vm("vm-2", "")}},
+			models.Datacenter{ID: "dc-b"},
+		)
+
+		decision, scores, err := engine.Decide("balance-count", state, func(placement.Candidate) error {
+			return errors.New("dc-b is full")
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decision).To(BeNil())
+		Expect(scores).To(HaveLen(2))
+		Expect(scores[0].Eligible).To(BeFalse())
+		Expect(scores[0].Reasons).To(ConsistOf("dc-b is full"))
+	})
+
+	Describe("balance-count", func() {
+		It("moves a VM from the fuller datacenter", func() {
+			state := newState(
+				models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("vm-1", ""), vm("vm-2", ""), vm("vm-3", "")}},
+				models.Datacenter{ID: "dc-b", VMs: []models.VM{vm("vm-4", "")}},
+			)
+
+			decision, scores, err := engine.Decide("balance-count", state, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).NotTo(BeNil())
+			Expect(decision.FromDC).To(Equal("dc-a"))
+			Expect(decision.ToDC).To(Equal("dc-b"))
+			Expect(decision.Reasons[0]).To(ContainSubstring("dc-a has 3 VMs and dc-b has 1"))
+			Expect(decision.Scores).To(Equal(scores))
+			Expect(scores).To(HaveLen(4))
+		})
+
+		It("doesn't move VMs between balanced datacenters", func() {
+			state := newState(
+				models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("vm-1", "")}},
+				models.Datacenter{ID: "dc-b", VMs: []models.VM{vm("vm-2", "")}},
+			)
+
+			decision, scores, err := engine.Decide("balance-count", state, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(BeNil())
+			Expect(scores).To(HaveLen(2))
+			Expect(scores[0].Reasons).To(ContainElement("does not improve the balance"))
+		})
+	})
+
+	Describe("balance-cpu", func() {
+		It("moves the VM that best evens out CPU utilization", func() {
+			big := vm("big", "")
+			big.CPU = 8
+			small := vm("small", "")
+			small.CPU = 1
+			other := vm("other", "")
+			other.CPU = 3
+			state := newState(
+				models.Datacenter{ID: "dc-a", VMs: []models.VM{big, small}, Capacity: &models.Resources{CPU: 16}},
+				models.Datacenter{ID: "dc-b", VMs: []models.VM{other}, Capacity: &models.Resources{CPU: 16}},
+			)
+
+			decision, _, err := engine.Decide("balance-cpu", state, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.VMID).To(Equal("small"))
+			Expect(decision.Reasons).To(ConsistOf("dc-a CPU 56% -> 50%", "dc-b CPU 19% -> 25%"))
+		})
+	})
+
+	Describe("balance-memory", func() {
+		It("compares allocated memory without a capacity", func() {
+			state := newState(
+				models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("vm-1", "")}},
+				models.Datacenter{ID: "dc-b", VMs: []models.VM{vm("vm-2", ""), vm("vm-3", ""), vm("vm-4", "")}},
+			)
+
+			decision, _, err := engine.Decide("balance-memory", state, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.FromDC).To(Equal("dc-b"))
+			Expect(decision.Reasons).To(ContainElement("dc-b memory 12288MB -> 8192MB"))
+		})
+	})
+
+	Describe("random", func() {
+		It("picks an admissible move", func() {
+			state := newState(
+				models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("vm-1", "")}},
+				models.Datacenter{ID: "dc-b", VMs: []models.VM{vm("vm-2", "")}},
+			)
+
+			decision, _, err := engine.Decide("random", state, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).NotTo(BeNil())
+			Expect(decision.Reasons).To(ConsistOf("picked at random"))
+		})
+	})
+
+	Describe("round-robin", func() {
+		It("moves each VM in turn instead of the same one back and forth", func() {
+			state := newState(
+				models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("vm-1", ""), vm("vm-2", "")}},
+				models.Datacenter{ID: "dc-b", VMs: []models.VM{vm("vm-3", "")}},
+			)
+
+			var moved []string
+			for i := 0; i < 4; i++ {
+				decision, _, err := engine.Decide("round-robin", state, nil)
+				Expect(err).NotTo(HaveOccurred())
+				engine.Commit(decision)
+				moved = append(moved, decision.VMID)
+			}
+			Expect(moved).To(Equal([]string{"vm-1", "vm-2", "vm-3", "vm-1"}))
+		})
+	})
+
+	Describe("affinity", func() {
+		It("moves a VM to the datacenter running its group", func() {
+			state := newState(
+				models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("web-1", "web"), vm("db-1", "db")}},
+				models.Datacenter{ID: "dc-b", VMs: []models.VM{vm("web-2", "web"), vm("web-3", "web")}},
+			)
+
+			decision, _, err := engine.Decide("affinity", state, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.VMID).To(Equal("web-1"))
+			Expect(decision.ToDC).To(Equal("dc-b"))
+		})
+
+		It("skips VMs without the label", func() {
+			state := newState(
+				models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("vm-1", "")}},
+				models.Datacenter{ID: "dc-b"},
+			)
+
+			decision, scores, err := engine.Decide("affinity", state, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision).To(BeNil())
+			Expect(scores[0].Reasons).To(ConsistOf(`VM vm-1 has no "app" label`))
+		})
+	})
+
+	Describe("anti-affinity", func() {
+		It("spreads a group over the datacenters", func() {
+			state := newState(
+				models.Datacenter{ID: "dc-a", VMs: []models.VM{vm("web-1", "web"), vm("web-2", "web")}},
+				models.Datacenter{ID: "dc-b", VMs: []models.VM{vm("db-1", "db")}},
+			)
+
+			decision, _, err := engine.Decide("anti-affinity", state, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.VMID).To(HavePrefix("web-"))
+			Expect(decision.ToDC).To(Equal("dc-b"))
+		})
+	})
+})
@@ -0,0 +1,200 @@
+package placement
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+// countBalance evens out the number of VMs per datacenter
+type countBalance struct{}
+
+func (s *countBalance) Name() string { return "balance-count" }
+
+func (s *countBalance) Description() string {
+	return "Move a VM from a datacenter with more VMs to one with fewer"
+}
+
+func (s *countBalance) Score(state *State, c Candidate) (float64, []string, bool) {
+	from, to := len(state.datacenter(c.FromDC).VMs), len(state.datacenter(c.ToDC).VMs)
+	improvement := math.Abs(float64(from-to)) - math.Abs(float64((from-1)-(to+1)))
+	reasons := []string{fmt.Sprintf("%s has %d VMs and %s has %d; afterwards %d and %d", c.FromDC, from, c.ToDC, to, from-1, to+1)}
+	if improvement <= 0 {
+		return improvement, append(reasons, "does not improve the balance"), false
+	}
+	return improvement, reasons, true
+}
+
+// resourceBalance evens out the utilization of one resource. Datacenters
+// without a known capacity are compared by allocated amount.
+type resourceBalance struct {
+	name     string
+	resource string
+	unit     string
+	amount   func(models.Resources) int
+}
+
+func (s *resourceBalance) Name() string { return s.name }
+
+func (s *resourceBalance) Description() string {
+	return fmt.Sprintf("Move a VM from a datacenter with high %s utilization to one with lower", s.resource)
+}
+
+// load returns a datacenter's load after adding delta, as a percentage of
+// its capacity or, without one, as the allocated amount
+func (s *resourceBalance) load(u models.DatacenterUtilization, delta int) (float64, string) {
+	allocated := s.amount(u.Allocated) + delta
+	if capacity := s.amount(u.Capacity); capacity > 0 {
+		percent := float64(allocated) * 100 / float64(capacity)
+		return percent, fmt.Sprintf("%.0f%%", percent)
+	}
+	return float64(allocated), fmt.Sprintf("%d%s", allocated, s.unit)
+}
+
+func (s *resourceBalance) Score(state *State, c Candidate) (float64, []string, bool) {
+	needed := s.amount(c.VM.AllocatedResources())
+	if needed == 0 {
+		return 0, []string{fmt.Sprintf("VM %s uses no %s", c.VM.Name, s.resource)}, false
+	}
+
+	from, to := state.Utilization[c.FromDC], state.Utilization[c.ToDC]
+	fromBefore, fromBeforeText := s.load(from, 0)
+	toBefore, toBeforeText := s.load(to, 0)
+	fromAfter, fromAfterText := s.load(from, -needed)
+	toAfter, toAfterText := s.load(to, needed)
+
+	improvement := math.Max(fromBefore, toBefore) - math.Max(fromAfter, toAfter)
+	reasons := []string{
+		fmt.Sprintf("%s %s %s -> %s", c.FromDC, s.resource, fromBeforeText, fromAfterText),
+		fmt.Sprintf("%s %s %s -> %s", c.ToDC, s.resource, toBeforeText, toAfterText),
+	}
+	if improvement <= 0 {
+		return improvement, append(reasons, "does not lower the peak utilization"), false
+	}
+	return improvement, reasons, true
+}
+
+// random picks any admissible move
+type random struct {
+	rng *rand.Rand
+}
+
+func (s *random) Name() string { return "random" }
+
+func (s *random) Description() string {
+	return "Move a random running VM to a random datacenter"
+}
+
+func (s *random) Score(_ *State, _ Candidate) (float64, []string, bool) {
+	return s.rng.Float64(), []string{"picked at random"}, true
+}
+
+// roundRobin cycles through the VMs in name order, sending each to the
+// datacenter after its own
+type roundRobin struct {
+	last string
+}
+
+func (s *roundRobin) Name() string { return "round-robin" }
+
+func (s *roundRobin) Description() string {
+	return "Move VMs in turn by name, each to the next datacenter"
+}
+
+func (s *roundRobin) Score(state *State, c Candidate) (float64, []string, bool) {
+	var names []string
+	for _, dc := range state.Datacenters {
+		for _, vm := range dc.VMs {
+			names = append(names, vm.Name)
+		}
+	}
+	sort.Strings(names)
+
+	// Turns until this VM is next: VMs after the last one moved come first
+	turn := sort.SearchStrings(names, c.VM.Name)
+	if s.last != "" {
+		after := sort.Search(len(names), func(i int) bool { return names[i] > s.last })
+		turn = (turn - after + len(names)) % len(names)
+	}
+
+	// Prefer the datacenter following the VM's own, wrapping around
+	hops := 0
+	for i, dc := range state.Datacenters {
+		if dc.ID == c.FromDC {
+			for j := 1; j < len(state.Datacenters); j++ {
+				if state.Datacenters[(i+j)%len(state.Datacenters)].ID == c.ToDC {
+					hops = j
+				}
+			}
+		}
+	}
+
+	score := -float64(turn*len(state.Datacenters) + hops)
+	reason := fmt.Sprintf("VM %s is position %d in the rotation after %s", c.VM.Name, turn+1, s.last)
+	if s.last == "" {
+		reason = fmt.Sprintf("VM %s is position %d in the rotation", c.VM.Name, turn+1)
+	}
+	return score, []string{reason, fmt.Sprintf("%s is %d datacenter(s) after %s", c.ToDC, hops, c.FromDC)}, true
+}
+
+func (s *roundRobin) Commit(decision *Decision) {
+	s.last = decision.VMName
+}
+
+// labelAffinity brings VMs sharing a label value together (affinity) or
+// spreads them over the datacenters (anti-affinity)
+type labelAffinity struct {
+	label string
+	anti  bool
+}
+
+func (s *labelAffinity) Name() string {
+	if s.anti {
+		return "anti-affinity"
+	}
+	return "affinity"
+}
+
+func (s *labelAffinity) Description() string {
+	if s.anti {
+		return fmt.Sprintf("Spread VMs with the same %q label over the datacenters", s.label)
+	}
+	return fmt.Sprintf("Move VMs to the datacenter running most VMs with the same %q label", s.label)
+}
+
+// peers counts the other VMs in a datacenter with the same label value
+func (s *labelAffinity) peers(dc *models.Datacenter, vm models.VM, value string) int {
+	count := 0
+	for _, other := range dc.VMs {
+		if other.ID != vm.ID && other.Labels[s.label] == value {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *labelAffinity) Score(state *State, c Candidate) (float64, []string, bool) {
+	value, ok := c.VM.Labels[s.label]
+	if !ok {
+		return 0, []string{fmt.Sprintf("VM %s has no %q label", c.VM.Name, s.label)}, false
+	}
+
+	from := s.peers(state.datacenter(c.FromDC), c.VM, value)
+	to := s.peers(state.datacenter(c.ToDC), c.VM, value)
+	reasons := []string{fmt.Sprintf("%d other VM(s) with %s=%s in %s, %d in %s", from, s.label, value, c.FromDC, to, c.ToDC)}
+
+	score := float64(to - from)
+	if s.anti {
+		score = -score
+	}
+	if score <= 0 {
+		if s.anti {
+			return score, append(reasons, "does not spread the group"), false
+		}
+		return score, append(reasons, "does not bring the group together"), false
+	}
+	return score, reasons, true
+}
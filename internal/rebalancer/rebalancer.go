@@ -0,0 +1,386 @@
+// Package rebalancer runs a background controller that keeps the VMs spread
+// over the datacenters. On every check it measures the imbalance and, once
+// it passes a threshold, lets the placement engine plan moves and starts as
+// many as the concurrency limit allows, skipping VMs that migrated recently
+// and holding off during blackout windows.
+package rebalancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/placement"
+)
+
+// historySize is how many executed moves the status keeps
+const historySize = 50
+
+// Cluster is what the rebalancer needs from the rest of the server
+type Cluster interface {
+	// State returns the current placement state
+	State() (*placement.State, error)
+	// Admit reports why a move is not allowed, such as missing capacity
+	Admit(candidate placement.Candidate) error
+	// Execute starts a planned move and returns its migration ID, if any
+	Execute(ctx context.Context, decision *placement.Decision) (string, error)
+}
+
+// Duration is a time.Duration that reads and writes as "30s" in JSON
+type Duration time.Duration
+
+// MarshalText encodes the duration as a string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText decodes a duration string
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Config tunes the rebalancer
+type Config struct {
+	// Interval is the time between checks
+	Interval Duration `json:"interval"`
+	// Threshold is the spread in VM count between the fullest and the
+	// emptiest datacenter at which moves are planned
+	Threshold int `json:"threshold"`
+	// Strategy is the placement strategy used to plan moves
+	Strategy string `json:"strategy"`
+	// MaxConcurrent limits the migrations in flight at once
+	MaxConcurrent int `json:"maxConcurrent"`
+	// Cooldown is how long a VM stays put after it migrated
+	Cooldown Duration `json:"cooldown"`
+	// Blackouts are the windows in which no migration is started
+	Blackouts []Window `json:"blackouts"`
+}
+
+// DefaultConfig returns the default rebalancer configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:      Duration(30 * time.Second),
+		Threshold:     2,
+		Strategy:      placement.DefaultStrategy,
+		MaxConcurrent: 1,
+		Cooldown:      Duration(10 * time.Minute),
+	}
+}
+
+// Validate checks the configuration
+func (c Config) Validate() error {
+	switch {
+	case c.Interval <= 0:
+		return errors.New("interval must be positive")
+	case c.Threshold < 1:
+		return errors.New("threshold must be at least 1")
+	case c.MaxConcurrent < 1:
+		return errors.New("maxConcurrent must be at least 1")
+	case c.Cooldown < 0:
+		return errors.New("cooldown must not be negative")
+	}
+	return nil
+}
+
+// Move statuses
+const (
+	MoveStarted  = "started"  // The migration was started
+	MoveFailed   = "failed"   // Starting the migration failed
+	MoveDeferred = "deferred" // Held back by the concurrency limit or a blackout
+)
+
+// Move is a planned migration and what became of it
+type Move struct {
+	VMID        string    `json:"vmId"`
+	VMName      string    `json:"vmName"`
+	FromDC      string    `json:"from"`
+	ToDC        string    `json:"to"`
+	Strategy    string    `json:"strategy"`
+	Score       float64   `json:"score"`
+	Reasons     []string  `json:"reasons"`
+	Status      string    `json:"status"`
+	MigrationID string    `json:"migrationId,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// Controller states reported in the status
+const (
+	StateStopped     = "stopped"     // Not running
+	StateBalanced    = "balanced"    // The imbalance is below the threshold
+	StateRebalancing = "rebalancing" // Moves were planned on the last check
+	StateBlackout    = "blackout"    // In a blackout window
+	StateError       = "error"       // The last check failed
+)
+
+// Status reports what the rebalancer is doing
+type Status struct {
+	Running   bool       `json:"running"`
+	State     string     `json:"state"`
+	Config    Config     `json:"config"`
+	Imbalance int        `json:"imbalance"`
+	InFlight  int        `json:"inFlight"`
+	LastCheck *time.Time `json:"lastCheck,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	// Planned lists the moves planned on the last check
+	Planned []Move `json:"planned"`
+	// History lists the recently started and failed moves, newest first
+	History []Move `json:"history"`
+}
+
+// Controller periodically rebalances the datacenters
+type Controller struct {
+	engine  *placement.Engine
+	cluster Cluster
+	notify  func(eventType string, payload interface{})
+	now     func() time.Time
+
+	mu     sync.Mutex
+	status Status
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a stopped controller. notify receives the rebalancer:*
+// events; it may be nil.
+func New(engine *placement.Engine, cluster Cluster, notify func(eventType string, payload interface{})) *Controller {
+	if notify == nil {
+		notify = func(string, interface{}) {}
+	}
+	return &Controller{
+		engine:  engine,
+		cluster: cluster,
+		notify:  notify,
+		now:     time.Now,
+		status: Status{
+			State:   StateStopped,
+			Config:  DefaultConfig(),
+			Planned: []Move{},
+			History: []Move{},
+		},
+	}
+}
+
+// SetClock replaces the controller's clock, for tests
+func (c *Controller) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Status returns a snapshot of the controller's status
+func (c *Controller) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := c.status
+	status.Config.Blackouts = append([]Window{}, status.Config.Blackouts...)
+	status.Planned = append([]Move{}, status.Planned...)
+	status.History = append([]Move{}, status.History...)
+	return status
+}
+
+// Start runs the controller with config, restarting it if it is running
+func (c *Controller) Start(config Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if _, ok := c.engine.Strategies()[config.Strategy]; !ok {
+		return fmt.Errorf("unknown placement strategy %q", config.Strategy)
+	}
+
+	c.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.mu.Lock()
+	c.cancel, c.done = cancel, done
+	c.status.Running = true
+	c.status.State = StateBalanced
+	c.status.Config = config
+	c.mu.Unlock()
+
+	log.Printf("Rebalancer started (interval %s, threshold %d, strategy %s)", time.Duration(config.Interval), config.Threshold, config.Strategy)
+	c.notify("rebalancer:started", c.Status())
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(time.Duration(config.Interval))
+		defer ticker.Stop()
+		for {
+			c.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the controller and waits for a running check to finish
+func (c *Controller) Stop() {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel, c.done = nil, nil
+	c.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+
+	c.mu.Lock()
+	c.status.Running = false
+	c.status.State = StateStopped
+	c.mu.Unlock()
+
+	log.Printf("Rebalancer stopped")
+	c.notify("rebalancer:stopped", c.Status())
+}
+
+// Check measures the imbalance once and starts the planned moves the
+// limits allow. The controller calls it on every interval.
+func (c *Controller) Check(ctx context.Context) {
+	c.mu.Lock()
+	config := c.status.Config
+	now := c.now()
+	c.mu.Unlock()
+
+	state, err := c.cluster.State()
+	if err != nil {
+		c.finishCheck(now, StateError, 0, 0, nil, err)
+		return
+	}
+
+	inFlight := 0
+	for _, dc := range state.Datacenters {
+		for _, vm := range dc.VMs {
+			if vm.MigrationStatus == "migrating" {
+				inFlight++
+			}
+		}
+	}
+
+	imbalance := state.Imbalance()
+	if imbalance < config.Threshold {
+		c.finishCheck(now, StateBalanced, imbalance, inFlight, nil, nil)
+		return
+	}
+
+	moves := c.plan(state, config, now)
+
+	blackout := false
+	for _, window := range config.Blackouts {
+		if window.Contains(now) {
+			blackout = true
+		}
+	}
+
+	slots := config.MaxConcurrent - inFlight
+	for i := range moves {
+		if blackout || slots <= 0 {
+			moves[i].Status = MoveDeferred
+			continue
+		}
+		slots--
+
+		decision := &placement.Decision{
+			Strategy: moves[i].Strategy,
+			VMID:     moves[i].VMID,
+			VMName:   moves[i].VMName,
+			FromDC:   moves[i].FromDC,
+			ToDC:     moves[i].ToDC,
+			Score:    moves[i].Score,
+			Reasons:  moves[i].Reasons,
+		}
+		migrationID, err := c.cluster.Execute(ctx, decision)
+		if err != nil {
+			moves[i].Status = MoveFailed
+			moves[i].Error = err.Error()
+			log.Printf("Rebalancer failed to move VM %s from %s to %s: %v", moves[i].VMName, moves[i].FromDC, moves[i].ToDC, err)
+		} else {
+			moves[i].Status = MoveStarted
+			moves[i].MigrationID = migrationID
+			inFlight++
+			log.Printf("Rebalancer moving VM %s from %s to %s", moves[i].VMName, moves[i].FromDC, moves[i].ToDC)
+		}
+		c.notify("rebalancer:decision", moves[i])
+	}
+
+	checkState := StateRebalancing
+	if blackout {
+		checkState = StateBlackout
+	}
+	c.finishCheck(now, checkState, imbalance, inFlight, moves, nil)
+}
+
+// plan decides up to MaxConcurrent moves, applying each to the state before
+// deciding the next, until the imbalance drops below the threshold
+func (c *Controller) plan(state *placement.State, config Config, now time.Time) []Move {
+	admit := func(candidate placement.Candidate) error {
+		if last := candidate.VM.LastMigratedAt; last != nil && now.Sub(*last) < time.Duration(config.Cooldown) {
+			return fmt.Errorf("VM %s migrated %s ago and is cooling down", candidate.VM.Name, now.Sub(*last).Round(time.Second))
+		}
+		return c.cluster.Admit(candidate)
+	}
+
+	moves := []Move{}
+	for len(moves) < config.MaxConcurrent && state.Imbalance() >= config.Threshold {
+		decision, _, err := c.engine.Decide(config.Strategy, state, admit)
+		if err != nil || decision == nil {
+			break
+		}
+		state.Move(decision)
+		moves = append(moves, Move{
+			VMID:     decision.VMID,
+			VMName:   decision.VMName,
+			FromDC:   decision.FromDC,
+			ToDC:     decision.ToDC,
+			Strategy: decision.Strategy,
+			Score:    decision.Score,
+			Reasons:  decision.Reasons,
+			Time:     now,
+		})
+	}
+	return moves
+}
+
+// finishCheck records the outcome of a check in the status
+func (c *Controller) finishCheck(now time.Time, state string, imbalance, inFlight int, moves []Move, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.status.LastCheck = &now
+	c.status.State = state
+	c.status.Imbalance = imbalance
+	c.status.InFlight = inFlight
+	c.status.Error = ""
+	if err != nil {
+		c.status.Error = err.Error()
+	}
+	if moves == nil {
+		moves = []Move{}
+	}
+	c.status.Planned = moves
+
+	for _, move := range moves {
+		if move.Status == MoveDeferred {
+			continue
+		}
+		c.status.History = append([]Move{move}, c.status.History...)
+	}
+	if len(c.status.History) > historySize {
+		c.status.History = c.status.History[:historySize]
+	}
+}
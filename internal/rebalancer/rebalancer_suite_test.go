@@ -0,0 +1,13 @@
+package rebalancer_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRebalancer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rebalancer Suite")
+}
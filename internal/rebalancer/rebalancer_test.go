@@ -0,0 +1,250 @@
+package rebalancer_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/placement"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/rebalancer"
+)
+
+// fakeCluster moves VMs between in-memory datacenters
+type fakeCluster struct {
+	mu          sync.Mutex
+	datacenters *models.DatacenterCollection
+	executeErr  error
+	executed    []string
+}
+
+func (f *fakeCluster) State() (*placement.State, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	collection := &models.DatacenterCollection{}
+	for _, dc := range f.datacenters.Datacenters {
+		dc.VMs = append([]models.VM{}, dc.VMs...)
+		collection.Datacenters = append(collection.Datacenters, dc)
+	}
+	return placement.NewState(collection, models.ComputeUtilization(collection, nil)), nil
+}
+
+func (f *fakeCluster) Admit(placement.Candidate) error {
+	return nil
+}
+
+func (f *fakeCluster) Execute(_ context.Context, decision *placement.Decision) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.executeErr != nil {
+		return "", f.executeErr
+	}
+	f.executed = append(f.executed, decision.VMID)
+
+	// Leave the VM migrating in the target, as the watcher would
+	var moved models.VM
+	for i := range f.datacenters.Datacenters {
+		dc := &f.datacenters.Datacenters[i]
+		for j, vm := range dc.VMs {
+			if vm.ID == decision.VMID {
+				moved = vm
+				dc.VMs = append(dc.VMs[:j], dc.VMs[j+1:]...)
+				break
+			}
+		}
+	}
+	moved.MigrationStatus = "migrating"
+	for i := range f.datacenters.Datacenters {
+		if f.datacenters.Datacenters[i].ID == decision.ToDC {
+			f.datacenters.Datacenters[i].VMs = append(f.datacenters.Datacenters[i].VMs, moved)
+		}
+	}
+	return "migration-" + decision.VMID, nil
+}
+
+func runningVMs(ids ...string) []models.VM {
+	vms := make([]models.VM, len(ids))
+	for i, id := range ids {
+		vms[i] = models.VM{ID: id, Name: id, Status: "running", CPU: 1, Memory: 1024}
+	}
+	return vms
+}
+
+var _ = Describe("Controller", func() {
+	var (
+		cluster    *fakeCluster
+		controller *rebalancer.Controller
+		config     rebalancer.Config
+		now        time.Time
+		events     []string
+		eventsMu   sync.Mutex
+	)
+
+	BeforeEach(func() {
+		cluster = &fakeCluster{datacenters: &models.DatacenterCollection{Datacenters: []models.Datacenter{
+			{ID: "dc-a", VMs: runningVMs("vm-1", "vm-2", "vm-3", "vm-4", "vm-5")},
+			{ID: "dc-b", VMs: runningVMs("vm-6")},
+		}}}
+		events = nil
+		controller = rebalancer.New(placement.NewEngine(placement.DefaultOptions()), cluster, func(eventType string, _ interface{}) {
+			eventsMu.Lock()
+			defer eventsMu.Unlock()
+			events = append(events, eventType)
+		})
+		// A Wednesday at noon
+		now = time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+		controller.SetClock(func() time.Time { return now })
+
+		config = rebalancer.DefaultConfig()
+		config.MaxConcurrent = 2
+	})
+
+	AfterEach(func() {
+		controller.Stop()
+	})
+
+	It("starts moves up to the concurrency limit", func() {
+		Expect(controller.Start(config)).To(Succeed())
+		Eventually(func() []string {
+			cluster.mu.Lock()
+			defer cluster.mu.Unlock()
+			return cluster.executed
+		}).Should(HaveLen(2))
+
+		status := controller.Status()
+		Expect(status.Running).To(BeTrue())
+		Expect(status.State).To(Equal(rebalancer.StateRebalancing))
+		Expect(status.Imbalance).To(Equal(4))
+		Expect(status.InFlight).To(Equal(2))
+		Expect(status.Planned).To(HaveLen(2))
+		Expect(status.Planned[0].Status).To(Equal(rebalancer.MoveStarted))
+		Expect(status.Planned[0].FromDC).To(Equal("dc-a"))
+		Expect(status.Planned[0].MigrationID).To(Equal("migration-" + status.Planned[0].VMID))
+		Expect(status.History).To(HaveLen(2))
+
+		eventsMu.Lock()
+		Expect(events).To(Equal([]string{"rebalancer:started", "rebalancer:decision", "rebalancer:decision"}))
+		eventsMu.Unlock()
+	})
+
+	It("waits for migrations in flight", func() {
+		// Checks run with the default configuration, one migration at a time
+		controller.Check(context.Background())
+		controller.Check(context.Background())
+
+		Expect(cluster.executed).To(HaveLen(1))
+		status := controller.Status()
+		Expect(status.InFlight).To(Equal(1))
+		Expect(status.Planned).NotTo(BeEmpty())
+		for _, move := range status.Planned {
+			Expect(move.Status).To(Equal(rebalancer.MoveDeferred))
+		}
+	})
+
+	It("stays idle while the imbalance is below the threshold", func() {
+		config.Threshold = 5
+		Expect(controller.Start(config)).To(Succeed())
+		Eventually(func() *time.Time { return controller.Status().LastCheck }).ShouldNot(BeNil())
+
+		Expect(controller.Status().State).To(Equal(rebalancer.StateBalanced))
+		Expect(cluster.executed).To(BeEmpty())
+	})
+
+	It("skips VMs in their cooldown", func() {
+		recently := now.Add(-time.Minute)
+		for i := range cluster.datacenters.Datacenters[0].VMs {
+			if i > 0 {
+				cluster.datacenters.Datacenters[0].VMs[i].LastMigratedAt = &recently
+			}
+		}
+
+		controller.Check(context.Background())
+		Expect(cluster.executed).To(Equal([]string{"vm-1"}))
+	})
+
+	It("defers moves during a blackout", func() {
+		window, err := rebalancer.ParseWindow("wed 11:00-13:00")
+		Expect(err).NotTo(HaveOccurred())
+		config.Blackouts = []rebalancer.Window{window}
+		Expect(controller.Start(config)).To(Succeed())
+		Eventually(func() string { return controller.Status().State }).Should(Equal(rebalancer.StateBlackout))
+
+		Expect(cluster.executed).To(BeEmpty())
+		Expect(controller.Status().Planned).To(HaveLen(2))
+		Expect(controller.Status().Planned[0].Status).To(Equal(rebalancer.MoveDeferred))
+	})
+
+	It("records failed moves", func() {
+		cluster.executeErr = errors.New("cluster unreachable")
+		controller.Check(context.Background())
+
+		status := controller.Status()
+		Expect(status.Planned[0].Status).To(Equal(rebalancer.MoveFailed))
+		Expect(status.Planned[0].Error).To(Equal("cluster unreachable"))
+		Expect(status.InFlight).To(Equal(0))
+	})
+
+	It("rejects invalid configurations", func() {
+		config.MaxConcurrent = 0
+		Expect(controller.Start(config)).To(HaveOccurred())
+
+		config = rebalancer.DefaultConfig()
+		config.Strategy = "nope"
+		Expect(controller.Start(config)).To(MatchError(ContainSubstring("unknown placement strategy")))
+	})
+
+	It("stops", func() {
+		Expect(controller.Start(config)).To(Succeed())
+		controller.Stop()
+
+		status := controller.Status()
+		Expect(status.Running).To(BeFalse())
+		Expect(status.State).To(Equal(rebalancer.StateStopped))
+	})
+})
+
+var _ = Describe("Window", func() {
+	at := func(weekday time.Weekday, hour, minute int) time.Time {
+		// 2025-10-12 is a Sunday
+		return time.Date(2025, 10, 12+int(weekday), hour, minute, 0, 0, time.UTC)
+	}
+
+	It("covers a period of every day", func() {
+		window, err := rebalancer.ParseWindow("09:00-17:30")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(window.Contains(at(time.Monday, 9, 0))).To(BeTrue())
+		Expect(window.Contains(at(time.Sunday, 17, 29))).To(BeTrue())
+		Expect(window.Contains(at(time.Monday, 17, 30))).To(BeFalse())
+		Expect(window.String()).To(Equal("09:00-17:30"))
+	})
+
+	It("runs past midnight into the next day", func() {
+		window, err := rebalancer.ParseWindow("fri 22:00-06:00")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(window.Contains(at(time.Friday, 23, 0))).To(BeTrue())
+		Expect(window.Contains(at(time.Saturday, 5, 59))).To(BeTrue())
+		Expect(window.Contains(at(time.Friday, 5, 0))).To(BeFalse())
+		Expect(window.Contains(at(time.Saturday, 23, 0))).To(BeFalse())
+	})
+
+	It("limits a window to weekdays", func() {
+		window, err := rebalancer.ParseWindow("sat,sun 00:00-24:00")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(window.Contains(at(time.Sunday, 23, 59))).To(BeTrue())
+		Expect(window.Contains(at(time.Monday, 0, 0))).To(BeFalse())
+		Expect(window.String()).To(Equal("sat,sun 00:00-24:00"))
+	})
+
+	It("rejects malformed windows", func() {
+		for _, s := range []string{"", "9-17", "fri", "xyz 09:00-10:00", "09:00-25:00", "a b c"} {
+			_, err := rebalancer.ParseWindow(s)
+			Expect(err).To(HaveOccurred(), s)
+		}
+	})
+})
@@ -0,0 +1,128 @@
+package rebalancer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a recurring period of the day, optionally limited to some
+// weekdays, during which the rebalancer doesn't start migrations. Windows
+// are written as "[days ]HH:MM-HH:MM", for example "22:00-06:00" or
+// "sat,sun 00:00-24:00"; a window ending before it starts runs past midnight.
+type Window struct {
+	Days  []time.Weekday
+	Start time.Duration // Since midnight
+	End   time.Duration // Since midnight
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWindow parses a blackout window
+func ParseWindow(s string) (Window, error) {
+	var w Window
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+	case 2:
+		for _, day := range strings.Split(fields[0], ",") {
+			weekday, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				return w, fmt.Errorf("invalid weekday %q in window %q", day, s)
+			}
+			w.Days = append(w.Days, weekday)
+		}
+	default:
+		return w, fmt.Errorf("invalid window %q: want \"[days ]HH:MM-HH:MM\"", s)
+	}
+
+	start, end, ok := strings.Cut(fields[len(fields)-1], "-")
+	if !ok {
+		return w, fmt.Errorf("invalid window %q: want \"[days ]HH:MM-HH:MM\"", s)
+	}
+	var err error
+	if w.Start, err = parseClock(start); err != nil {
+		return w, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return w, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	return w, nil
+}
+
+// parseClock parses HH:MM into the time since midnight, allowing 24:00
+func parseClock(s string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(s, "%d:%d", &hours, &minutes); err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	if hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > 24*60 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// Contains reports whether t, in its own location, falls in the window
+func (w Window) Contains(t time.Time) bool {
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if w.Start <= w.End {
+		return w.onDay(t.Weekday()) && clock >= w.Start && clock < w.End
+	}
+	// Runs past midnight: the early hours belong to the previous day's window
+	if clock >= w.Start {
+		return w.onDay(t.Weekday())
+	}
+	return clock < w.End && w.onDay((t.Weekday()+6)%7)
+}
+
+// onDay reports whether the window applies to a weekday
+func (w Window) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the window the way ParseWindow reads it
+func (w Window) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	s := clock(w.Start) + "-" + clock(w.End)
+	if len(w.Days) == 0 {
+		return s
+	}
+	days := make([]string, len(w.Days))
+	for i, day := range w.Days {
+		days[i] = strings.ToLower(day.String()[:3])
+	}
+	return strings.Join(days, ",") + " " + s
+}
+
+// MarshalText encodes the window as a string
+func (w Window) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+// UnmarshalText decodes a window string
+func (w *Window) UnmarshalText(text []byte) error {
+	parsed, err := ParseWindow(string(text))
+	if err != nil {
+		return err
+	}
+	*w = parsed
+	return nil
+}
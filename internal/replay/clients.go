@@ -0,0 +1,226 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// errReadOnly is returned for any change requested while replaying
+var errReadOnly = errors.New("clusters are replayed from a recording and can't be changed")
+
+// kubevirtClient serves the resources the cluster watchers use from a
+// replayed cluster. Any other call panics on the nil embedded interface.
+type kubevirtClient struct {
+	kubecli.KubevirtClient
+	player  *Player
+	cluster *cluster
+}
+
+func (k *kubevirtClient) VirtualMachine(namespace string) kubecli.VirtualMachineInterface {
+	return &vmClient{resourceClient: k.resource(watcher.ResourceVM, "virtualmachines", namespace)}
+}
+
+func (k *kubevirtClient) VirtualMachineInstance(namespace string) kubecli.VirtualMachineInstanceInterface {
+	return &vmiClient{resourceClient: k.resource(watcher.ResourceVMI, "virtualmachineinstances", namespace)}
+}
+
+func (k *kubevirtClient) VirtualMachineInstanceMigration(namespace string) kubecli.VirtualMachineInstanceMigrationInterface {
+	return &migrationClient{resourceClient: k.resource(watcher.ResourceMigration, "virtualmachineinstancemigrations", namespace)}
+}
+
+func (k *kubevirtClient) resource(resource watcher.RecordedResource, plural, namespace string) resourceClient {
+	return resourceClient{
+		player:    k.player,
+		cluster:   k.cluster,
+		resource:  resource,
+		group:     schema.GroupResource{Group: kubevirtv1.GroupVersion.Group, Resource: plural},
+		namespace: namespace,
+	}
+}
+
+// resourceClient holds what the typed clients share
+type resourceClient struct {
+	player    *Player
+	cluster   *cluster
+	resource  watcher.RecordedResource
+	group     schema.GroupResource
+	namespace string
+}
+
+// list returns the replayed objects of the client's namespace
+func (r resourceClient) list() []runtime.Object {
+	r.player.mu.Lock()
+	defer r.player.mu.Unlock()
+
+	var objects []runtime.Object
+	for _, object := range r.cluster.objects[r.resource] {
+		accessor, err := meta.Accessor(object)
+		if err == nil && (r.namespace == "" || accessor.GetNamespace() == r.namespace) {
+			objects = append(objects, object.DeepCopyObject())
+		}
+	}
+	return objects
+}
+
+// get returns a replayed object by name
+func (r resourceClient) get(name string) (runtime.Object, error) {
+	r.player.mu.Lock()
+	defer r.player.mu.Unlock()
+
+	object, ok := r.cluster.objects[r.resource][r.namespace+"/"+name]
+	if !ok {
+		return nil, apierrors.NewNotFound(r.group, name)
+	}
+	return object.DeepCopyObject(), nil
+}
+
+// watch subscribes to the replayed events of the client's namespace
+func (r resourceClient) watch() (watch.Interface, error) {
+	w, err := r.cluster.streams[r.resource].Watch()
+	if err != nil {
+		return nil, err
+	}
+
+	r.player.mu.Lock()
+	r.cluster.watches++
+	r.player.mu.Unlock()
+	select {
+	case r.player.watching <- struct{}{}:
+	default:
+	}
+
+	if r.namespace == "" {
+		return w, nil
+	}
+	return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+		accessor, err := meta.Accessor(e.Object)
+		return e, err == nil && accessor.GetNamespace() == r.namespace
+	}), nil
+}
+
+// vmClient serves replayed VirtualMachines
+type vmClient struct {
+	kubecli.VirtualMachineInterface
+	resourceClient
+}
+
+func (c *vmClient) List(_ context.Context, _ metav1.ListOptions) (*kubevirtv1.VirtualMachineList, error) {
+	list := &kubevirtv1.VirtualMachineList{}
+	for _, object := range c.list() {
+		list.Items = append(list.Items, *object.(*kubevirtv1.VirtualMachine))
+	}
+	return list, nil
+}
+
+func (c *vmClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	return c.watch()
+}
+
+func (c *vmClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*kubevirtv1.VirtualMachine, error) {
+	object, err := c.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return object.(*kubevirtv1.VirtualMachine), nil
+}
+
+func (c *vmClient) Start(context.Context, string, *kubevirtv1.StartOptions) error {
+	return errReadOnly
+}
+
+func (c *vmClient) Stop(context.Context, string, *kubevirtv1.StopOptions) error {
+	return errReadOnly
+}
+
+func (c *vmClient) Restart(context.Context, string, *kubevirtv1.RestartOptions) error {
+	return errReadOnly
+}
+
+// vmiClient serves replayed VirtualMachineInstances
+type vmiClient struct {
+	kubecli.VirtualMachineInstanceInterface
+	resourceClient
+}
+
+func (c *vmiClient) List(_ context.Context, _ metav1.ListOptions) (*kubevirtv1.VirtualMachineInstanceList, error) {
+	list := &kubevirtv1.VirtualMachineInstanceList{}
+	for _, object := range c.list() {
+		list.Items = append(list.Items, *object.(*kubevirtv1.VirtualMachineInstance))
+	}
+	return list, nil
+}
+
+func (c *vmiClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	return c.watch()
+}
+
+func (c *vmiClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*kubevirtv1.VirtualMachineInstance, error) {
+	object, err := c.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return object.(*kubevirtv1.VirtualMachineInstance), nil
+}
+
+func (c *vmiClient) Pause(context.Context, string, *kubevirtv1.PauseOptions) error {
+	return errReadOnly
+}
+
+func (c *vmiClient) Unpause(context.Context, string, *kubevirtv1.UnpauseOptions) error {
+	return errReadOnly
+}
+
+// migrationClient serves replayed VirtualMachineInstanceMigrations
+type migrationClient struct {
+	kubecli.VirtualMachineInstanceMigrationInterface
+	resourceClient
+}
+
+func (c *migrationClient) List(_ context.Context, _ metav1.ListOptions) (*kubevirtv1.VirtualMachineInstanceMigrationList, error) {
+	list := &kubevirtv1.VirtualMachineInstanceMigrationList{}
+	for _, object := range c.list() {
+		list.Items = append(list.Items, *object.(*kubevirtv1.VirtualMachineInstanceMigration))
+	}
+	return list, nil
+}
+
+func (c *migrationClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	return c.watch()
+}
+
+func (c *migrationClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+	object, err := c.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return object.(*kubevirtv1.VirtualMachineInstanceMigration), nil
+}
+
+func (c *migrationClient) Create(context.Context, *kubevirtv1.VirtualMachineInstanceMigration, metav1.CreateOptions) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+	return nil, errReadOnly
+}
+
+func (c *migrationClient) Delete(context.Context, string, metav1.DeleteOptions) error {
+	return errReadOnly
+}
+
+// objectKey returns the namespace/name key of an object
+func objectKey(object runtime.Object) (string, error) {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return "", fmt.Errorf("recorded object has no metadata: %w", err)
+	}
+	return accessor.GetNamespace() + "/" + accessor.GetName(), nil
+}
@@ -0,0 +1,83 @@
+package replay
+
+import (
+	"context"
+
+	k8sv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// kubernetesClient serves the core resources the cluster watchers use from
+// a replayed cluster. Any other call panics on the nil embedded interface.
+type kubernetesClient struct {
+	kubernetes.Interface
+	player  *Player
+	cluster *cluster
+}
+
+func (k *kubernetesClient) CoreV1() corev1client.CoreV1Interface {
+	return &coreClient{player: k.player, cluster: k.cluster}
+}
+
+// coreClient serves recorded nodes. Claims are not recorded, so every
+// PersistentVolumeClaim is reported as missing.
+type coreClient struct {
+	corev1client.CoreV1Interface
+	player  *Player
+	cluster *cluster
+}
+
+func (c *coreClient) Nodes() corev1client.NodeInterface {
+	return &nodeClient{resourceClient: resourceClient{
+		player:   c.player,
+		cluster:  c.cluster,
+		resource: watcher.ResourceNode,
+		group:    schema.GroupResource{Resource: "nodes"},
+	}}
+}
+
+func (c *coreClient) PersistentVolumeClaims(string) corev1client.PersistentVolumeClaimInterface {
+	return &pvcClient{}
+}
+
+// nodeClient serves replayed Nodes
+type nodeClient struct {
+	corev1client.NodeInterface
+	resourceClient
+}
+
+func (c *nodeClient) List(_ context.Context, _ metav1.ListOptions) (*k8sv1.NodeList, error) {
+	list := &k8sv1.NodeList{}
+	for _, object := range c.list() {
+		list.Items = append(list.Items, *object.(*k8sv1.Node))
+	}
+	return list, nil
+}
+
+func (c *nodeClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	return c.watch()
+}
+
+func (c *nodeClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*k8sv1.Node, error) {
+	object, err := c.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return object.(*k8sv1.Node), nil
+}
+
+// pvcClient has no claims to serve
+type pvcClient struct {
+	corev1client.PersistentVolumeClaimInterface
+}
+
+func (c *pvcClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*k8sv1.PersistentVolumeClaim, error) {
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "persistentvolumeclaims"}, name)
+}
@@ -0,0 +1,198 @@
+// Package replay feeds a recording of cluster watch events back through the
+// regular cluster watchers. Each recorded cluster is served by read-only
+// KubeVirt and Kubernetes clients whose watches deliver the recorded
+// events, so they take the same conversion and store path as live events.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// Options controls the pace of a replay
+type Options struct {
+	// Speed scales the recorded gaps between events: 1 is real time, 10
+	// ten times faster. Zero delivers events without waiting.
+	Speed float64
+	// Step delivers one event per call to Player.Next instead of following
+	// the recorded timing
+	Step bool
+}
+
+// Player replays a recording
+type Player struct {
+	events  []watcher.RecordedEvent
+	options Options
+	next    chan struct{}
+
+	mu       sync.Mutex
+	clusters map[string]*cluster
+	watching chan struct{}
+}
+
+// cluster serves one recorded cluster
+type cluster struct {
+	name    string
+	streams map[watcher.RecordedResource]*watch.Broadcaster
+	watches int
+	// objects holds the latest replayed state keyed by resource and
+	// namespace/name, for Get calls made while converting events
+	objects map[watcher.RecordedResource]map[string]runtime.Object
+}
+
+// New creates a player for a recording
+func New(events []watcher.RecordedEvent, options Options) *Player {
+	return &Player{
+		events:   events,
+		options:  options,
+		next:     make(chan struct{}),
+		clusters: make(map[string]*cluster),
+		watching: make(chan struct{}, 1),
+	}
+}
+
+// ClientFactory serves a configured cluster from the recording. It
+// satisfies watcher.ClientFactory; configured clusters missing from the
+// recording simply stay empty.
+func (p *Player) ClientFactory(config watcher.ClusterConfig) (*watcher.ClusterClients, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.clusters[config.Name]
+	if !ok {
+		c = &cluster{
+			name:    config.Name,
+			streams: make(map[watcher.RecordedResource]*watch.Broadcaster),
+			objects: make(map[watcher.RecordedResource]map[string]runtime.Object),
+		}
+		for _, resource := range []watcher.RecordedResource{watcher.ResourceVM, watcher.ResourceVMI, watcher.ResourceMigration, watcher.ResourceNode} {
+			c.streams[resource] = watch.NewBroadcaster(1000, watch.WaitIfChannelFull)
+			c.objects[resource] = make(map[string]runtime.Object)
+		}
+		p.clusters[config.Name] = c
+	}
+	return &watcher.ClusterClients{
+		Kubernetes: &kubernetesClient{player: p, cluster: c},
+		KubeVirt:   &kubevirtClient{player: p, cluster: c},
+	}, nil
+}
+
+// Next releases the next event in step mode. It blocks until the player
+// is ready for it.
+func (p *Player) Next(ctx context.Context) {
+	select {
+	case p.next <- struct{}{}:
+	case <-ctx.Done():
+	}
+}
+
+// Run delivers the recording once every served cluster is being watched.
+// It returns when all events have been delivered or ctx ends.
+func (p *Player) Run(ctx context.Context) error {
+	if err := p.waitForWatches(ctx); err != nil {
+		return err
+	}
+	log.Printf("Replaying %d recorded events", len(p.events))
+
+	var previous time.Time
+	for i, event := range p.events {
+		if err := p.wait(ctx, previous, event.Time); err != nil {
+			return err
+		}
+		previous = event.Time
+
+		if err := p.deliver(event); err != nil {
+			log.Printf("Skipping recorded event %d: %v", i+1, err)
+		}
+	}
+
+	log.Printf("Replay finished")
+	return nil
+}
+
+// waitForWatches blocks until each served cluster has watches on all
+// resources, so no event is broadcast before the watchers listen
+func (p *Player) waitForWatches(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		ready := len(p.clusters) > 0
+		for _, c := range p.clusters {
+			if c.watches < len(c.streams) {
+				ready = false
+			}
+		}
+		p.mu.Unlock()
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.watching:
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// wait holds the next event back according to the replay mode
+func (p *Player) wait(ctx context.Context, previous, current time.Time) error {
+	if p.options.Step {
+		select {
+		case <-p.next:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if p.options.Speed <= 0 || previous.IsZero() || !current.After(previous) {
+		return ctx.Err()
+	}
+	select {
+	case <-time.After(time.Duration(float64(current.Sub(previous)) / p.options.Speed)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver broadcasts a recorded event to its cluster's watchers
+func (p *Player) deliver(event watcher.RecordedEvent) error {
+	object, err := event.Decode()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	c, ok := p.clusters[event.Cluster]
+	if !ok {
+		p.mu.Unlock()
+		return fmt.Errorf("cluster %s is not configured", event.Cluster)
+	}
+	key, err := objectKey(object)
+	if err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	if event.Type == watch.Deleted {
+		delete(c.objects[event.Resource], key)
+	} else {
+		c.objects[event.Resource][key] = object
+	}
+	stream := c.streams[event.Resource]
+	p.mu.Unlock()
+
+	if p.options.Step {
+		log.Printf("Replay: %s %s %s in cluster %s", event.Type, event.Resource, key, event.Cluster)
+	}
+	return stream.Action(event.Type, object.DeepCopyObject())
+}
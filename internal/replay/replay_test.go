@@ -0,0 +1,118 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/mocks"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// The fixture was recorded from a simulated run in which alpha-web-1 was
+// live migrated from cluster alpha (dc-test-1) to beta (dc-test-2)
+const fixture = "testdata/cross-cluster-migration.ndjson"
+
+var _ = Describe("Replay", func() {
+	var (
+		store  *mocks.MockStore
+		player *Player
+		w      *watcher.VMWatcher
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+
+	start := func(options Options) {
+		events, err := watcher.LoadRecording(fixture)
+		Expect(err).NotTo(HaveOccurred())
+		player = New(events, options)
+
+		configPath := filepath.Join(GinkgoT().TempDir(), "datacenters.yaml")
+		Expect(os.WriteFile(configPath, []byte(`datacenters:
+  - id: dc-test-1
+    clusters:
+    - name: alpha
+  - id: dc-test-2
+    clusters:
+    - name: beta
+`), 0o644)).To(Succeed())
+
+		store = mocks.NewMockStore()
+		store.InitializeWithSampleData()
+		watcherOptions := watcher.DefaultOptions()
+		watcherOptions.ClientFactory = player.ClientFactory
+		w, err = watcher.NewVMWatcher(store, configPath, watcherOptions)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Start()).To(Succeed())
+
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	AfterEach(func() {
+		cancel()
+		w.Stop()
+	})
+
+	vmLocation := func(name string) func() string {
+		return func() string {
+			for _, dc := range store.GetDatacenters().Datacenters {
+				for _, vm := range dc.VMs {
+					if vm.Name == name {
+						return dc.ID + "/" + vm.Cluster + "/" + vm.Status
+					}
+				}
+			}
+			return ""
+		}
+	}
+
+	It("should rebuild the recorded outcome through the store", func() {
+		start(Options{})
+
+		Expect(player.Run(ctx)).To(Succeed())
+
+		Eventually(vmLocation("alpha-web-1"), 5*time.Second).Should(Equal("dc-test-2/beta/running"))
+		Eventually(vmLocation("beta-web-1"), 5*time.Second).Should(Equal("dc-test-2/beta/running"))
+		Eventually(func() string {
+			migration, err := store.GetMigration("alpha-web-1-788adf81-send")
+			if err != nil {
+				return ""
+			}
+			return migration.Phase
+		}, 5*time.Second).Should(Equal("Succeeded"))
+
+		migrations, err := store.GetAllMigrations()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(migrations).To(ContainElement(HaveField("MigrationID", "alpha-web-1-788adf81")))
+	})
+
+	It("should wait for each step in step mode", func() {
+		start(Options{Step: true})
+
+		done := make(chan error, 1)
+		go func() { done <- player.Run(ctx) }()
+
+		Consistently(vmLocation("beta-web-1"), 300*time.Millisecond).Should(BeEmpty())
+
+		// The first recorded event is beta-web-1's instance; its VM follows
+		player.Next(ctx)
+		player.Next(ctx)
+		Eventually(vmLocation("beta-web-1"), 5*time.Second).Should(Equal("dc-test-2/beta/running"))
+		Expect(vmLocation("alpha-web-1")()).To(BeEmpty())
+
+		cancel()
+		Eventually(done).Should(Receive(MatchError(context.Canceled)))
+	})
+
+	It("should refuse changes to replayed clusters", func() {
+		start(Options{})
+
+		_, err := w.StartMigration(context.Background(), watcher.MigrationPlan{VMName: "alpha-web-1", Namespace: "demo", SourceCluster: "alpha", TargetCluster: "alpha"})
+		Expect(err).To(MatchError(ContainSubstring("replayed")))
+		Expect(w.PowerAction(context.Background(), "alpha", "demo", "alpha-web-1", watcher.PowerStop)).To(MatchError(ContainSubstring("replayed")))
+	})
+})
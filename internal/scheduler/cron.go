@@ -0,0 +1,216 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronHorizon is how far ahead Next looks for a matching time, so that
+// expressions that never match, such as "0 0 30 2 *", end the search
+const cronHorizon = 5 // years
+
+// Cron is a parsed cron expression with the five fields minute, hour, day
+// of month, month and day of week, in server time. A field is "*", a
+// value, a range "1-5", a step "*/10" or "8-18/2", or a list of those
+// "0,30"; months and weekdays may be named ("jan", "mon"). When both day
+// fields are restricted a day matching either one matches, as in cron.
+// "@hourly", "@daily", "@weekly", "@monthly" and "@every <duration>" are
+// shorthands.
+type Cron struct {
+	spec  string
+	every time.Duration
+
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// cronField describes the values one field of an expression takes
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Names of the values from min on
+}
+
+var (
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// Sunday is both 0 and 7
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+var cronShorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// ParseCron parses a cron expression
+func ParseCron(spec string) (*Cron, error) {
+	spec = strings.TrimSpace(spec)
+	c := &Cron{spec: spec}
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", spec, err)
+		}
+		if every < time.Second {
+			return nil, fmt.Errorf("invalid cron expression %q: interval must be at least 1s", spec)
+		}
+		c.every = every
+		return c, nil
+	}
+	expanded := spec
+	if full, ok := cronShorthands[strings.ToLower(spec)]; ok {
+		expanded = full
+	}
+
+	fields := strings.Fields(expanded)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: want 5 fields, got %d", spec, len(fields))
+	}
+	var err error
+	for i, target := range []*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow} {
+		field := []cronField{minuteField, hourField, domField, monthField, dowField}[i]
+		if *target, err = field.parse(fields[i]); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", spec, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return c, nil
+}
+
+// parse returns the values a field matches as a bit set
+func (f cronField) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %s %q", f.name, part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		var lo, hi int
+		switch {
+		case part == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s %q", f.name, part)
+			}
+		default:
+			var err error
+			if lo, err = f.value(part); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if step > 1 {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses one value of a field, a number or a name
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, want %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after the given one that the expression
+// matches, or the zero time if it matches none within five years. An
+// "@every" expression matches its interval after the given time.
+func (c *Cron) Next(after time.Time) time.Time {
+	if c.every > 0 {
+		return after.Add(c.every)
+	}
+
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), 0, 0, after.Location()).Add(time.Minute)
+	limit := t.AddDate(cronHorizon, 0, 0)
+	for t.Before(limit) {
+		if c.matchesDay(t) {
+			if next, ok := c.onDay(t); ok {
+				return next
+			}
+		}
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the expression matches the day of t
+func (c *Cron) matchesDay(t time.Time) bool {
+	if c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// onDay returns the first matching time on the day of t, from t on
+func (c *Cron) onDay(t time.Time) (time.Time, bool) {
+	for hour := t.Hour(); hour < 24; hour++ {
+		if c.hour&(1<<uint(hour)) == 0 {
+			continue
+		}
+		minute := 0
+		if hour == t.Hour() {
+			minute = t.Minute()
+		}
+		for ; minute < 60; minute++ {
+			if c.minute&(1<<uint(minute)) != 0 {
+				return time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location()), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// String returns the expression as it was written
+func (c *Cron) String() string {
+	return c.spec
+}
@@ -0,0 +1,57 @@
+package scheduler_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/scheduler"
+)
+
+var _ = Describe("Cron", func() {
+	// A Wednesday
+	start := time.Date(2025, 6, 11, 10, 7, 30, 0, time.UTC)
+
+	next := func(spec string, after time.Time) time.Time {
+		cron, err := scheduler.ParseCron(spec)
+		Expect(err).NotTo(HaveOccurred())
+		return cron.Next(after)
+	}
+
+	DescribeTable("finds the next matching time",
+		func(spec string, want time.Time) {
+			Expect(next(spec, start)).To(Equal(want))
+		},
+		Entry("every minute", "* * * * *", time.Date(2025, 6, 11, 10, 8, 0, 0, time.UTC)),
+		Entry("a step", "*/15 * * * *", time.Date(2025, 6, 11, 10, 15, 0, 0, time.UTC)),
+		Entry("a list", "5,40 * * * *", time.Date(2025, 6, 11, 10, 40, 0, 0, time.UTC)),
+		Entry("a ranged step", "0 8-18/4 * * *", time.Date(2025, 6, 11, 12, 0, 0, 0, time.UTC)),
+		Entry("the next day", "0 2 * * *", time.Date(2025, 6, 12, 2, 0, 0, 0, time.UTC)),
+		Entry("a named weekday", "30 9 * * mon-fri", time.Date(2025, 6, 12, 9, 30, 0, 0, time.UTC)),
+		Entry("Sunday as 7", "0 0 * * 7", time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)),
+		Entry("a named month", "0 0 1 jan *", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("day of month or weekday", "0 0 20 * 5", time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)),
+		Entry("a leap day", "0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)),
+		Entry("@daily", "@daily", time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)),
+		Entry("@every", "@every 90m", start.Add(90*time.Minute)),
+	)
+
+	It("never matches an impossible date", func() {
+		Expect(next("0 0 30 2 *", start)).To(BeZero())
+	})
+
+	DescribeTable("rejects invalid expressions",
+		func(spec string) {
+			_, err := scheduler.ParseCron(spec)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("too few fields", "* * * *"),
+		Entry("a value out of range", "60 * * * *"),
+		Entry("a reversed range", "0 5-2 * * *"),
+		Entry("a zero step", "*/0 * * * *"),
+		Entry("an unknown name", "0 0 * * someday"),
+		Entry("a bad interval", "@every soon"),
+		Entry("a tiny interval", "@every 10ms"),
+	)
+})
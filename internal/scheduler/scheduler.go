@@ -0,0 +1,377 @@
+// Package scheduler runs migration jobs at a set time or on a cron
+// schedule. Schedules are kept in the store so they survive restarts; a
+// schedule that came due while the server was down runs once when it is
+// back, however many runs it missed.
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+// historySize is how many runs a schedule keeps
+const historySize = 20
+
+// The loop sleeps at least minWait, so that a schedule it fails to save
+// doesn't keep it spinning, and at most maxWait, so that it notices clock
+// jumps
+const (
+	minWait = time.Second
+	maxWait = time.Minute
+)
+
+// ErrNotFound is returned for a schedule that doesn't exist
+var ErrNotFound = errors.New("schedule not found")
+
+// Store is where the scheduler keeps its schedules
+type Store interface {
+	SaveSchedule(schedule models.Schedule) error
+	GetSchedules() ([]models.Schedule, error)
+	RemoveSchedule(id string) error
+}
+
+// Runner starts the job of a schedule and reports what it did
+type Runner func(ctx context.Context, job models.ScheduleJob) models.ScheduleRun
+
+// Scheduler starts the jobs of the schedules as they come due
+type Scheduler struct {
+	store  Store
+	run    Runner
+	notify func(eventType string, payload interface{})
+
+	// mu serializes changes to the schedules, including recording runs
+	mu   sync.Mutex
+	now  func() time.Time
+	wake chan struct{}
+
+	loopMu sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a stopped scheduler. notify receives the schedule:* events;
+// it may be nil.
+func New(store Store, run Runner, notify func(eventType string, payload interface{})) *Scheduler {
+	if notify == nil {
+		notify = func(string, interface{}) {}
+	}
+	return &Scheduler{
+		store:  store,
+		run:    run,
+		notify: notify,
+		now:    time.Now,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// SetClock replaces the scheduler's clock, for tests
+func (s *Scheduler) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Validate checks the timing of a schedule: it has either a cron expression
+// or a time, and a one-off time is not in the past
+func (s *Scheduler) Validate(schedule models.Schedule) error {
+	s.mu.Lock()
+	now := s.now()
+	s.mu.Unlock()
+	return validate(schedule, now)
+}
+
+func validate(schedule models.Schedule, now time.Time) error {
+	if (schedule.Cron == "") == (schedule.At == nil) {
+		return errors.New("either cron or at is required")
+	}
+	if schedule.Cron != "" {
+		cron, err := ParseCron(schedule.Cron)
+		if err != nil {
+			return err
+		}
+		if cron.Next(now).IsZero() {
+			return fmt.Errorf("cron expression %q never matches", schedule.Cron)
+		}
+	}
+	if schedule.At != nil && schedule.At.Before(now) {
+		return errors.New("at must not be in the past")
+	}
+	return nil
+}
+
+// Create validates and saves a new schedule and returns it with its ID and
+// next run filled in
+func (s *Scheduler) Create(schedule models.Schedule) (models.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if err := validate(schedule, now); err != nil {
+		return models.Schedule{}, err
+	}
+	schedule.ID = newScheduleID()
+	schedule.CreatedAt = now
+	schedule.History = []models.ScheduleRun{}
+	schedule.NextRun = nil
+	if !schedule.Paused {
+		schedule.NextRun = nextRun(schedule, now)
+	}
+	if err := s.store.SaveSchedule(schedule); err != nil {
+		return models.Schedule{}, err
+	}
+
+	log.Printf("Created schedule %s", schedule.ID)
+	s.notify("schedule:created", schedule)
+	s.poke()
+	return schedule, nil
+}
+
+// List returns all schedules, oldest first
+func (s *Scheduler) List() ([]models.Schedule, error) {
+	schedules, err := s.store.GetSchedules()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		if !schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+	return schedules, nil
+}
+
+// Get returns a schedule by ID
+func (s *Scheduler) Get(id string) (models.Schedule, error) {
+	schedules, err := s.store.GetSchedules()
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	for _, schedule := range schedules {
+		if schedule.ID == id {
+			return schedule, nil
+		}
+	}
+	return models.Schedule{}, ErrNotFound
+}
+
+// SetPaused pauses or resumes a schedule. A paused schedule has no next
+// run; resuming it picks the next cron time, or its time for a one-off
+// that hasn't run yet.
+func (s *Scheduler) SetPaused(id string, paused bool) (models.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, err := s.Get(id)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	if schedule.Paused == paused {
+		return schedule, nil
+	}
+	schedule.Paused = paused
+	schedule.NextRun = nil
+	if !paused {
+		schedule.NextRun = nextRun(schedule, s.now())
+	}
+	if err := s.store.SaveSchedule(schedule); err != nil {
+		return models.Schedule{}, err
+	}
+
+	s.notify("schedule:updated", schedule)
+	s.poke()
+	return schedule, nil
+}
+
+// Delete removes a schedule
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	if err := s.store.RemoveSchedule(id); err != nil {
+		return err
+	}
+
+	log.Printf("Deleted schedule %s", id)
+	s.notify("schedule:deleted", map[string]interface{}{"id": id})
+	return nil
+}
+
+// RunNow runs a schedule's job right away, paused or not, without moving
+// its next run
+func (s *Scheduler) RunNow(ctx context.Context, id string) (models.ScheduleRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, err := s.Get(id)
+	if err != nil {
+		return models.ScheduleRun{}, err
+	}
+	run := s.runJob(ctx, &schedule)
+	if err := s.store.SaveSchedule(schedule); err != nil {
+		return run, err
+	}
+	return run, nil
+}
+
+// RunDue runs the jobs of the schedules that are due. The loop calls it
+// whenever the earliest schedule comes due.
+func (s *Scheduler) RunDue(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules, err := s.List()
+	if err != nil {
+		log.Printf("Scheduler failed to load schedules: %v", err)
+		return
+	}
+	for _, schedule := range schedules {
+		if ctx.Err() != nil {
+			return
+		}
+		if schedule.Paused || schedule.NextRun == nil || schedule.NextRun.After(s.now()) {
+			continue
+		}
+
+		s.runJob(ctx, &schedule)
+		schedule.NextRun = nil
+		if schedule.Cron != "" {
+			schedule.NextRun = nextRun(schedule, s.now())
+		}
+		if err := s.store.SaveSchedule(schedule); err != nil {
+			log.Printf("Scheduler failed to save schedule %s: %v", schedule.ID, err)
+		}
+	}
+}
+
+// runJob runs the job of a schedule and records the run in its history.
+// The caller holds s.mu.
+func (s *Scheduler) runJob(ctx context.Context, schedule *models.Schedule) models.ScheduleRun {
+	run := s.run(ctx, schedule.Job)
+	run.Time = s.now()
+
+	log.Printf("Schedule %s ran: %s %s", schedule.ID, run.State, run.Message)
+	schedule.History = append([]models.ScheduleRun{run}, schedule.History...)
+	if len(schedule.History) > historySize {
+		schedule.History = schedule.History[:historySize]
+	}
+	s.notify("schedule:run", map[string]interface{}{"scheduleId": schedule.ID, "run": run})
+	return run
+}
+
+// nextRun returns when a schedule runs next after now: its time if it is a
+// one-off that hasn't come due yet, else the next cron time
+func nextRun(schedule models.Schedule, now time.Time) *time.Time {
+	if schedule.At != nil {
+		for _, run := range schedule.History {
+			if !run.Time.Before(*schedule.At) {
+				return nil
+			}
+		}
+		at := *schedule.At
+		return &at
+	}
+	cron, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return nil
+	}
+	next := cron.Next(now)
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// Start runs the scheduler loop until Stop is called
+func (s *Scheduler) Start() {
+	s.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.loopMu.Lock()
+	s.cancel, s.done = cancel, done
+	s.loopMu.Unlock()
+
+	go func() {
+		defer close(done)
+		for {
+			s.RunDue(ctx)
+			timer := time.NewTimer(s.untilNext())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-s.wake:
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler loop and waits for running jobs to be started
+func (s *Scheduler) Stop() {
+	s.loopMu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.loopMu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// untilNext returns how long until the earliest schedule comes due
+func (s *Scheduler) untilNext() time.Duration {
+	schedules, err := s.store.GetSchedules()
+	if err != nil {
+		return maxWait
+	}
+	s.mu.Lock()
+	now := s.now()
+	s.mu.Unlock()
+
+	wait := maxWait
+	for _, schedule := range schedules {
+		if schedule.Paused || schedule.NextRun == nil {
+			continue
+		}
+		if d := schedule.NextRun.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < minWait {
+		wait = minWait
+	}
+	return wait
+}
+
+// poke wakes the loop so it picks up a changed schedule
+func (s *Scheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// newScheduleID returns a random schedule ID
+func newScheduleID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("sched-%d", time.Now().UnixNano())
+	}
+	return "sched-" + hex.EncodeToString(b)
+}
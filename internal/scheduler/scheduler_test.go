@@ -0,0 +1,190 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/scheduler"
+)
+
+// fakeStore keeps schedules in memory
+type fakeStore struct {
+	mu        sync.Mutex
+	schedules map[string]models.Schedule
+}
+
+func (f *fakeStore) SaveSchedule(schedule models.Schedule) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (f *fakeStore) GetSchedules() ([]models.Schedule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	schedules := []models.Schedule{}
+	for _, schedule := range f.schedules {
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func (f *fakeStore) RemoveSchedule(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.schedules, id)
+	return nil
+}
+
+var _ = Describe("Scheduler", func() {
+	var (
+		store *fakeStore
+		s     *scheduler.Scheduler
+		now   time.Time
+		ran   []models.ScheduleJob
+		ctx   context.Context
+	)
+
+	migrateJob := models.ScheduleJob{Type: models.JobMigrate, Migrate: &models.MigrateRequest{VMID: "vm-1", ToDC: "dc-b"}}
+
+	BeforeEach(func() {
+		store = &fakeStore{schedules: map[string]models.Schedule{}}
+		ran = nil
+		ctx = context.Background()
+		now = time.Date(2025, 6, 11, 10, 0, 0, 0, time.UTC)
+		s = scheduler.New(store, func(_ context.Context, job models.ScheduleJob) models.ScheduleRun {
+			ran = append(ran, job)
+			return models.ScheduleRun{State: models.RunStarted, OperationID: "op-1"}
+		}, nil)
+		s.SetClock(func() time.Time { return now })
+	})
+
+	It("requires exactly one of cron and at", func() {
+		at := now.Add(time.Hour)
+		Expect(s.Validate(models.Schedule{Job: migrateJob})).To(MatchError(ContainSubstring("either cron or at")))
+		Expect(s.Validate(models.Schedule{Cron: "@hourly", At: &at, Job: migrateJob})).To(HaveOccurred())
+		Expect(s.Validate(models.Schedule{Cron: "bogus", Job: migrateJob})).To(HaveOccurred())
+		past := now.Add(-time.Hour)
+		Expect(s.Validate(models.Schedule{At: &past, Job: migrateJob})).To(MatchError(ContainSubstring("past")))
+	})
+
+	It("runs a one-off schedule once when it comes due", func() {
+		at := now.Add(time.Hour)
+		created, err := s.Create(models.Schedule{At: &at, Job: migrateJob})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.ID).To(HavePrefix("sched-"))
+		Expect(*created.NextRun).To(Equal(at))
+
+		s.RunDue(ctx)
+		Expect(ran).To(BeEmpty())
+
+		now = at.Add(time.Minute)
+		s.RunDue(ctx)
+		s.RunDue(ctx)
+		Expect(ran).To(HaveLen(1))
+
+		got, err := s.Get(created.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got.NextRun).To(BeNil())
+		Expect(got.History).To(HaveLen(1))
+		Expect(got.History[0].State).To(Equal(models.RunStarted))
+		Expect(got.History[0].OperationID).To(Equal("op-1"))
+		Expect(got.History[0].Time).To(Equal(now))
+	})
+
+	It("runs a cron schedule once for all the runs it missed", func() {
+		created, err := s.Create(models.Schedule{Cron: "*/15 * * * *", Job: migrateJob})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*created.NextRun).To(Equal(now.Add(15 * time.Minute)))
+
+		now = now.Add(time.Hour + time.Minute)
+		s.RunDue(ctx)
+		Expect(ran).To(HaveLen(1))
+
+		got, _ := s.Get(created.ID)
+		Expect(*got.NextRun).To(Equal(time.Date(2025, 6, 11, 11, 15, 0, 0, time.UTC)))
+	})
+
+	It("skips paused schedules and recomputes the next run on resume", func() {
+		created, err := s.Create(models.Schedule{Cron: "@hourly", Job: migrateJob})
+		Expect(err).NotTo(HaveOccurred())
+
+		paused, err := s.SetPaused(created.ID, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paused.Paused).To(BeTrue())
+		Expect(paused.NextRun).To(BeNil())
+
+		now = now.Add(3 * time.Hour)
+		s.RunDue(ctx)
+		Expect(ran).To(BeEmpty())
+
+		resumed, err := s.SetPaused(created.ID, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*resumed.NextRun).To(Equal(time.Date(2025, 6, 11, 14, 0, 0, 0, time.UTC)))
+	})
+
+	It("runs a schedule on demand without moving its next run", func() {
+		created, err := s.Create(models.Schedule{Cron: "@daily", Job: migrateJob})
+		Expect(err).NotTo(HaveOccurred())
+
+		run, err := s.RunNow(ctx, created.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(run.State).To(Equal(models.RunStarted))
+
+		got, _ := s.Get(created.ID)
+		Expect(got.History).To(HaveLen(1))
+		Expect(*got.NextRun).To(Equal(*created.NextRun))
+	})
+
+	It("keeps a bounded history, newest first", func() {
+		created, err := s.Create(models.Schedule{Cron: "* * * * *", Job: migrateJob})
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := 0; i < 25; i++ {
+			now = now.Add(time.Minute)
+			s.RunDue(ctx)
+		}
+
+		got, _ := s.Get(created.ID)
+		Expect(got.History).To(HaveLen(20))
+		Expect(got.History[0].Time).To(Equal(now))
+	})
+
+	It("deletes schedules", func() {
+		created, err := s.Create(models.Schedule{Cron: "@hourly", Job: migrateJob})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.Delete(created.ID)).To(Succeed())
+		_, err = s.Get(created.ID)
+		Expect(err).To(MatchError(scheduler.ErrNotFound))
+		Expect(s.Delete(created.ID)).To(MatchError(scheduler.ErrNotFound))
+	})
+
+	It("runs due schedules from the loop", func() {
+		var mu sync.Mutex
+		count := 0
+		s = scheduler.New(store, func(context.Context, models.ScheduleJob) models.ScheduleRun {
+			mu.Lock()
+			defer mu.Unlock()
+			count++
+			return models.ScheduleRun{State: models.RunSkipped}
+		}, nil)
+
+		at := time.Now().Add(-time.Second)
+		store.schedules["sched-due"] = models.Schedule{ID: "sched-due", At: &at, NextRun: &at, Job: migrateJob}
+
+		s.Start()
+		defer s.Stop()
+		Eventually(func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return count
+		}).Should(Equal(1))
+	})
+})
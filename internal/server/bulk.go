@@ -0,0 +1,263 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/placement"
+)
+
+// defaultBulkConcurrency is how many VMs a bulk migration moves at once
+// unless the request says otherwise
+const defaultBulkConcurrency = 1
+
+// Bulk migration orders
+const (
+	bulkOrderListed = "listed"   // The order of vmIds
+	bulkOrderName   = "name"     // By VM name
+	bulkOrderSmall  = "smallest" // Smallest memory and CPU first
+	bulkOrderLarge  = "largest"  // Largest memory and CPU first
+)
+
+// What a bulk migration does when a VM fails to move
+const (
+	bulkOnFailureContinue = "continue"
+	bulkOnFailureStop     = "stop"
+)
+
+// BulkMove is one VM of a bulk migration as planned
+type BulkMove struct {
+	VMID   string `json:"vmId"`
+	VMName string `json:"vmName"`
+	FromDC string `json:"from"`
+	ToDC   string `json:"to"`
+	// Admitted says whether the move passes admission after the moves
+	// before it; Reason explains when it doesn't
+	Admitted bool   `json:"admitted"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// BulkMigrateHandler moves many VMs to one datacenter as one operation.
+// A dry run answers with the planned moves; otherwise the response is 202
+// with the operation, which has one step per VM.
+func BulkMigrateHandler(c *fiber.Ctx) error {
+	var req models.BulkMigrateRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body: " + err.Error()})
+	}
+	if c.Query("dry-run") == "1" {
+		req.DryRun = true
+	}
+	if err := validateBulkRequest(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if findDatacenter(req.ToDC) == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "datacenter not found"})
+	}
+
+	vms, err := selectBulkVMs(req)
+	if err != nil {
+		return c.Status(migrationErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	planned, err := planBulkMoves(req, vms)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.DryRun {
+		return c.JSON(fiber.Map{"dryRun": true, "toDC": req.ToDC, "moves": planned})
+	}
+	if len(vms) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "no VMs to move"})
+	}
+
+	op := startBulkMigration(req, vms)
+	return c.Status(202).JSON(fiber.Map{"operation": op, "moves": planned})
+}
+
+// startBulkMigration moves the selected VMs of a validated bulk request in
+// the background and returns the operation
+func startBulkMigration(req models.BulkMigrateRequest, vms []locatedVM) models.Operation {
+	moves := make([]vmMove, len(vms))
+	for i, vm := range vms {
+		moves[i] = vmMove{vm: vm.VM, fromDC: vm.dcID, toDC: req.ToDC, cluster: req.TargetCluster, force: req.Force}
+	}
+	op := operations.create("bulk-migration", req.ToDC, moveNames(moves))
+	runMoves(op.ID, moves, moveOptions{
+		parallelism:   req.Concurrency,
+		stopOnFailure: req.OnFailure == bulkOnFailureStop,
+	})
+	return op
+}
+
+// validateBulkRequest checks a bulk request and fills in its defaults
+func validateBulkRequest(req *models.BulkMigrateRequest) error {
+	if req.ToDC == "" {
+		return fmt.Errorf("toDC is required")
+	}
+	if (len(req.VMIDs) == 0) == (req.Selector == nil) {
+		return fmt.Errorf("either vmIds or selector is required")
+	}
+	if req.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative")
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = defaultBulkConcurrency
+	}
+	switch req.OnFailure {
+	case "":
+		req.OnFailure = bulkOnFailureContinue
+	case bulkOnFailureContinue, bulkOnFailureStop:
+	default:
+		return fmt.Errorf("onFailure must be continue or stop")
+	}
+	switch req.Order {
+	case "":
+		req.Order = bulkOrderName
+		if len(req.VMIDs) > 0 {
+			req.Order = bulkOrderListed
+		}
+	case bulkOrderName, bulkOrderSmall, bulkOrderLarge:
+	case bulkOrderListed:
+		if len(req.VMIDs) == 0 {
+			return fmt.Errorf("order listed needs vmIds")
+		}
+	default:
+		return fmt.Errorf("order must be listed, name, smallest or largest")
+	}
+	if req.Selector != nil {
+		if _, err := labels.Parse(req.Selector.Labels); err != nil {
+			return fmt.Errorf("invalid label selector: %v", err)
+		}
+		if _, err := path.Match(req.Selector.Name, ""); err != nil {
+			return fmt.Errorf("invalid name pattern %q: %v", req.Selector.Name, err)
+		}
+	}
+	return nil
+}
+
+// locatedVM is a VM with the datacenter it runs in
+type locatedVM struct {
+	models.VM
+	dcID string
+}
+
+// selectBulkVMs returns the VMs a bulk request moves, in the requested
+// order. VMs already in the target datacenter are left out.
+func selectBulkVMs(req models.BulkMigrateRequest) ([]locatedVM, error) {
+	datacenters := datacenterView()
+
+	var vms []locatedVM
+	if len(req.VMIDs) > 0 {
+		seen := make(map[string]bool)
+		for _, id := range req.VMIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			vm, ok := locateVM(datacenters, id)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", errVMNotFound, id)
+			}
+			vms = append(vms, vm)
+		}
+	} else {
+		selector, _ := labels.Parse(req.Selector.Labels)
+		for _, dc := range datacenters.Datacenters {
+			if req.Selector.Datacenter != "" && dc.ID != req.Selector.Datacenter {
+				continue
+			}
+			for _, vm := range dc.VMs {
+				if selectorMatches(req.Selector, vm, selector) {
+					vms = append(vms, locatedVM{VM: vm, dcID: dc.ID})
+				}
+			}
+		}
+	}
+
+	selected := vms[:0]
+	for _, vm := range vms {
+		if vm.dcID != req.ToDC {
+			selected = append(selected, vm)
+		}
+	}
+	sortBulkVMs(selected, req.Order)
+	return selected, nil
+}
+
+// selectorMatches reports whether a VM matches a selector, apart from its
+// datacenter
+func selectorMatches(s *models.VMSelector, vm models.VM, selector labels.Selector) bool {
+	if s.Cluster != "" && vm.Cluster != s.Cluster {
+		return false
+	}
+	if s.Namespace != "" && vm.Namespace != s.Namespace {
+		return false
+	}
+	if s.Name != "" {
+		if ok, _ := path.Match(s.Name, vm.Name); !ok {
+			return false
+		}
+	}
+	return selector.Matches(labels.Set(vm.Labels))
+}
+
+// locateVM finds a VM by ID in any datacenter
+func locateVM(datacenters *models.DatacenterCollection, id string) (locatedVM, bool) {
+	for _, dc := range datacenters.Datacenters {
+		for _, vm := range dc.VMs {
+			if vm.ID == id {
+				return locatedVM{VM: vm, dcID: dc.ID}, true
+			}
+		}
+	}
+	return locatedVM{}, false
+}
+
+// sortBulkVMs puts VMs in a bulk order; listed keeps them as they are
+func sortBulkVMs(vms []locatedVM, order string) {
+	size := func(vm locatedVM) [2]int { return [2]int{vm.Memory, vm.CPU} }
+	less := func(a, b [2]int) bool { return a[0] < b[0] || a[0] == b[0] && a[1] < b[1] }
+
+	switch order {
+	case bulkOrderName:
+		sort.SliceStable(vms, func(i, j int) bool { return vms[i].Name < vms[j].Name })
+	case bulkOrderSmall:
+		sort.SliceStable(vms, func(i, j int) bool { return less(size(vms[i]), size(vms[j])) })
+	case bulkOrderLarge:
+		sort.SliceStable(vms, func(i, j int) bool { return less(size(vms[j]), size(vms[i])) })
+	}
+}
+
+// planBulkMoves predicts which moves pass admission, one after the other,
+// counting the VMs moved before each against the target's capacity
+func planBulkMoves(req models.BulkMigrateRequest, vms []locatedVM) ([]BulkMove, error) {
+	state, err := placementState()
+	if err != nil {
+		return nil, err
+	}
+
+	planned := make([]BulkMove, 0, len(vms))
+	for _, vm := range vms {
+		move := BulkMove{VMID: vm.ID, VMName: vm.Name, FromDC: vm.dcID, ToDC: req.ToDC}
+		err := admitMigration(models.MigrateRequest{VMID: vm.ID, FromDC: vm.dcID, ToDC: req.ToDC, Force: req.Force}, req.TargetCluster)
+		if err == nil && !req.Force {
+			u := state.Utilization[req.ToDC]
+			err = checkCapacity(vm.Name, "datacenter "+req.ToDC, u.Utilization, vm.AllocatedResources())
+		}
+		if err != nil {
+			move.Reason = err.Error()
+		} else {
+			move.Admitted = true
+			state.Move(&placement.Decision{VMID: vm.ID, FromDC: vm.dcID, ToDC: req.ToDC})
+		}
+		planned = append(planned, move)
+	}
+	return planned, nil
+}
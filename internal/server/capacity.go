@@ -0,0 +1,114 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+// overcommitRatio is how far allocations may exceed capacity: 1 admits
+// migrations up to full capacity, 2 up to twice the capacity
+var overcommitRatio = 1.0
+
+// SetOvercommitRatio sets the overcommit ratio used to admit migrations
+func SetOvercommitRatio(ratio float64) {
+	overcommitRatio = ratio
+}
+
+// GetDatacenterUtilizationHandler returns the utilization of a datacenter
+// and its clusters
+func GetDatacenterUtilizationHandler(c *fiber.Ctx) error {
+	utilization, err := dataStore.GetUtilization()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, dc := range utilization {
+		if dc.DatacenterID == c.Params("id") {
+			return c.JSON(dc)
+		}
+	}
+	return c.Status(404).JSON(fiber.Map{"error": "datacenter not found"})
+}
+
+// totalUtilization sums the utilization of all datacenters
+func totalUtilization(datacenters []models.DatacenterUtilization) models.Utilization {
+	var capacity, allocated models.Resources
+	for _, dc := range datacenters {
+		capacity = capacity.Add(dc.Capacity)
+		allocated = allocated.Add(dc.Allocated)
+	}
+	return models.NewUtilization(capacity, allocated)
+}
+
+// admitMigration checks that the migration involves no failed datacenter,
+// that the target datacenter is not under maintenance and that moving a VM
+// into it, and into the target cluster when it changes, keeps them within
+// the overcommit ratio. Unknown VMs are admitted; the migration itself
+// reports them.
+func admitMigration(req models.MigrateRequest, targetCluster string) error {
+	if id := downDatacenter(req); id != "" {
+		return fmt.Errorf("%w: %s", errDatacenterDown, id)
+	}
+	if req.Force {
+		return nil
+	}
+	if req.FromDC != req.ToDC {
+		if dc := findDatacenter(req.ToDC); dc != nil && dc.Maintenance != nil {
+			return fmt.Errorf("%w: %s; set force to migrate anyway", errMaintenance, req.ToDC)
+		}
+	}
+	vm, err := dataStore.GetVM(req.FromDC, req.VMID)
+	if err != nil {
+		return nil
+	}
+	utilization, err := dataStore.GetUtilization()
+	if err != nil {
+		return err
+	}
+
+	needed := vm.AllocatedResources()
+	for _, dc := range utilization {
+		if dc.DatacenterID != req.ToDC {
+			continue
+		}
+		if req.FromDC != req.ToDC {
+			if err := checkCapacity(vm.Name, "datacenter "+dc.DatacenterID, dc.Utilization, needed); err != nil {
+				return err
+			}
+		}
+		if cluster, ok := dc.Clusters[targetCluster]; ok && targetCluster != vm.Cluster {
+			if err := checkCapacity(vm.Name, "cluster "+targetCluster, cluster, needed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkCapacity reports the first resource that adding needed would push
+// past the overcommit ratio. Resources without a known capacity pass.
+func checkCapacity(vmName, target string, utilization models.Utilization, needed models.Resources) error {
+	checks := []struct {
+		name                     string
+		allocated, needed, total int
+		unit                     string
+	}{
+		{"CPU", utilization.Allocated.CPU, needed.CPU, utilization.Capacity.CPU, " cores"},
+		{"memory", utilization.Allocated.Memory, needed.Memory, utilization.Capacity.Memory, "MB"},
+		{"disk", utilization.Allocated.Disk, needed.Disk, utilization.Capacity.Disk, "GB"},
+	}
+	for _, check := range checks {
+		if check.total <= 0 || check.needed == 0 {
+			continue
+		}
+		limit := float64(check.total) * overcommitRatio
+		if float64(check.allocated+check.needed) > limit {
+			return fmt.Errorf("%w: moving VM %s needs %d%s of %s in %s, which has %d%s of %d%s allocated (%.0f%% overcommit limit); set force to migrate anyway",
+				errOvercommit, vmName, check.needed, check.unit, check.name, target,
+				check.allocated, check.unit, check.total, check.unit, overcommitRatio*100)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/eventbus"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// eventBus shares the hub's events with the other replicas; it is nil
+// unless StartEventBus is called
+var eventBus *eventbus.Bus
+
+// StartEventBus replaces the event hub with a bus that shares events with
+// the peer replicas. It must be called before anything broadcasts or
+// registers with the hub.
+func StartEventBus(options eventbus.Options) error {
+	if err := eventbus.Validate(options); err != nil {
+		return err
+	}
+	bus := eventbus.New(options)
+	watcher.DefaultHub = bus
+	eventBus = bus
+	bus.Start()
+	return nil
+}
+
+// SetEventBusForTesting sets the bus that receives the events of peers,
+// leaving the event hub alone
+func SetEventBusForTesting(bus *eventbus.Bus) {
+	eventBus = bus
+}
+
+// broadcastEvent broadcasts an event on the hub in use, which
+// StartEventBus may replace after the package is initialized
+func broadcastEvent(typ string, payload interface{}) {
+	watcher.DefaultHub.BroadcastEvent(typ, payload)
+}
+
+// BusEventsHandler receives a batch of events posted by a peer replica
+func BusEventsHandler(c *fiber.Ctx) error {
+	if eventBus == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "events are not shared between replicas"})
+	}
+	if err := eventBus.Receive(c.Get(eventbus.HeaderToken), c.Body()); err != nil {
+		if errors.Is(err, eventbus.ErrUnauthorized) {
+			return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(204)
+}
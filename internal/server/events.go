@@ -0,0 +1,260 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// defaultHeartbeatInterval is how often an idle event stream gets a
+// comment, so that proxies keep the connection open
+const defaultHeartbeatInterval = 15 * time.Second
+
+// heartbeatOverride replaces defaultHeartbeatInterval when set. It is
+// atomic because streams of earlier tests are still open when a test
+// changes it.
+var heartbeatOverride atomic.Int64
+
+// SetHeartbeatIntervalForTesting changes how often event streams send a
+// heartbeat; zero restores the default
+func SetHeartbeatIntervalForTesting(interval time.Duration) {
+	heartbeatOverride.Store(int64(interval))
+}
+
+// heartbeatInterval returns how often event streams send a heartbeat
+func heartbeatInterval() time.Duration {
+	if interval := heartbeatOverride.Load(); interval > 0 {
+		return time.Duration(interval)
+	}
+	return defaultHeartbeatInterval
+}
+
+// EventsHandler streams the hub's events as Server-Sent Events, each named
+// after its type and with its ID. ?types= (patterns such as migration:*)
+// and ?datacenter= select the events, comma separated or repeated. A client
+// that reconnects with Last-Event-ID, or ?since=, first gets the events it
+// missed from the hub's buffer; when they are no longer buffered it gets a
+// resync-required event instead and should refetch the datacenters. Events
+// dropped while the client was slow are replayed the same way.
+func EventsHandler(c *fiber.Ctx) error {
+	sub, err := parseSubscription(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Set SSE headers
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+
+	// Register with the watcher hub. We must keep the registration
+	// alive for the duration of the stream writer. If we unregister
+	// immediately (via defer here) the channel will be closed before
+	// the stream function runs which causes the connection to end.
+	hub := watcher.DefaultHub
+	ch, missed, ok := sub.register(hub)
+
+	// Use low-level stream writer to push events as they arrive. The
+	// Unregister is performed inside the stream writer when it exits
+	// (client disconnected or writer returned an error).
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// Ensure we unregister this client when the writer exits.
+		defer hub.Unregister(ch)
+
+		sse := &sseWriter{w: w}
+		stream := &eventStream{emit: sse.write, hub: hub, ch: ch, filter: sub.filter, lastID: sub.lastID}
+
+		// Send an initial hello/ping event through the same writer so
+		// the client receives a valid SSE payload immediately.
+		if err := sse.write("hello", 0, `{"msg":"connected"}`); err != nil {
+			return
+		}
+		if err := stream.replay(missed, ok); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval())
+		defer heartbeat.Stop()
+		for {
+			select {
+			case event, open := <-ch:
+				if !open {
+					return
+				}
+				if err := stream.send(event); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if err := sse.heartbeat(); err != nil {
+					return
+				}
+				if err := stream.catchUp(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	// Returning nil lets Fiber consider the response handled by the stream writer
+	return nil
+}
+
+// EventStatsHandler returns the state of the event hub and its clients,
+// including how many events each client dropped
+func EventStatsHandler(c *fiber.Ctx) error {
+	return c.JSON(watcher.DefaultHub.Stats())
+}
+
+// subscription is where an event stream resumes and which events it
+// receives, shared by the SSE and WebSocket streams
+type subscription struct {
+	resume bool   // Whether the client asked to resume
+	lastID uint64 // The last event the client has seen
+	filter watcher.Filter
+}
+
+// parseSubscription reads the Last-Event-ID header, or ?since=, and the
+// ?types= and ?datacenter= parameters of a request
+func parseSubscription(c *fiber.Ctx) (subscription, error) {
+	var sub subscription
+	resume := c.Get("Last-Event-ID")
+	if resume == "" {
+		resume = c.Query("since")
+	}
+	if resume != "" {
+		id, err := strconv.ParseUint(resume, 10, 64)
+		if err != nil {
+			return sub, fmt.Errorf("invalid event ID %s", strconv.Quote(resume))
+		}
+		sub.resume, sub.lastID = true, id
+	}
+
+	sub.filter = watcher.Filter{
+		Types:       queryList(c, "types"),
+		Datacenters: queryList(c, "datacenter"),
+	}
+	return sub, sub.filter.Validate()
+}
+
+// register adds the subscription to a hub and returns the events to
+// replay. A client that does not resume starts at the latest event.
+func (sub *subscription) register(hub watcher.EventBus) (ch chan watcher.Event, missed []watcher.Event, ok bool) {
+	if !sub.resume {
+		sub.lastID = hub.LastID()
+	}
+	return hub.RegisterSince(sub.lastID, sub.filter)
+}
+
+// queryList returns the values of a query parameter that may be repeated
+// or hold a comma-separated list
+func queryList(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, raw := range c.Context().QueryArgs().PeekMulti(key) {
+		for _, value := range strings.Split(string(raw), ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// eventStream writes hub events to one client, filling in the events the
+// client missed
+type eventStream struct {
+	// emit writes one event to the client; name is the event type, or
+	// resync-required
+	emit    func(name string, id uint64, data string) error
+	hub     watcher.EventBus
+	ch      chan watcher.Event
+	filter  watcher.Filter
+	lastID  uint64 // The last event sent or skipped
+	dropped uint64 // The hub's dropped count for ch at the last check
+}
+
+// send writes an event. Events already sent are skipped, and when the hub
+// dropped events for the client they are replayed from its buffer first.
+func (s *eventStream) send(event watcher.Event) error {
+	if err := s.catchUp(); err != nil {
+		return err
+	}
+	if event.ID <= s.lastID {
+		return nil
+	}
+	s.lastID = event.ID
+	return s.emit(event.Type, event.ID, event.Data)
+}
+
+// catchUp replays the events the hub dropped for the client since the
+// last check
+func (s *eventStream) catchUp() error {
+	dropped := s.hub.Dropped(s.ch)
+	if dropped == s.dropped {
+		return nil
+	}
+	s.dropped = dropped
+	missed, ok := s.hub.Since(s.lastID)
+	return s.replay(missed, ok)
+}
+
+// replay writes the events a client missed, or resync-required when some
+// of them are lost
+func (s *eventStream) replay(missed []watcher.Event, ok bool) error {
+	if !ok {
+		current := s.hub.LastID()
+		data, _ := json.Marshal(map[string]interface{}{
+			"reason":         "missed events are no longer buffered",
+			"lastEventId":    s.lastID,
+			"currentEventId": current,
+		})
+		s.lastID = current
+		return s.emit("resync-required", current, string(data))
+	}
+	for _, event := range missed {
+		if !s.filter.Matches(event) {
+			continue
+		}
+		if err := s.send(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sseWriter writes Server-Sent Events
+type sseWriter struct {
+	w *bufio.Writer
+}
+
+// heartbeat writes a comment, which clients ignore
+func (s *sseWriter) heartbeat() error {
+	if _, err := fmt.Fprint(s.w, ": heartbeat\n\n"); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// write writes and flushes one SSE message. A zero ID leaves out the id
+// field.
+func (s *sseWriter) write(name string, id uint64, data string) error {
+	if _, err := fmt.Fprintf(s.w, "event: %s\n", name); err != nil {
+		return err
+	}
+	if id != 0 {
+		if _, err := fmt.Fprintf(s.w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
@@ -0,0 +1,393 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/placement"
+)
+
+// recoveryPriorityLabel is the VM label that sets its restart priority,
+// overriding the recovery plan
+const recoveryPriorityLabel = "recovery-priority"
+
+// failoverStepDelay is the pause before each VM is restarted or failed
+// back, so a failover plays out visibly on the map
+var failoverStepDelay = 2 * time.Second
+
+// SetFailoverDelayForTesting shortens the pause between failover steps
+func SetFailoverDelayForTesting(delay time.Duration) {
+	failoverStepDelay = delay
+}
+
+// Failover states
+const (
+	failoverFailed     = "failed"
+	failoverRecovering = "recovering"
+	failoverRecovered  = "recovered"
+)
+
+// Placement statuses of the VMs of a failed datacenter
+const (
+	vmUnavailable   = "unavailable"   // Down with its datacenter
+	vmRestarted     = "restarted"     // Running in a surviving datacenter
+	vmUnrecoverable = "unrecoverable" // No surviving datacenter could take it
+	vmFailedBack    = "failed-back"   // Back in its own datacenter
+)
+
+// failover is a simulated datacenter failure. It is a model overlay: the
+// store and the clusters are never changed, the overlay is applied to the
+// datacenters the API serves.
+type failover struct {
+	Datacenter  string                        `json:"datacenter"`
+	State       string                        `json:"state"`
+	Reason      string                        `json:"reason,omitempty"`
+	Since       time.Time                     `json:"since"`
+	RecoveredAt *time.Time                    `json:"recoveredAt,omitempty"`
+	Plan        models.RecoveryPlan           `json:"plan"`
+	VMs         map[string]*failoverPlacement `json:"vms"`
+	Timeline    []models.TimelineEntry        `json:"timeline"`
+
+	cancel context.CancelFunc
+}
+
+// failoverPlacement tracks one VM of a failed datacenter
+type failoverPlacement struct {
+	VMID     string `json:"vmId"`
+	VMName   string `json:"vmName"`
+	Priority int    `json:"priority"`
+	Status   string `json:"status"`
+	ToDC     string `json:"to,omitempty"`
+
+	running bool
+	target  string // The recovery plan's preferred datacenter
+}
+
+// failovers holds the failure simulations by datacenter ID; recovered ones
+// are kept for their timeline
+var (
+	failoverMu sync.Mutex
+	failovers  = make(map[string]*failover)
+)
+
+// FailoverRequest fails a datacenter
+type FailoverRequest struct {
+	Reason string              `json:"reason,omitempty"`
+	Plan   models.RecoveryPlan `json:"plan"`
+}
+
+// FailDatacenterHandler takes a datacenter down. Its VMs become
+// unavailable and are then restarted in surviving datacenters one by one,
+// highest priority first.
+func FailDatacenterHandler(c *fiber.Ctx) error {
+	var req FailoverRequest
+	if len(c.Body()) > 0 {
+		if err := json.Unmarshal(c.Body(), &req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid request body: " + err.Error()})
+		}
+	}
+	selectors := make([]labels.Selector, len(req.Plan.Rules))
+	for i, rule := range req.Plan.Rules {
+		selector, err := labels.Parse(rule.Selector)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid selector %q: %v", rule.Selector, err)})
+		}
+		selectors[i] = selector
+	}
+
+	dc := findDatacenter(c.Params("id"))
+	if dc == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "datacenter not found"})
+	}
+
+	failoverMu.Lock()
+	defer failoverMu.Unlock()
+
+	if f, ok := failovers[dc.ID]; ok && f.State != failoverRecovered {
+		return c.Status(409).JSON(fiber.Map{"error": fmt.Sprintf("datacenter %s is already down", dc.ID)})
+	}
+
+	f := &failover{
+		Datacenter: dc.ID,
+		State:      failoverFailed,
+		Reason:     req.Reason,
+		Since:      time.Now(),
+		Plan:       req.Plan,
+		VMs:        make(map[string]*failoverPlacement),
+	}
+	f.record(models.TimelineEntry{Event: "datacenter-failed", FromDC: dc.ID, Message: fmt.Sprintf("Datacenter %s is down", dc.Name)})
+	for _, vm := range dc.VMs {
+		p := &failoverPlacement{VMID: vm.ID, VMName: vm.Name, Status: vmUnavailable, running: vm.Status == "running"}
+		p.Priority, p.target = recoveryPriority(vm, req.Plan, selectors)
+		f.VMs[vm.ID] = p
+		f.record(models.TimelineEntry{Event: "vm-unavailable", VMID: vm.ID, VMName: vm.Name, FromDC: dc.ID, Message: fmt.Sprintf("VM %s is unavailable", vm.Name)})
+	}
+	failovers[dc.ID] = f
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	go restartVMs(ctx, f)
+
+	log.Printf("Datacenter %s failed; restarting %d VMs elsewhere", dc.ID, len(dc.VMs))
+	broadcastEvent("failover:started", map[string]interface{}{"datacenter": dc.ID})
+	return c.Status(202).JSON(f)
+}
+
+// RecoverDatacenterHandler brings a failed datacenter back. The restarted
+// VMs fail back one by one, then the datacenter accepts VMs again.
+func RecoverDatacenterHandler(c *fiber.Ctx) error {
+	failoverMu.Lock()
+	defer failoverMu.Unlock()
+
+	f, ok := failovers[c.Params("id")]
+	if !ok || f.State == failoverRecovered {
+		return c.Status(409).JSON(fiber.Map{"error": fmt.Sprintf("datacenter %s is not down", c.Params("id"))})
+	}
+	if f.State == failoverRecovering {
+		return c.Status(409).JSON(fiber.Map{"error": fmt.Sprintf("datacenter %s is already recovering", f.Datacenter)})
+	}
+
+	f.cancel()
+	f.State = failoverRecovering
+	f.record(models.TimelineEntry{Event: "datacenter-recovering", FromDC: f.Datacenter, Message: fmt.Sprintf("Datacenter %s is back; failing VMs back", f.Datacenter)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	go failBackVMs(ctx, f)
+
+	broadcastEvent("failover:recovering", map[string]interface{}{"datacenter": f.Datacenter})
+	return c.Status(202).JSON(f)
+}
+
+// GetFailoverHandler returns the latest failover of a datacenter with its
+// timeline
+func GetFailoverHandler(c *fiber.Ctx) error {
+	failoverMu.Lock()
+	defer failoverMu.Unlock()
+
+	f, ok := failovers[c.Params("id")]
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "datacenter has not failed"})
+	}
+	return c.JSON(f)
+}
+
+// recoveryPriority returns a VM's restart priority and preferred target
+func recoveryPriority(vm models.VM, plan models.RecoveryPlan, selectors []labels.Selector) (int, string) {
+	priority, target := 0, ""
+	for i, rule := range plan.Rules {
+		if selectors[i].Matches(labels.Set(vm.Labels)) {
+			priority, target = rule.Priority, rule.Target
+			break
+		}
+	}
+	if value, ok := vm.Labels[recoveryPriorityLabel]; ok {
+		if p, err := strconv.Atoi(value); err == nil {
+			priority = p
+		}
+	}
+	return priority, target
+}
+
+// restartVMs restarts the running VMs of a failed datacenter elsewhere,
+// highest priority first
+func restartVMs(ctx context.Context, f *failover) {
+	failoverMu.Lock()
+	var order []*failoverPlacement
+	for _, p := range f.VMs {
+		if p.running {
+			order = append(order, p)
+		}
+	}
+	failoverMu.Unlock()
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].Priority != order[j].Priority {
+			return order[i].Priority > order[j].Priority
+		}
+		return order[i].VMName < order[j].VMName
+	})
+
+	for _, p := range order {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(failoverStepDelay):
+		}
+
+		failoverMu.Lock()
+		if ctx.Err() != nil {
+			failoverMu.Unlock()
+			return
+		}
+		target, err := recoveryTarget(f, p)
+		if err != nil {
+			p.Status = vmUnrecoverable
+			f.record(models.TimelineEntry{Event: "vm-unrecoverable", VMID: p.VMID, VMName: p.VMName, FromDC: f.Datacenter, Message: err.Error()})
+		} else {
+			p.Status, p.ToDC = vmRestarted, target
+			f.record(models.TimelineEntry{Event: "vm-restarted", VMID: p.VMID, VMName: p.VMName, FromDC: f.Datacenter, ToDC: target, Message: fmt.Sprintf("VM %s restarted in %s (priority %d)", p.VMName, target, p.Priority)})
+		}
+		failoverMu.Unlock()
+	}
+
+	failoverMu.Lock()
+	if ctx.Err() == nil {
+		f.record(models.TimelineEntry{Event: "failover-complete", FromDC: f.Datacenter, Message: fmt.Sprintf("Failover of datacenter %s complete", f.Datacenter)})
+	}
+	failoverMu.Unlock()
+}
+
+// recoveryTarget picks the surviving datacenter for a VM: the plan's
+// target when it can take the VM, otherwise the one with the fewest VMs.
+// The caller holds failoverMu.
+func recoveryTarget(f *failover, p *failoverPlacement) (string, error) {
+	collection := dataStore.GetDatacenters()
+	overlayFailovers(collection)
+	state, err := newPlacementState(collection)
+	if err != nil {
+		return "", err
+	}
+
+	var vm *models.VM
+	for _, dc := range state.Datacenters {
+		for i := range dc.VMs {
+			if dc.VMs[i].ID == p.VMID {
+				vm = &dc.VMs[i]
+			}
+		}
+	}
+	if vm == nil {
+		return "", fmt.Errorf("VM %s no longer exists", p.VMName)
+	}
+
+	admit := func(candidate placement.Candidate) error {
+		u := state.Utilization[candidate.ToDC]
+		return checkCapacity(vm.Name, "datacenter "+candidate.ToDC, u.Utilization, vm.AllocatedResources())
+	}
+	if p.target != "" {
+		for _, dc := range state.Datacenters {
+			if dc.ID == p.target && dc.AcceptsVMs() && admit(placement.Candidate{VM: *vm, FromDC: f.Datacenter, ToDC: dc.ID}) == nil {
+				return dc.ID, nil
+			}
+		}
+	}
+	return state.Target(*vm, f.Datacenter, admit)
+}
+
+// failBackVMs returns the restarted VMs to their recovered datacenter one
+// by one and then ends the failover
+func failBackVMs(ctx context.Context, f *failover) {
+	failoverMu.Lock()
+	var restarted []*failoverPlacement
+	for _, p := range f.VMs {
+		if p.Status == vmRestarted {
+			restarted = append(restarted, p)
+		}
+	}
+	failoverMu.Unlock()
+	sort.Slice(restarted, func(i, j int) bool { return restarted[i].VMName < restarted[j].VMName })
+
+	for _, p := range restarted {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(failoverStepDelay):
+		}
+
+		failoverMu.Lock()
+		from := p.ToDC
+		p.Status, p.ToDC = vmFailedBack, ""
+		f.record(models.TimelineEntry{Event: "vm-failed-back", VMID: p.VMID, VMName: p.VMName, FromDC: from, ToDC: f.Datacenter, Message: fmt.Sprintf("VM %s failed back from %s", p.VMName, from)})
+		failoverMu.Unlock()
+	}
+
+	failoverMu.Lock()
+	now := time.Now()
+	f.State, f.RecoveredAt = failoverRecovered, &now
+	f.record(models.TimelineEntry{Event: "datacenter-recovered", FromDC: f.Datacenter, Message: fmt.Sprintf("Datacenter %s recovered", f.Datacenter)})
+	failoverMu.Unlock()
+
+	log.Printf("Datacenter %s recovered", f.Datacenter)
+	broadcastEvent("failover:recovered", map[string]interface{}{"datacenter": f.Datacenter})
+}
+
+// record appends a timeline entry and streams it. The caller holds
+// failoverMu.
+func (f *failover) record(entry models.TimelineEntry) {
+	entry.Time = time.Now()
+	f.Timeline = append(f.Timeline, entry)
+	broadcastEvent("failover:"+entry.Event, map[string]interface{}{"datacenter": f.Datacenter, "entry": entry})
+}
+
+// datacenterView returns the datacenters as the API shows them: the
+// store's data with the failures overlaid
+func datacenterView() *models.DatacenterCollection {
+	collection := dataStore.GetDatacenters()
+	failoverMu.Lock()
+	defer failoverMu.Unlock()
+	overlayFailovers(collection)
+	return collection
+}
+
+// downDatacenter returns the failed datacenter a migration involves: its
+// source or target, or the datacenter a restarted VM belongs to. It returns
+// "" when none is down.
+func downDatacenter(req models.MigrateRequest) string {
+	failoverMu.Lock()
+	defer failoverMu.Unlock()
+	for id, f := range failovers {
+		if f.State == failoverRecovered {
+			continue
+		}
+		if _, ok := f.VMs[req.VMID]; ok || id == req.FromDC || id == req.ToDC {
+			return id
+		}
+	}
+	return ""
+}
+
+// overlayFailovers applies the failures to datacenters read from the
+// store: failed datacenters are marked, their VMs unavailable, and
+// restarted VMs are shown in the datacenter they were restarted in. The
+// caller holds failoverMu.
+func overlayFailovers(collection *models.DatacenterCollection) {
+	moved := make(map[string][]models.VM)
+	for i := range collection.Datacenters {
+		dc := &collection.Datacenters[i]
+		f, ok := failovers[dc.ID]
+		if !ok || f.State == failoverRecovered {
+			continue
+		}
+		dc.Failure = &models.Failure{Since: f.Since, State: f.State, Reason: f.Reason}
+
+		vms := make([]models.VM, 0, len(dc.VMs))
+		for _, vm := range dc.VMs {
+			p := f.VMs[vm.ID]
+			switch {
+			case p != nil && p.Status == vmRestarted:
+				vm.Status = "running"
+				moved[p.ToDC] = append(moved[p.ToDC], vm)
+			case p != nil && p.Status == vmFailedBack:
+				vms = append(vms, vm)
+			default:
+				vm.Status = vmUnavailable
+				vms = append(vms, vm)
+			}
+		}
+		dc.VMs = vms
+	}
+	for i := range collection.Datacenters {
+		dc := &collection.Datacenters[i]
+		dc.VMs = append(dc.VMs, moved[dc.ID]...)
+	}
+}
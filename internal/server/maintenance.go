@@ -0,0 +1,356 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/placement"
+)
+
+// defaultEvacuationParallelism is how many VMs an evacuation moves at once
+// unless the request says otherwise
+const defaultEvacuationParallelism = 2
+
+// evacuationTimeout bounds how long an evacuation or return may run
+var evacuationTimeout = 30 * time.Minute
+
+// maintenanceMu serializes maintenance changes; evacuations holds the
+// operation moving VMs out of or back into a datacenter, by datacenter ID
+var (
+	maintenanceMu sync.Mutex
+	evacuations   = make(map[string]string)
+)
+
+// MaintenanceRequest enters or exits maintenance of a datacenter
+type MaintenanceRequest struct {
+	Action string `json:"action"` // enter or exit
+	Reason string `json:"reason,omitempty"`
+	// Evacuate moves the datacenter's VMs out on entering maintenance
+	Evacuate bool `json:"evacuate,omitempty"`
+	// BringBack returns the evacuated VMs on exiting maintenance
+	BringBack bool `json:"bringBack,omitempty"`
+	// Parallelism bounds how many VMs are moved at once
+	Parallelism int `json:"parallelism,omitempty"`
+}
+
+// vmMove is one VM move of an evacuation or return
+type vmMove struct {
+	vm     models.VM
+	fromDC string
+	toDC   string
+	// cluster optionally picks the target cluster in toDC
+	cluster string
+	// force skips the capacity admission check
+	force bool
+	// unplaced says why no target was found when toDC is empty
+	unplaced string
+}
+
+// DatacenterMaintenanceHandler puts a datacenter under maintenance or takes
+// it out again. Moving VMs out or back runs as an operation; the response
+// is then 202 with the operation.
+func DatacenterMaintenanceHandler(c *fiber.Ctx) error {
+	var req MaintenanceRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body: " + err.Error()})
+	}
+	if req.Parallelism < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "parallelism must not be negative"})
+	}
+	if req.Parallelism == 0 {
+		req.Parallelism = defaultEvacuationParallelism
+	}
+
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+
+	dc := findDatacenter(c.Params("id"))
+	if dc == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "datacenter not found"})
+	}
+
+	switch req.Action {
+	case "enter":
+		return enterMaintenance(c, dc, req)
+	case "exit":
+		return exitMaintenance(c, dc, req)
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "action must be enter or exit"})
+	}
+}
+
+// enterMaintenance marks a datacenter as under maintenance and optionally
+// starts evacuating it
+func enterMaintenance(c *fiber.Ctx, dc *models.Datacenter, req MaintenanceRequest) error {
+	if dc.Maintenance != nil {
+		return c.Status(409).JSON(fiber.Map{"error": fmt.Sprintf("datacenter %s is already under maintenance", dc.ID)})
+	}
+
+	maintenance := &models.Maintenance{Since: time.Now(), Reason: req.Reason}
+	var moves []vmMove
+	var op *models.Operation
+	if req.Evacuate {
+		var err error
+		if moves, err = planEvacuation(dc); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		created := operations.create("evacuation", dc.ID, moveNames(moves))
+		op = &created
+		maintenance.OperationID = op.ID
+	}
+
+	updated, err := dataStore.SetDatacenterMaintenance(dc.ID, maintenance)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Printf("Datacenter %s entered maintenance", dc.ID)
+	broadcastEvent("datacenter:maintenance", map[string]interface{}{"datacenter": dc.ID, "maintenance": maintenance})
+
+	if op == nil {
+		return c.JSON(fiber.Map{"datacenter": updated})
+	}
+	evacuations[dc.ID] = op.ID
+	runMoves(op.ID, moves, moveOptions{
+		parallelism: req.Parallelism,
+		done:        func(move vmMove) { recordEvacuated(dc.ID, move) },
+		finished:    func() { endEvacuation(dc.ID, op.ID) },
+	})
+	return c.Status(202).JSON(fiber.Map{"datacenter": updated, "operation": op})
+}
+
+// exitMaintenance ends a datacenter's maintenance, stopping a running
+// evacuation, and optionally brings the evacuated VMs back
+func exitMaintenance(c *fiber.Ctx, dc *models.Datacenter, req MaintenanceRequest) error {
+	if dc.Maintenance == nil {
+		return c.Status(409).JSON(fiber.Map{"error": fmt.Sprintf("datacenter %s is not under maintenance", dc.ID)})
+	}
+	if opID, ok := evacuations[dc.ID]; ok {
+		if err := operations.cancel(opID); err != nil && !errors.Is(err, errOperationFinished) {
+			log.Printf("Failed to cancel operation %s: %v", opID, err)
+		}
+		delete(evacuations, dc.ID)
+	}
+
+	var moves []vmMove
+	if req.BringBack {
+		for _, evacuated := range dc.Maintenance.Evacuated {
+			currentDC, vm := findVM(evacuated.VMID, "")
+			if vm == nil || currentDC == dc.ID {
+				continue
+			}
+			moves = append(moves, vmMove{vm: *vm, fromDC: currentDC, toDC: dc.ID})
+		}
+	}
+
+	updated, err := dataStore.SetDatacenterMaintenance(dc.ID, nil)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Printf("Datacenter %s exited maintenance", dc.ID)
+	broadcastEvent("datacenter:maintenance", map[string]interface{}{"datacenter": dc.ID, "maintenance": nil})
+
+	if !req.BringBack {
+		return c.JSON(fiber.Map{"datacenter": updated})
+	}
+	op := operations.create("return", dc.ID, moveNames(moves))
+	evacuations[dc.ID] = op.ID
+	runMoves(op.ID, moves, moveOptions{
+		parallelism: req.Parallelism,
+		finished:    func() { endEvacuation(dc.ID, op.ID) },
+	})
+	return c.Status(202).JSON(fiber.Map{"datacenter": updated, "operation": op})
+}
+
+// planEvacuation picks a target for every VM of a datacenter, spreading
+// them over the other datacenters that are not under maintenance
+func planEvacuation(dc *models.Datacenter) ([]vmMove, error) {
+	state, err := placementState()
+	if err != nil {
+		return nil, err
+	}
+
+	var moves []vmMove
+	for _, vm := range dc.VMs {
+		move := vmMove{vm: vm, fromDC: dc.ID}
+		target, err := state.Target(vm, dc.ID, admitPlacement)
+		if err != nil {
+			move.unplaced = err.Error()
+		} else {
+			move.toDC = target
+			state.Move(&placement.Decision{VMID: vm.ID, FromDC: dc.ID, ToDC: target})
+		}
+		moves = append(moves, move)
+	}
+	return moves, nil
+}
+
+// moveOptions control how runMoves carries out the moves of an operation
+type moveOptions struct {
+	// parallelism bounds how many VMs are moved at once
+	parallelism int
+	// stopOnFailure cancels the moves not yet started once one fails
+	stopOnFailure bool
+	// done is called for every VM that moved
+	done func(vmMove)
+	// finished is called once no move is running any more
+	finished func()
+}
+
+// runMoves carries out the moves of an operation in the background until
+// they are done or the operation is cancelled
+func runMoves(opID string, moves []vmMove, options moveOptions) {
+	ctx, cancel := context.WithTimeout(context.Background(), evacuationTimeout)
+	operations.cancellable(opID, cancel)
+	operations.update(opID, func(op *models.Operation) { op.State = models.OperationRunning })
+
+	go func() {
+		defer cancel()
+
+		var wg sync.WaitGroup
+		var stopOnce sync.Once
+		stopped := make(chan struct{})
+		slots := make(chan struct{}, options.parallelism)
+		for i, move := range moves {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+			case <-stopped:
+			}
+			select {
+			case <-stopped:
+				operations.updateStep(opID, i, models.OperationCancelled, "skipped after an earlier failure")
+				continue
+			default:
+			}
+			if ctx.Err() != nil {
+				operations.updateStep(opID, i, models.OperationCancelled, "")
+				continue
+			}
+
+			wg.Add(1)
+			go func(i int, move vmMove) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				if !runMove(ctx, opID, i, move, options.done) && options.stopOnFailure {
+					stopOnce.Do(func() { close(stopped) })
+				}
+			}(i, move)
+		}
+		wg.Wait()
+
+		if options.finished != nil {
+			options.finished()
+		}
+		finishOperation(opID, ctx.Err() == context.Canceled)
+	}()
+}
+
+// runMove moves one VM and reports it as a step of the operation. It
+// returns whether the VM moved.
+func runMove(ctx context.Context, opID string, index int, move vmMove, done func(vmMove)) bool {
+	if move.toDC == "" {
+		operations.updateStep(opID, index, models.OperationFailed, move.unplaced)
+		return false
+	}
+
+	operations.updateStep(opID, index, models.OperationRunning, fmt.Sprintf("moving from %s to %s", move.fromDC, move.toDC))
+	migrationID, err := migrateAndWait(ctx, models.MigrateRequest{VMID: move.vm.ID, FromDC: move.fromDC, ToDC: move.toDC, TargetCluster: move.cluster, Force: move.force})
+	switch {
+	case err == nil:
+		message := fmt.Sprintf("moved to %s", move.toDC)
+		if migrationID != "" {
+			message = fmt.Sprintf("moved to %s by migration %s", move.toDC, migrationID)
+		}
+		if done != nil {
+			done(move)
+		}
+		operations.updateStep(opID, index, models.OperationSucceeded, message)
+		return true
+	case ctx.Err() == context.Canceled:
+		operations.updateStep(opID, index, models.OperationCancelled, "cancelled while moving")
+	default:
+		operations.updateStep(opID, index, models.OperationFailed, err.Error())
+	}
+	return false
+}
+
+// endEvacuation forgets a datacenter's evacuation or return once it ended,
+// unless another one has replaced it
+func endEvacuation(dcID, opID string) {
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+	if evacuations[dcID] == opID {
+		delete(evacuations, dcID)
+	}
+}
+
+// finishOperation sets an operation's final state from its steps
+func finishOperation(opID string, cancelled bool) {
+	operations.update(opID, func(op *models.Operation) {
+		moved, failed := 0, 0
+		for _, step := range op.Steps {
+			switch step.State {
+			case models.OperationSucceeded:
+				moved++
+			case models.OperationFailed:
+				failed++
+			}
+		}
+		op.Result = map[string]int{"moved": moved, "failed": failed}
+
+		switch {
+		case cancelled:
+			op.State = models.OperationCancelled
+		case failed > 0:
+			op.State = models.OperationFailed
+			op.Error = fmt.Sprintf("%d of %d VMs could not be moved", failed, len(op.Steps))
+		default:
+			op.State = models.OperationSucceeded
+		}
+	})
+}
+
+// recordEvacuated remembers where an evacuated VM went, so exiting
+// maintenance can bring it back
+func recordEvacuated(dcID string, move vmMove) {
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+
+	dc := findDatacenter(dcID)
+	if dc == nil || dc.Maintenance == nil {
+		return
+	}
+	maintenance := *dc.Maintenance
+	maintenance.Evacuated = append(maintenance.Evacuated, models.EvacuatedVM{VMID: move.vm.ID, VMName: move.vm.Name, ToDC: move.toDC})
+	if _, err := dataStore.SetDatacenterMaintenance(dcID, &maintenance); err != nil {
+		log.Printf("Failed to record evacuated VM %s: %v", move.vm.Name, err)
+	}
+}
+
+// findDatacenter returns a datacenter by ID
+func findDatacenter(id string) *models.Datacenter {
+	datacenters := dataStore.GetDatacenters()
+	for i := range datacenters.Datacenters {
+		if datacenters.Datacenters[i].ID == id {
+			return &datacenters.Datacenters[i]
+		}
+	}
+	return nil
+}
+
+// moveNames names the steps of an operation after the VMs it moves
+func moveNames(moves []vmMove) []string {
+	names := make([]string, len(moves))
+	for i, move := range moves {
+		names[i] = move.vm.Name
+	}
+	return names
+}
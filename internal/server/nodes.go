@@ -0,0 +1,46 @@
+package server
+
+import (
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+// ClusterNodesHandler lists the nodes of a cluster with the VMs placed on
+// each. Nodes are only known in watcher mode; other clusters have none.
+func ClusterNodesHandler(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	nodes, err := dataStore.GetNodes(name)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(nodes) == 0 && !clusterExists(name) {
+		return c.Status(404).JSON(fiber.Map{"error": "cluster not found"})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	if nodes == nil {
+		nodes = []models.Node{}
+	}
+	return c.JSON(nodes)
+}
+
+// clusterExists reports whether a datacenter lists the cluster or a VM runs on it
+func clusterExists(name string) bool {
+	for _, dc := range dataStore.GetDatacenters().Datacenters {
+		for _, cluster := range dc.Clusters {
+			if cluster == name {
+				return true
+			}
+		}
+		for _, vm := range dc.VMs {
+			if vm.Cluster == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,439 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// migrationPollInterval is how often a running operation checks on the
+// live migrations it started
+var migrationPollInterval = time.Second
+
+// migrationRecordTimeout is how long a started live migration may take to
+// show up in the store
+var migrationRecordTimeout = time.Minute
+
+// operations tracks long-running operations such as migrations and
+// evacuations
+var operations = &operationTracker{cancels: make(map[string]context.CancelFunc)}
+
+// errOperationNotFound, errOperationFinished and errNotCancellable classify
+// failures to cancel an operation
+var (
+	errOperationNotFound = errors.New("operation not found")
+	errOperationFinished = errors.New("operation has already finished")
+	errNotCancellable    = errors.New("operation cannot be cancelled")
+)
+
+// operationTracker keeps operations in the store, so they outlive a
+// restart, and streams their progress as operation:* events
+type operationTracker struct {
+	mu sync.Mutex
+	// cancels stops the work of running operations that can be cancelled
+	cancels map[string]context.CancelFunc
+}
+
+// create registers a pending operation with one pending step per name
+func (t *operationTracker) create(opType, target string, stepNames []string) models.Operation {
+	op := models.Operation{
+		ID:        newOperationID(),
+		Type:      opType,
+		Target:    target,
+		State:     models.OperationPending,
+		CreatedAt: time.Now(),
+		Steps:     make([]models.OperationStep, len(stepNames)),
+	}
+	for i, name := range stepNames {
+		op.Steps[i] = models.OperationStep{Name: name, State: models.OperationPending}
+	}
+
+	t.mu.Lock()
+	if err := dataStore.SaveOperation(op); err != nil {
+		log.Printf("Failed to save operation %s: %v", op.ID, err)
+	}
+	t.mu.Unlock()
+
+	broadcastEvent("operation:created", map[string]interface{}{"operation": op})
+	return op
+}
+
+// get returns an operation
+func (t *operationTracker) get(id string) (models.Operation, bool) {
+	op, err := dataStore.GetOperation(id)
+	if err != nil {
+		return models.Operation{}, false
+	}
+	return *op, true
+}
+
+// update changes an operation, recomputes its progress and streams it
+func (t *operationTracker) update(id string, change func(op *models.Operation)) {
+	t.mu.Lock()
+	op, err := dataStore.GetOperation(id)
+	if err != nil {
+		t.mu.Unlock()
+		return
+	}
+	change(op)
+	refreshOperation(op, time.Now())
+	if models.OperationFinished(op.State) {
+		delete(t.cancels, id)
+	}
+	if err := dataStore.SaveOperation(*op); err != nil {
+		log.Printf("Failed to save operation %s: %v", op.ID, err)
+	}
+	t.mu.Unlock()
+
+	broadcastEvent("operation:updated", map[string]interface{}{"operation": op})
+}
+
+// updateStep moves one step of an operation to a new state
+func (t *operationTracker) updateStep(id string, index int, state, message string) {
+	t.update(id, func(op *models.Operation) {
+		setStep(op, index, state, message)
+	})
+}
+
+// cancellable registers how to stop the work of a running operation
+func (t *operationTracker) cancellable(id string, cancel context.CancelFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cancels[id] = cancel
+}
+
+// cancel asks a running operation to stop. The operation ends up
+// cancelled once its work has stopped.
+func (t *operationTracker) cancel(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	op, err := dataStore.GetOperation(id)
+	if err != nil {
+		return errOperationNotFound
+	}
+	if models.OperationFinished(op.State) {
+		return errOperationFinished
+	}
+	cancel, ok := t.cancels[id]
+	if !ok {
+		return errNotCancellable
+	}
+	cancel()
+	return nil
+}
+
+// failInterrupted fails the operations that were still pending or running
+// when the server stopped; their work didn't survive the restart
+func (t *operationTracker) failInterrupted() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ops, err := dataStore.GetOperations()
+	if err != nil {
+		log.Printf("Failed to load operations: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, op := range ops {
+		if models.OperationFinished(op.State) {
+			continue
+		}
+		for i := range op.Steps {
+			if !models.OperationFinished(op.Steps[i].State) {
+				setStep(&op, i, models.OperationFailed, "interrupted by a server restart")
+			}
+		}
+		op.State = models.OperationFailed
+		op.Error = "interrupted by a server restart"
+		refreshOperation(&op, now)
+		if err := dataStore.SaveOperation(op); err != nil {
+			log.Printf("Failed to save operation %s: %v", op.ID, err)
+		}
+	}
+}
+
+// setStep moves one step of an operation to a new state
+func setStep(op *models.Operation, index int, state, message string) {
+	step := &op.Steps[index]
+	now := time.Now()
+	if state == models.OperationRunning && step.StartedAt == nil {
+		step.StartedAt = &now
+	}
+	if models.OperationFinished(state) {
+		step.FinishedAt = &now
+	}
+	step.State = state
+	step.Message = message
+}
+
+// refreshOperation sets an operation's timestamps and progress from its
+// state and steps
+func refreshOperation(op *models.Operation, now time.Time) {
+	if op.State == models.OperationRunning && op.StartedAt == nil {
+		op.StartedAt = &now
+	}
+	if models.OperationFinished(op.State) && op.FinishedAt == nil {
+		op.FinishedAt = &now
+	}
+	finished := 0
+	for _, step := range op.Steps {
+		if models.OperationFinished(step.State) {
+			finished++
+		}
+	}
+	op.Progress = 100
+	if len(op.Steps) > 0 {
+		op.Progress = finished * 100 / len(op.Steps)
+	}
+}
+
+// newOperationID returns a random operation ID
+func newOperationID() string {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		panic(fmt.Sprintf("failed to generate operation ID: %v", err))
+	}
+	return "op-" + hex.EncodeToString(suffix)
+}
+
+// GetOperationsHandler lists operations, newest first. ?type=, ?state= and
+// ?target= narrow the list.
+func GetOperationsHandler(c *fiber.Ctx) error {
+	ops, err := dataStore.GetOperations()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filtered := make([]models.Operation, 0, len(ops))
+	for _, op := range ops {
+		if (c.Query("type") == "" || op.Type == c.Query("type")) &&
+			(c.Query("state") == "" || op.State == c.Query("state")) &&
+			(c.Query("target") == "" || op.Target == c.Query("target")) {
+			filtered = append(filtered, op)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool { return filtered[i].CreatedAt.After(filtered[j].CreatedAt) })
+	return c.JSON(filtered)
+}
+
+// GetOperationHandler returns an operation with the progress of its steps
+func GetOperationHandler(c *fiber.Ctx) error {
+	op, ok := operations.get(c.Params("id"))
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "operation not found"})
+	}
+	return c.JSON(op)
+}
+
+// CancelOperationHandler asks a running operation to stop and answers 202
+// with the operation
+func CancelOperationHandler(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := operations.cancel(id); err != nil {
+		status := 409
+		if errors.Is(err, errOperationNotFound) {
+			status = 404
+		}
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	op, _ := operations.get(id)
+	return c.Status(202).JSON(op)
+}
+
+// trackMigration admits a migration and runs it as a migration operation.
+// A live migration is started before it returns and its operation runs
+// until the migration finishes; a move in the store runs in the
+// background. Requests that are not admitted return an error and no
+// operation.
+func trackMigration(ctx context.Context, req models.MigrateRequest) (operationID, migrationID string, err error) {
+	plan, vmName, err := planMigration(req)
+	if err != nil {
+		return "", "", err
+	}
+	op := operations.create("migration", req.VMID, []string{vmName})
+	result := map[string]string{"vmId": req.VMID, "from": req.FromDC, "to": req.ToDC}
+
+	if plan == nil {
+		operations.update(op.ID, func(op *models.Operation) {
+			setStep(op, 0, models.OperationRunning, fmt.Sprintf("moving from %s to %s", req.FromDC, req.ToDC))
+			op.State = models.OperationRunning
+			op.Result = result
+		})
+		go func() {
+			_, err := executeMigration(context.Background(), req, nil)
+			finishMigration(op.ID, err, fmt.Sprintf("moved to %s", req.ToDC))
+		}()
+		return op.ID, "", nil
+	}
+
+	migrationID, err = executeMigration(ctx, req, plan)
+	if err != nil {
+		finishMigration(op.ID, err, "")
+		return "", "", err
+	}
+	result["migrationId"] = migrationID
+	operations.update(op.ID, func(op *models.Operation) {
+		setStep(op, 0, models.OperationRunning, fmt.Sprintf("live migration %s from %s to %s", migrationID, req.FromDC, req.ToDC))
+		op.State = models.OperationRunning
+		op.Result = result
+	})
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), evacuationTimeout)
+		defer cancel()
+		err := awaitMigration(ctx, migrationID)
+		finishMigration(op.ID, err, fmt.Sprintf("moved to %s by migration %s", req.ToDC, migrationID))
+	}()
+	return op.ID, migrationID, nil
+}
+
+// finishMigration ends the operation of a migration with its outcome
+func finishMigration(id string, err error, message string) {
+	operations.update(id, func(op *models.Operation) {
+		switch {
+		case err == nil:
+			setStep(op, 0, models.OperationSucceeded, message)
+			op.State = models.OperationSucceeded
+		case errors.Is(err, errMigrationAborted):
+			setStep(op, 0, models.OperationCancelled, err.Error())
+			op.State = models.OperationCancelled
+		default:
+			setStep(op, 0, models.OperationFailed, err.Error())
+			op.State = models.OperationFailed
+			op.Error = err.Error()
+		}
+	})
+}
+
+// startMigration admits and starts a migration. VMs on watched clusters are
+// live migrated and the migration ID is returned; other VMs are moved in
+// the store right away.
+func startMigration(ctx context.Context, req models.MigrateRequest) (string, error) {
+	plan, _, err := planMigration(req)
+	if err != nil {
+		return "", err
+	}
+	return executeMigration(ctx, req, plan)
+}
+
+// planMigration checks and admits a migration and returns the name of the
+// VM. VMs on watched clusters get the plan of their live migration; for
+// other VMs the plan is nil and only the record is moved.
+func planMigration(req models.MigrateRequest) (*watcher.MigrationPlan, string, error) {
+	if vmWatcher != nil {
+		plan, err := planClusterMigration(req)
+		if err != nil {
+			return nil, "", err
+		}
+		if plan != nil {
+			if err := admitMigration(req, plan.TargetCluster); err != nil {
+				return nil, "", err
+			}
+			return plan, plan.VMName, nil
+		}
+	}
+
+	if req.FromDC == req.ToDC {
+		return nil, "", fmt.Errorf("%w: source and target datacenters cannot be the same", errInvalidTarget)
+	}
+	vm, err := dataStore.GetVM(req.FromDC, req.VMID)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", errVMNotFound, err)
+	}
+	if findDatacenter(req.ToDC) == nil {
+		return nil, "", fmt.Errorf("%w: datacenter %s not found", errInvalidTarget, req.ToDC)
+	}
+	if err := admitMigration(req, ""); err != nil {
+		return nil, "", err
+	}
+	return nil, vm.Name, nil
+}
+
+// executeMigration starts the live migration of a plan, or moves the
+// record in the store when there is none
+func executeMigration(ctx context.Context, req models.MigrateRequest, plan *watcher.MigrationPlan) (string, error) {
+	if plan != nil {
+		result, err := vmWatcher.StartMigration(ctx, *plan)
+		if err != nil {
+			return "", err
+		}
+		return result.MigrationID, nil
+	}
+	if _, err := dataStore.MigrateVM(req.VMID, req.FromDC, req.ToDC); err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+// errMigrationAborted reports a live migration aborted while it was awaited
+var errMigrationAborted = errors.New("migration aborted")
+
+// awaitMigration waits until every record of a live migration reports it
+// succeeded. A migration that fails, is aborted or deleted, or whose
+// records disappear or never show up, is an error.
+func awaitMigration(ctx context.Context, migrationID string) error {
+	ticker := time.NewTicker(migrationPollInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(migrationRecordTimeout)
+	seen := false
+	for {
+		records, err := findMigrationRecords(migrationID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(records) > 0:
+			seen = true
+		case seen:
+			return fmt.Errorf("migration %s disappeared before it finished", migrationID)
+		case time.Now().After(deadline):
+			return fmt.Errorf("migration %s did not show up within %s", migrationID, migrationRecordTimeout)
+		}
+
+		succeeded := len(records) > 0
+		for _, record := range records {
+			switch {
+			case record.Phase == "Aborted":
+				return fmt.Errorf("%w: %s", errMigrationAborted, migrationID)
+			case record.Phase == "Terminating":
+				return fmt.Errorf("migration %s was deleted before it finished", migrationID)
+			case record.Failed || record.Phase == "Failed":
+				if record.FailureReason != "" {
+					return fmt.Errorf("migration %s failed: %s", migrationID, record.FailureReason)
+				}
+				return fmt.Errorf("migration %s failed", migrationID)
+			}
+			succeeded = succeeded && record.Phase == "Succeeded"
+		}
+		if succeeded {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// migrateAndWait moves a VM and, for a live migration, waits for it to finish
+func migrateAndWait(ctx context.Context, req models.MigrateRequest) (string, error) {
+	migrationID, err := startMigration(ctx, req)
+	if err != nil || migrationID == "" {
+		return migrationID, err
+	}
+	return migrationID, awaitMigration(ctx, migrationID)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/placement"
+)
+
+// placementEngine picks the VM and target for automatic migrations
+var placementEngine = placement.NewEngine(placement.DefaultOptions())
+
+// placementStrategy is used when a request doesn't name a strategy
+var placementStrategy = placement.DefaultStrategy
+
+// SetPlacementStrategy sets the default strategy for automatic migrations
+func SetPlacementStrategy(name string) error {
+	if _, ok := placementEngine.Strategies()[name]; !ok {
+		return fmt.Errorf("unknown placement strategy %q", name)
+	}
+	placementStrategy = name
+	return nil
+}
+
+// SetPlacementOptions replaces the placement engine with one using
+// options. The rebalancer is stopped and recreated on the new engine.
+func SetPlacementOptions(options placement.Options) {
+	rebalance.Stop()
+	placementEngine = placement.NewEngine(options)
+	rebalance = newRebalancer()
+}
+
+// GetPlacementStrategiesHandler lists the placement strategies and the default
+func GetPlacementStrategiesHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"default":    placementStrategy,
+		"strategies": placementEngine.Strategies(),
+	})
+}
+
+// decidePlacement lets a strategy pick a move among the running VMs,
+// leaving out moves that would overcommit the target
+func decidePlacement(strategy string) (*placement.Decision, []placement.Score, error) {
+	if strategy == "" {
+		strategy = placementStrategy
+	}
+	state, err := placementState()
+	if err != nil {
+		return nil, nil, err
+	}
+	return placementEngine.Decide(strategy, state, admitPlacement)
+}
+
+// placementState builds the placement state from the store, with failed
+// datacenters overlaid
+func placementState() (*placement.State, error) {
+	return newPlacementState(datacenterView())
+}
+
+// newPlacementState builds the placement state of datacenters, computing
+// their utilization from the VMs they show
+func newPlacementState(collection *models.DatacenterCollection) (*placement.State, error) {
+	nodes, err := dataStore.GetNodes("")
+	if err != nil {
+		return nil, err
+	}
+	return placement.NewState(collection, models.ComputeUtilization(collection, nodes)), nil
+}
+
+// admitPlacement rejects moves that would overcommit the target datacenter
+func admitPlacement(candidate placement.Candidate) error {
+	return admitMigration(models.MigrateRequest{VMID: candidate.VM.ID, FromDC: candidate.FromDC, ToDC: candidate.ToDC}, "")
+}
+
+// executePlacement carries out a decision. VMs on watched clusters are live
+// migrated and the migration ID is returned; other VMs are moved in the store.
+func executePlacement(ctx context.Context, decision *placement.Decision) (string, error) {
+	migrationID, err := startMigration(ctx, models.MigrateRequest{VMID: decision.VMID, FromDC: decision.FromDC, ToDC: decision.ToDC})
+	if err != nil {
+		return "", err
+	}
+	placementEngine.Commit(decision)
+	return migrationID, nil
+}
+
+// trackPlacement carries out a decision as a migration operation, like
+// trackMigration
+func trackPlacement(ctx context.Context, decision *placement.Decision) (operationID, migrationID string, err error) {
+	operationID, migrationID, err = trackMigration(ctx, models.MigrateRequest{VMID: decision.VMID, FromDC: decision.FromDC, ToDC: decision.ToDC})
+	if err != nil {
+		return "", "", err
+	}
+	placementEngine.Commit(decision)
+	return operationID, migrationID, nil
+}
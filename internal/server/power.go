@@ -0,0 +1,150 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// simulatedPowerDelay is how long a simulated VM stays in each transitional
+// state when no watcher is running
+var simulatedPowerDelay = 3 * time.Second
+
+// SetSimulatedPowerDelayForTesting shortens simulated power transitions
+func SetSimulatedPowerDelayForTesting(delay time.Duration) {
+	simulatedPowerDelay = delay
+}
+
+// simulatedPowerTransition lists the states a VM may be in for an action
+// and the states it then passes through
+type simulatedPowerTransition struct {
+	from  []string
+	steps []string
+}
+
+var simulatedPowerTransitions = map[watcher.PowerAction]simulatedPowerTransition{
+	watcher.PowerStart:   {from: []string{"stopped"}, steps: []string{"starting", "running"}},
+	watcher.PowerStop:    {from: []string{"running", "paused"}, steps: []string{"stopping", "stopped"}},
+	watcher.PowerRestart: {from: []string{"running"}, steps: []string{"stopping", "stopped", "starting", "running"}},
+	watcher.PowerPause:   {from: []string{"running"}, steps: []string{"paused"}},
+	watcher.PowerUnpause: {from: []string{"paused"}, steps: []string{"running"}},
+}
+
+// VMs with a simulated power action in progress, keyed by datacenter/VM ID
+var (
+	simulatedPowerMu   sync.Mutex
+	simulatedPowerBusy = make(map[string]bool)
+)
+
+// VMPowerHandler runs a power action (start, stop, restart, pause, unpause)
+// on a VM. VMs on watched clusters are driven through the KubeVirt API;
+// otherwise the store simulates the transition.
+func VMPowerHandler(c *fiber.Ctx) error {
+	action, err := watcher.ParsePowerAction(c.Params("action"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	dcID, vm := findVM(c.Params("id"), c.Query("datacenter"))
+	if vm == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "vm not found"})
+	}
+
+	if vmWatcher != nil && vm.Cluster != "" && vmWatcher.IsWatched(vm.Cluster) {
+		if err := vmWatcher.PowerAction(c.UserContext(), vm.Cluster, vm.Namespace, vm.Name, action); err != nil {
+			return c.Status(502).JSON(fiber.Map{"error": err.Error()})
+		}
+		broadcastEvent("vm:power", map[string]interface{}{"datacenter": dcID, "vmId": vm.ID, "action": action})
+		return c.Status(202).JSON(fiber.Map{"ok": true, "vmId": vm.ID, "action": action})
+	}
+
+	status, err := simulatePowerAction(dcID, vm, action)
+	if err != nil {
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	}
+	broadcastEvent("vm:power", map[string]interface{}{"datacenter": dcID, "vmId": vm.ID, "action": action})
+	return c.Status(202).JSON(fiber.Map{"ok": true, "vmId": vm.ID, "action": action, "status": status})
+}
+
+// findVM looks up a VM by ID, optionally restricted to one datacenter
+func findVM(vmID, dcID string) (string, *models.VM) {
+	datacenters := dataStore.GetDatacenters()
+	for i := range datacenters.Datacenters {
+		dc := &datacenters.Datacenters[i]
+		if dcID != "" && dc.ID != dcID {
+			continue
+		}
+		for j := range dc.VMs {
+			if dc.VMs[j].ID == vmID {
+				return dc.ID, &dc.VMs[j]
+			}
+		}
+	}
+	return "", nil
+}
+
+// simulatePowerAction moves a VM record into the first state of an action
+// and steps through the rest in the background. It returns the new status.
+func simulatePowerAction(dcID string, vm *models.VM, action watcher.PowerAction) (string, error) {
+	transition := simulatedPowerTransitions[action]
+
+	allowed := false
+	for _, from := range transition.from {
+		if vm.Status == from {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", fmt.Errorf("cannot %s a VM that is %s", action, vm.Status)
+	}
+
+	key := dcID + "/" + vm.ID
+	simulatedPowerMu.Lock()
+	if simulatedPowerBusy[key] {
+		simulatedPowerMu.Unlock()
+		return "", fmt.Errorf("VM %s has a power action in progress", vm.ID)
+	}
+	simulatedPowerBusy[key] = true
+	simulatedPowerMu.Unlock()
+
+	if err := setSimulatedPowerState(dcID, vm.ID, transition.steps[0]); err != nil {
+		simulatedPowerMu.Lock()
+		delete(simulatedPowerBusy, key)
+		simulatedPowerMu.Unlock()
+		return "", err
+	}
+
+	go func() {
+		defer func() {
+			simulatedPowerMu.Lock()
+			delete(simulatedPowerBusy, key)
+			simulatedPowerMu.Unlock()
+		}()
+		for _, status := range transition.steps[1:] {
+			time.Sleep(simulatedPowerDelay)
+			if err := setSimulatedPowerState(dcID, vm.ID, status); err != nil {
+				log.Printf("Simulated %s of VM %s stopped: %v", action, vm.ID, err)
+				return
+			}
+		}
+	}()
+
+	return transition.steps[0], nil
+}
+
+// setSimulatedPowerState stores a VM status and broadcasts the change
+func setSimulatedPowerState(dcID, vmID, status string) error {
+	vm, err := dataStore.UpdateVM(dcID, vmID, nil, &status, nil, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	broadcastEvent("vm:updated", map[string]interface{}{"datacenter": dcID, "vm": vm})
+	return nil
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/placement"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/rebalancer"
+)
+
+// placementCluster gives the rebalancer access to the store and the
+// migration paths
+type placementCluster struct{}
+
+func (placementCluster) State() (*placement.State, error) {
+	return placementState()
+}
+
+func (placementCluster) Admit(candidate placement.Candidate) error {
+	return admitPlacement(candidate)
+}
+
+func (placementCluster) Execute(ctx context.Context, decision *placement.Decision) (string, error) {
+	return executePlacement(ctx, decision)
+}
+
+// rebalance is the background rebalancer; it is stopped until started
+// through the admin API or --rebalance
+var rebalance = newRebalancer()
+
+func newRebalancer() *rebalancer.Controller {
+	return rebalancer.New(placementEngine, placementCluster{}, broadcastEvent)
+}
+
+// StartRebalancer starts the background rebalancer. An empty strategy uses
+// the default placement strategy.
+func StartRebalancer(config rebalancer.Config) error {
+	if config.Strategy == "" {
+		config.Strategy = placementStrategy
+	}
+	return rebalance.Start(config)
+}
+
+// RebalancerRequest starts or stops the rebalancer. Configuration fields
+// left out keep their current values.
+type RebalancerRequest struct {
+	Action string `json:"action"` // start or stop
+	rebalancer.Config
+}
+
+// GetRebalancerHandler returns the rebalancer's status and planned moves
+func GetRebalancerHandler(c *fiber.Ctx) error {
+	return c.JSON(rebalance.Status())
+}
+
+// RebalancerHandler starts, reconfigures or stops the rebalancer
+func RebalancerHandler(c *fiber.Ctx) error {
+	req := RebalancerRequest{Config: rebalance.Status().Config}
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body: " + err.Error()})
+	}
+
+	switch req.Action {
+	case "start":
+		if err := StartRebalancer(req.Config); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	case "stop":
+		rebalance.Stop()
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "action must be start or stop"})
+	}
+	return c.JSON(rebalance.Status())
+}
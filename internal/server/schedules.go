@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/scheduler"
+)
+
+// scheduleStore gives the scheduler the data store in use, which tests
+// replace
+type scheduleStore struct{}
+
+func (scheduleStore) SaveSchedule(schedule models.Schedule) error {
+	return dataStore.SaveSchedule(schedule)
+}
+
+func (scheduleStore) GetSchedules() ([]models.Schedule, error) {
+	return dataStore.GetSchedules()
+}
+
+func (scheduleStore) RemoveSchedule(id string) error {
+	return dataStore.RemoveSchedule(id)
+}
+
+// schedules runs the scheduled migrations; it is stopped until
+// StartScheduler is called
+var schedules = scheduler.New(scheduleStore{}, runScheduledJob, broadcastEvent)
+
+// StartScheduler starts running the stored schedules as they come due.
+// Schedules that came due while the server was down run right away.
+func StartScheduler() {
+	schedules.Start()
+}
+
+// validateJob checks the job of a new schedule against the datacenters and
+// VMs as they are now, and fills in the defaults of a bulk job
+func validateJob(job *models.ScheduleJob) error {
+	switch job.Type {
+	case models.JobMigrate:
+		req := job.Migrate
+		if req == nil || req.VMID == "" || req.ToDC == "" {
+			return errors.New("a migrate job needs migrate.vmId and migrate.toDC")
+		}
+		if findDatacenter(req.ToDC) == nil {
+			return fmt.Errorf("datacenter %s not found", req.ToDC)
+		}
+		if _, ok := locateVM(datacenterView(), req.VMID); !ok {
+			return fmt.Errorf("%w: %s", errVMNotFound, req.VMID)
+		}
+	case models.JobAuto:
+		if job.Strategy != "" {
+			if _, ok := placementEngine.Strategies()[job.Strategy]; !ok {
+				return fmt.Errorf("unknown placement strategy %q", job.Strategy)
+			}
+		}
+	case models.JobBulk:
+		if job.Bulk == nil {
+			return errors.New("a bulk job needs bulk")
+		}
+		if job.Bulk.DryRun {
+			return errors.New("a bulk job cannot be a dry run")
+		}
+		if err := validateBulkRequest(job.Bulk); err != nil {
+			return err
+		}
+		if findDatacenter(job.Bulk.ToDC) == nil {
+			return fmt.Errorf("datacenter %s not found", job.Bulk.ToDC)
+		}
+	default:
+		return errors.New("job type must be migrate, auto or bulk")
+	}
+	return nil
+}
+
+// runScheduledJob starts the migration of a schedule's job as an
+// operation. A job with nothing left to do, such as a VM already in its
+// target, is skipped.
+func runScheduledJob(ctx context.Context, job models.ScheduleJob) models.ScheduleRun {
+	failed := func(err error) models.ScheduleRun {
+		return models.ScheduleRun{State: models.RunFailed, Message: err.Error()}
+	}
+	skipped := func(format string, args ...interface{}) models.ScheduleRun {
+		return models.ScheduleRun{State: models.RunSkipped, Message: fmt.Sprintf(format, args...)}
+	}
+	started := func(opID, format string, args ...interface{}) models.ScheduleRun {
+		return models.ScheduleRun{State: models.RunStarted, OperationID: opID, Message: fmt.Sprintf(format, args...)}
+	}
+
+	switch job.Type {
+	case models.JobMigrate:
+		vm, ok := locateVM(datacenterView(), job.Migrate.VMID)
+		if !ok {
+			return failed(fmt.Errorf("%w: %s", errVMNotFound, job.Migrate.VMID))
+		}
+		if vm.dcID == job.Migrate.ToDC {
+			return skipped("VM %s is already in %s", vm.Name, vm.dcID)
+		}
+		// The VM moves from wherever it runs now
+		req := *job.Migrate
+		req.FromDC = vm.dcID
+		operationID, _, err := trackMigration(ctx, req)
+		if err != nil {
+			return failed(err)
+		}
+		return started(operationID, "moving VM %s from %s to %s", vm.Name, req.FromDC, req.ToDC)
+
+	case models.JobAuto:
+		decision, _, err := decidePlacement(job.Strategy)
+		if err != nil {
+			return failed(err)
+		}
+		if decision == nil {
+			return skipped("no migration improves the placement")
+		}
+		operationID, _, err := trackPlacement(ctx, decision)
+		if err != nil {
+			return failed(err)
+		}
+		return started(operationID, "moving VM %s from %s to %s", decision.VMName, decision.FromDC, decision.ToDC)
+
+	case models.JobBulk:
+		req := *job.Bulk
+		vms, err := selectBulkVMs(req)
+		if err != nil {
+			return failed(err)
+		}
+		if len(vms) == 0 {
+			return skipped("no VMs to move")
+		}
+		op := startBulkMigration(req, vms)
+		return started(op.ID, "moving %d VMs to %s", len(vms), req.ToDC)
+	}
+	return failed(fmt.Errorf("unknown job type %q", job.Type))
+}
+
+// CreateScheduleHandler creates a one-off schedule, with at, or a recurring
+// one, with cron
+func CreateScheduleHandler(c *fiber.Ctx) error {
+	var schedule models.Schedule
+	if err := json.Unmarshal(c.Body(), &schedule); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body: " + err.Error()})
+	}
+	if err := validateJob(&schedule.Job); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := schedules.Validate(schedule); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	created, err := schedules.Create(schedule)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(created)
+}
+
+// GetSchedulesHandler lists the schedules, oldest first
+func GetSchedulesHandler(c *fiber.Ctx) error {
+	list, err := schedules.List()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if list == nil {
+		list = []models.Schedule{}
+	}
+	return c.JSON(fiber.Map{"schedules": list})
+}
+
+// GetScheduleHandler returns a schedule with its run history
+func GetScheduleHandler(c *fiber.Ctx) error {
+	schedule, err := schedules.Get(c.Params("id"))
+	if err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(schedule)
+}
+
+// PauseScheduleHandler stops a schedule from running until it is resumed
+func PauseScheduleHandler(c *fiber.Ctx) error {
+	return setSchedulePaused(c, true)
+}
+
+// ResumeScheduleHandler lets a paused schedule run again
+func ResumeScheduleHandler(c *fiber.Ctx) error {
+	return setSchedulePaused(c, false)
+}
+
+func setSchedulePaused(c *fiber.Ctx, paused bool) error {
+	schedule, err := schedules.SetPaused(c.Params("id"), paused)
+	if err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(schedule)
+}
+
+// RunScheduleHandler runs a schedule's job right away and returns the run
+func RunScheduleHandler(c *fiber.Ctx) error {
+	run, err := schedules.RunNow(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(202).JSON(run)
+}
+
+// DeleteScheduleHandler deletes a schedule. Operations it started keep
+// running.
+func DeleteScheduleHandler(c *fiber.Ctx) error {
+	if err := schedules.Delete(c.Params("id")); err != nil {
+		return c.Status(scheduleErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(204)
+}
+
+// scheduleErrorStatus maps a scheduler error to an HTTP status
+func scheduleErrorStatus(err error) int {
+	if errors.Is(err, scheduler.ErrNotFound) {
+		return 404
+	}
+	return 500
+}
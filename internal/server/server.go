@@ -1,8 +1,8 @@
 package server
 
 import (
-	"bufio"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
@@ -10,11 +10,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/cldmnky/summit-connect-stockholm-2025/internal/data"
 	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
@@ -45,6 +47,7 @@ func InitDataStore(dbPath string, seedPath string) error {
 		return err
 	}
 	dataStore = ds
+	operations.failInterrupted()
 	return nil
 }
 
@@ -66,16 +69,17 @@ func InitDataStoreForVMWatcher(dbPath string, watcherConfigPath string) error {
 	}
 
 	dataStore = ds
+	operations.failInterrupted()
 	return nil
 }
 
 // InitVMWatcher initializes and starts the VM watcher
-func InitVMWatcher(configPath string) error {
+func InitVMWatcher(configPath string, options watcher.Options) error {
 	if dataStore == nil {
 		return fmt.Errorf("datastore must be initialized before starting VM watcher")
 	}
 
-	watcher, err := watcher.NewVMWatcher(dataStore, configPath)
+	watcher, err := watcher.NewVMWatcher(dataStore, configPath, options)
 	if err != nil {
 		return fmt.Errorf("failed to create VM watcher: %w", err)
 	}
@@ -125,20 +129,24 @@ func StartBackendServerWithFS(port int, frontendFS *embed.FS) {
 
 	// Get all datacenters
 	api.Get("/datacenters", GetDatacentersHandler)
+	api.Get("/datacenters/:id/utilization", GetDatacenterUtilizationHandler)
 
 	// Admin routes for runtime updates
 	admin := api.Group("/admin")
 	admin.Get("/datacenters", func(c *fiber.Ctx) error {
-		return c.JSON(dataStore.GetDatacenters())
+		return c.JSON(datacenterView())
 	})
 
 	// Lightweight test endpoint to broadcast a test event via the hub. This
 	// helps debugging SSE delivery from server -> hub -> connected clients.
 	admin.Get("/test-event", func(c *fiber.Ctx) error {
-		watcher.DefaultHub.BroadcastEvent("test:event", map[string]string{"msg": "manual test event"})
+		broadcastEvent("test:event", map[string]string{"msg": "manual test event"})
 		return c.JSON(fiber.Map{"ok": true, "sent": true})
 	})
 
+	// GET /api/v1/admin/events/stats -> event hub clients and dropped events
+	admin.Get("/events/stats", EventStatsHandler)
+
 	// PATCH /api/v1/admin/datacenters/:id  -> update name/location/coordinates
 	admin.Patch("/datacenters/:id", UpdateDatacenterHandler)
 
@@ -151,75 +159,84 @@ func StartBackendServerWithFS(port int, frontendFS *embed.FS) {
 	// DELETE /api/v1/admin/datacenters/:dcId/vms/:vmId -> remove VM
 	admin.Delete("/datacenters/:dcId/vms/:vmId", RemoveVMHandler)
 
+	// POST /api/v1/admin/datacenters/:id/maintenance -> enter or exit maintenance
+	admin.Post("/datacenters/:id/maintenance", DatacenterMaintenanceHandler)
+
+	// POST /api/v1/admin/datacenters/:id/fail -> simulate a datacenter failure
+	admin.Post("/datacenters/:id/fail", FailDatacenterHandler)
+
+	// POST /api/v1/admin/datacenters/:id/recover -> recover a failed datacenter
+	admin.Post("/datacenters/:id/recover", RecoverDatacenterHandler)
+
+	// GET /api/v1/admin/datacenters/:id/failover -> failover state and timeline
+	admin.Get("/datacenters/:id/failover", GetFailoverHandler)
+
+	// Background rebalancer: status, and start/stop
+	admin.Get("/rebalancer", GetRebalancerHandler)
+	admin.Post("/rebalancer", RebalancerHandler)
+
+	// Outbound webhooks for the hub's events, with a delivery log
+	admin.Get("/webhooks", GetWebhooksHandler)
+	admin.Post("/webhooks", CreateWebhookHandler)
+	admin.Get("/webhooks/:id", GetWebhookHandler)
+	admin.Patch("/webhooks/:id", UpdateWebhookHandler)
+	admin.Delete("/webhooks/:id", DeleteWebhookHandler)
+	admin.Get("/webhooks/:id/deliveries", GetWebhookDeliveriesHandler)
+	admin.Post("/webhooks/:id/test", TestWebhookHandler)
+
+	// VM power actions: start, stop, restart, pause, unpause
+	api.Post("/vms/:id/:action", VMPowerHandler)
+
+	// Cluster nodes with the VMs placed on each
+	api.Get("/clusters/:name/nodes", ClusterNodesHandler)
+
 	// Migrate VM
 	api.Post("/migrate", MigrateVMHandler)
 
-	// Auto migrate (picks a random VM and migrates it)
+	// Bulk migrate (many VMs to one datacenter as one operation)
+	api.Post("/migrate/bulk", BulkMigrateHandler)
+
+	// Auto migrate (a placement strategy picks the VM and the target)
 	api.Get("/migrate", AutoMigrateVMHandler)
+	api.Get("/placement/strategies", GetPlacementStrategiesHandler)
+
+	// Long-running operations such as migrations and evacuations
+	api.Get("/operations", GetOperationsHandler)
+	api.Get("/operations/:id", GetOperationHandler)
+	api.Post("/operations/:id/cancel", CancelOperationHandler)
+
+	// Scheduled migrations, one-off or recurring
+	api.Post("/schedules", CreateScheduleHandler)
+	api.Get("/schedules", GetSchedulesHandler)
+	api.Get("/schedules/:id", GetScheduleHandler)
+	api.Post("/schedules/:id/pause", PauseScheduleHandler)
+	api.Post("/schedules/:id/resume", ResumeScheduleHandler)
+	api.Post("/schedules/:id/run", RunScheduleHandler)
+	api.Delete("/schedules/:id", DeleteScheduleHandler)
 
 	// Migration tracking endpoints
 	api.Get("/migrations", GetAllMigrationsHandler)
 	api.Get("/migrations/active", GetActiveMigrationsHandler)
+	api.Get("/migrations/logical", GetLogicalMigrationsHandler)
 	api.Get("/migrations/datacenter/:dcId", GetMigrationsByDatacenterHandler)
 	api.Get("/migrations/vm/:vmName", GetMigrationsByVMHandler)
 	api.Get("/migrations/direction/:direction", GetMigrationsByDirectionHandler) // New endpoint for direction-based queries
 	api.Get("/migrations/:id", GetMigrationHandler)
+	api.Delete("/migrations/:id", AbortMigrationHandler)
+	api.Post("/migrations/:id/abort", AbortMigrationHandler)
 
 	// Status endpoint
 	api.Get("/status", GetStatusHandler)
 
-	// Server-Sent Events endpoint for watcher events
-	api.Get("/events", func(c *fiber.Ctx) error {
-		// Set SSE headers
-		c.Set("Content-Type", "text/event-stream")
-		c.Set("Cache-Control", "no-cache")
-		c.Set("Connection", "keep-alive")
-
-		// Register with the watcher hub. We must keep the registration
-		// alive for the duration of the stream writer. If we unregister
-		// immediately (via defer here) the channel will be closed before
-		// the stream function runs which causes the connection to end.
-		ch := watcher.DefaultHub.Register()
-
-		// Use low-level stream writer to push events as they arrive. The
-		// Unregister is performed inside the stream writer when it exits
-		// (client disconnected or writer returned an error).
-		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
-			// Ensure we unregister this client when the writer exits.
-			defer watcher.DefaultHub.Unregister(ch)
-
-			// Send an initial hello/ping event through the same writer so
-			// the client receives a valid SSE payload immediately.
-			if _, err := w.WriteString("event: hello\n"); err != nil {
-				return
-			}
-			if _, err := w.WriteString("data: {\"msg\":\"connected\"}\n\n"); err != nil {
-				return
-			}
-			if err := w.Flush(); err != nil {
-				return
-			}
+	// Server-Sent Events endpoint for watcher events, with replay of
+	// missed events
+	api.Get("/events", EventsHandler)
 
-			for msg := range ch {
-				// Write SSE 'message' with JSON payload
-				if _, err := w.WriteString("data: "); err != nil {
-					return
-				}
-				if _, err := w.WriteString(msg); err != nil {
-					return
-				}
-				if _, err := w.WriteString("\n\n"); err != nil {
-					return
-				}
-				if err := w.Flush(); err != nil {
-					return
-				}
-			}
-		})
+	// WebSocket with the same events, plus commands from the client
+	api.Get("/ws", WebSocketHandler)
 
-		// Returning nil lets Fiber consider the response handled by the stream writer
-		return nil
-	})
+	// Events posted by peer replicas when events are shared
+	api.Post("/bus/events", BusEventsHandler)
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -261,10 +278,34 @@ func StartBackendServerWithFS(port int, frontendFS *embed.FS) {
 // API Handlers
 
 func GetDatacentersHandler(c *fiber.Ctx) error {
-	datacenters := dataStore.GetDatacenters()
+	datacenters := datacenterView()
+
+	// Optional Kubernetes-style label selector, e.g. ?labelSelector=app=web,tier!=db
+	if selectorParam := c.Query("labelSelector"); selectorParam != "" {
+		selector, err := labels.Parse(selectorParam)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid labelSelector: %v", err)})
+		}
+		filterVMsByLabels(datacenters, selector)
+	}
+
 	return c.JSON(datacenters)
 }
 
+// filterVMsByLabels removes VMs whose labels don't match the selector
+func filterVMsByLabels(datacenters *models.DatacenterCollection, selector labels.Selector) {
+	for i := range datacenters.Datacenters {
+		dc := &datacenters.Datacenters[i]
+		filtered := []models.VM{}
+		for _, vm := range dc.VMs {
+			if selector.Matches(labels.Set(vm.Labels)) {
+				filtered = append(filtered, vm)
+			}
+		}
+		dc.VMs = filtered
+	}
+}
+
 func MigrateVMHandler(c *fiber.Ctx) error {
 	var req models.MigrateRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -282,109 +323,163 @@ func MigrateVMHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.FromDC == req.ToDC {
-		return c.Status(400).JSON(models.MigrateResponse{
-			Success: false,
-			Message: "Source and target datacenters cannot be the same",
-		})
-	}
-
-	// Perform migration
-	vm, err := dataStore.MigrateVM(req.VMID, req.FromDC, req.ToDC)
+	// In watcher mode, VMs on watched clusters are live migrated on the
+	// clusters; the watcher moves the record once the migration succeeds
+	operationID, migrationID, err := trackMigration(c.UserContext(), req)
 	if err != nil {
-		return c.Status(404).JSON(models.MigrateResponse{
+		return c.Status(migrationErrorStatus(err)).JSON(models.MigrateResponse{
 			Success: false,
 			Message: err.Error(),
 		})
 	}
 
-	// Data store persists to BoltDB automatically
-
-	return c.JSON(models.MigrateResponse{
-		Success: true,
-		Message: fmt.Sprintf("Successfully migrated VM %s (%s) from %s to %s", vm.ID, vm.Name, req.FromDC, req.ToDC),
-		VM:      vm,
+	message := fmt.Sprintf("Migrating VM %s from %s to %s", req.VMID, req.FromDC, req.ToDC)
+	if migrationID != "" {
+		message = fmt.Sprintf("Started migration %s of VM %s from %s to %s", migrationID, req.VMID, req.FromDC, req.ToDC)
+	}
+	return c.Status(202).JSON(models.MigrateResponse{
+		Success:     true,
+		Message:     message,
+		MigrationID: migrationID,
+		OperationID: operationID,
 	})
 }
 
-func AutoMigrateVMHandler(c *fiber.Ctx) error {
-	datacenters := dataStore.GetDatacenters()
+// errVMNotFound, errInvalidTarget, errOvercommit, errMaintenance and
+// errDatacenterDown classify migration planning failures
+var (
+	errVMNotFound     = errors.New("vm not found")
+	errInvalidTarget  = errors.New("invalid migration target")
+	errOvercommit     = errors.New("insufficient capacity")
+	errMaintenance    = errors.New("datacenter under maintenance")
+	errDatacenterDown = errors.New("datacenter is down")
+)
 
-	// Find a VM to migrate (prefer running VMs)
-	var sourceVM *models.VM
-	var sourceDC *models.Datacenter
-	var targetDC *models.Datacenter
+// planClusterMigration resolves a migrate request to the clusters involved.
+// It returns nil when the VM is not on a watched cluster, in which case only
+// the record is moved.
+func planClusterMigration(req models.MigrateRequest) (*watcher.MigrationPlan, error) {
+	vm, err := dataStore.GetVM(req.FromDC, req.VMID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errVMNotFound, err)
+	}
+	if vm.Cluster == "" || !vmWatcher.IsWatched(vm.Cluster) {
+		return nil, nil
+	}
+
+	targetCluster := req.TargetCluster
+	if targetCluster == "" && req.FromDC == req.ToDC {
+		targetCluster = vm.Cluster
+	}
 
-	// Look for running VMs first
+	var targetDC *models.Datacenter
+	datacenters := dataStore.GetDatacenters()
 	for i := range datacenters.Datacenters {
-		dc := &datacenters.Datacenters[i]
-		for j := range dc.VMs {
-			vm := &dc.VMs[j]
-			if vm.Status == "running" {
-				sourceVM = vm
-				sourceDC = dc
-				break
-			}
-		}
-		if sourceVM != nil {
+		if datacenters.Datacenters[i].ID == req.ToDC {
+			targetDC = &datacenters.Datacenters[i]
 			break
 		}
 	}
+	if targetDC == nil {
+		return nil, fmt.Errorf("%w: datacenter %s not found", errInvalidTarget, req.ToDC)
+	}
 
-	// If no running VMs, pick any VM
-	if sourceVM == nil {
-		for i := range datacenters.Datacenters {
-			dc := &datacenters.Datacenters[i]
-			if len(dc.VMs) > 0 {
-				sourceVM = &dc.VMs[0]
-				sourceDC = dc
+	if targetCluster == "" {
+		// Default to the first watched cluster in the target datacenter
+		for _, cluster := range targetDC.Clusters {
+			if vmWatcher.IsWatched(cluster) {
+				targetCluster = cluster
 				break
 			}
 		}
+		if targetCluster == "" {
+			return nil, fmt.Errorf("%w: datacenter %s has no watched clusters", errInvalidTarget, req.ToDC)
+		}
+	} else {
+		found := false
+		for _, cluster := range targetDC.Clusters {
+			if cluster == targetCluster {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: cluster %s is not in datacenter %s", errInvalidTarget, targetCluster, req.ToDC)
+		}
+		if !vmWatcher.IsWatched(targetCluster) {
+			return nil, fmt.Errorf("%w: cluster %s is not watched", errInvalidTarget, targetCluster)
+		}
 	}
 
-	if sourceVM == nil {
-		return c.JSON(fiber.Map{
-			"ok":       true,
+	return &watcher.MigrationPlan{
+		VMName:        vm.Name,
+		Namespace:     vm.Namespace,
+		SourceCluster: vm.Cluster,
+		TargetCluster: targetCluster,
+	}, nil
+}
+
+// migrationErrorStatus maps a migration planning error to an HTTP status
+func migrationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errVMNotFound):
+		return 404
+	case errors.Is(err, errInvalidTarget):
+		return 400
+	case errors.Is(err, errOvercommit), errors.Is(err, errMaintenance), errors.Is(err, errDatacenterDown):
+		return 409
+	default:
+		return 500
+	}
+}
+
+// AutoMigrateVMHandler lets a placement strategy pick a VM and a target
+// datacenter and migrates it. ?strategy= selects the strategy and
+// ?dry-run=1 returns the decision with the scoring of every candidate
+// without migrating.
+func AutoMigrateVMHandler(c *fiber.Ctx) error {
+	decision, scores, err := decidePlacement(c.Query("strategy"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"ok":       false,
 			"migrated": false,
-			"reason":   "No VMs available for migration",
+			"reason":   err.Error(),
 		})
 	}
 
-	// Find a target datacenter (different from source)
-	for i := range datacenters.Datacenters {
-		dc := &datacenters.Datacenters[i]
-		if dc.ID != sourceDC.ID {
-			targetDC = dc
-			break
+	if decision == nil {
+		reason := "No running VMs available for migration"
+		if len(scores) > 0 {
+			reason = "No migration improves the placement"
 		}
-	}
-
-	if targetDC == nil {
 		return c.JSON(fiber.Map{
 			"ok":       true,
 			"migrated": false,
-			"reason":   "No target datacenter available",
+			"reason":   reason,
+			"scores":   scores,
 		})
 	}
 
 	// Check if dry-run is requested
-	dryRun := c.Query("dry-run") == "1"
-
-	if dryRun {
-		// Return migration info without actually performing it
+	if c.Query("dry-run") == "1" {
+		// Return the decision without performing it
 		return c.JSON(fiber.Map{
 			"ok":       true,
 			"migrated": true,
-			"vmId":     sourceVM.ID,
-			"from":     sourceDC.ID,
-			"to":       targetDC.ID,
+			"dryRun":   true,
+			"vmId":     decision.VMID,
+			"vmName":   decision.VMName,
+			"from":     decision.FromDC,
+			"to":       decision.ToDC,
+			"strategy": decision.Strategy,
+			"score":    decision.Score,
+			"reasons":  decision.Reasons,
+			"scores":   decision.Scores,
 			"reason":   "Dry run - migration simulated",
 		})
 	}
 
-	// Perform actual migration
-	vm, err := dataStore.MigrateVM(sourceVM.ID, sourceDC.ID, targetDC.ID)
+	operationID, migrationID, err := trackPlacement(c.UserContext(), decision)
 	if err != nil {
 		return c.JSON(fiber.Map{
 			"ok":       false,
@@ -393,20 +488,27 @@ func AutoMigrateVMHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// Data store persists to BoltDB automatically
-
-	return c.JSON(fiber.Map{
-		"ok":       true,
-		"migrated": true,
-		"vmId":     vm.ID,
-		"from":     sourceDC.ID,
-		"to":       targetDC.ID,
-		"reason":   fmt.Sprintf("Successfully migrated VM %s from %s to %s", vm.Name, sourceDC.Name, targetDC.Name),
+	reason := fmt.Sprintf("Migrating VM %s from %s to %s", decision.VMName, decision.FromDC, decision.ToDC)
+	if migrationID != "" {
+		reason = fmt.Sprintf("Started migration %s of VM %s from %s to %s", migrationID, decision.VMName, decision.FromDC, decision.ToDC)
+	}
+	return c.Status(202).JSON(fiber.Map{
+		"ok":          true,
+		"migrated":    true,
+		"vmId":        decision.VMID,
+		"vmName":      decision.VMName,
+		"from":        decision.FromDC,
+		"to":          decision.ToDC,
+		"strategy":    decision.Strategy,
+		"reasons":     decision.Reasons,
+		"migrationId": migrationID,
+		"operationId": operationID,
+		"reason":      reason,
 	})
 }
 
 func GetStatusHandler(c *fiber.Ctx) error {
-	datacenters := dataStore.GetDatacenters()
+	datacenters := datacenterView()
 
 	totalVMs := 0
 	runningVMs := 0
@@ -419,11 +521,19 @@ func GetStatusHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	utilization, err := dataStore.GetUtilization()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	return c.JSON(fiber.Map{
-		"datacenters": len(datacenters.Datacenters),
-		"total_vms":   totalVMs,
-		"running_vms": runningVMs,
-		"stopped_vms": totalVMs - runningVMs,
+		"datacenters":            len(datacenters.Datacenters),
+		"total_vms":              totalVMs,
+		"running_vms":            runningVMs,
+		"stopped_vms":            totalVMs - runningVMs,
+		"utilization":            totalUtilization(utilization),
+		"datacenter_utilization": utilization,
+		"overcommit_ratio":       overcommitRatio,
 	})
 }
 
@@ -454,6 +564,16 @@ func GetAllMigrationsHandler(c *fiber.Ctx) error {
 	return c.JSON(migrations)
 }
 
+// GetLogicalMigrationsHandler returns cross-cluster migrations with their
+// outgoing and incoming halves correlated by migration ID
+func GetLogicalMigrationsHandler(c *fiber.Ctx) error {
+	migrations, err := dataStore.GetAllMigrations()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(watcher.CorrelateMigrations(migrations))
+}
+
 func GetMigrationHandler(c *fiber.Ctx) error {
 	id := c.Params("id")
 	migration, err := dataStore.GetMigration(id)
@@ -466,6 +586,85 @@ func GetMigrationHandler(c *fiber.Ctx) error {
 	return c.JSON(migration)
 }
 
+// AbortMigrationHandler cancels a running migration. The id is either a
+// migration record ID or the shared ID of a cross-cluster migration, whose
+// halves are both aborted. In watcher mode the VMIMs are deleted on their
+// clusters and the watcher marks the records Aborted; otherwise the records
+// are marked Aborted directly.
+func AbortMigrationHandler(c *fiber.Ctx) error {
+	id := c.Params("id")
+	migrations, err := findMigrationRecords(id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(migrations) == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "migration not found"})
+	}
+
+	var running []models.Migration
+	for _, migration := range migrations {
+		if !migration.Completed {
+			running = append(running, migration)
+		}
+	}
+	if len(running) == 0 {
+		return c.Status(409).JSON(fiber.Map{"error": "migration has already finished"})
+	}
+
+	var aborted []string
+	pending := false
+	for _, migration := range running {
+		if vmWatcher != nil && vmWatcher.IsWatched(migration.Cluster) {
+			if err := vmWatcher.AbortMigration(c.UserContext(), migration.Cluster, migration.Namespace, migration.ID); err != nil {
+				return c.Status(502).JSON(fiber.Map{"error": err.Error(), "aborted": aborted})
+			}
+			pending = true
+		} else {
+			watcher.MarkMigrationAborted(&migration, time.Now())
+			if err := dataStore.UpdateMigration(migration); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error(), "aborted": aborted})
+			}
+			broadcastEvent("migration:updated", map[string]interface{}{"datacenter": migration.DatacenterID, "migration": migration})
+		}
+		aborted = append(aborted, migration.ID)
+	}
+
+	status := 200
+	if pending {
+		// The watcher finishes the abort once the clusters have processed it
+		status = 202
+	}
+	return c.Status(status).JSON(fiber.Map{"ok": true, "aborted": aborted})
+}
+
+// findMigrationRecords returns the record with the given ID together with
+// its cross-cluster peers, or every record sharing the given migration ID
+func findMigrationRecords(id string) ([]models.Migration, error) {
+	all, err := dataStore.GetAllMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	migrationID := id
+	for _, migration := range all {
+		if migration.ID == id {
+			if migration.MigrationID == "" {
+				return []models.Migration{migration}, nil
+			}
+			migrationID = migration.MigrationID
+			break
+		}
+	}
+
+	var records []models.Migration
+	for _, migration := range all {
+		if migration.ID == id || migration.MigrationID == migrationID {
+			records = append(records, migration)
+		}
+	}
+	return records, nil
+}
+
 func GetMigrationsByDatacenterHandler(c *fiber.Ctx) error {
 	dcId := c.Params("dcId")
 	migrations, err := dataStore.GetMigrationsByDatacenter(dcId)
@@ -1,20 +1,30 @@
 package server_test
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gorilla/websocket"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/data"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/eventbus"
 	"github.com/cldmnky/summit-connect-stockholm-2025/internal/mocks"
 	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
 	"github.com/cldmnky/summit-connect-stockholm-2025/internal/server"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/webhooks"
 )
 
 var _ = Describe("Server API Handlers", func() {
@@ -41,6 +51,19 @@ var _ = Describe("Server API Handlers", func() {
 		setupTestServer(app, mockStore)
 	})
 
+	// settled waits for an operation to finish and returns its state, so
+	// its moves are done before the next spec replaces the store
+	settled := func(id string) string {
+		var state string
+		Eventually(func() bool {
+			op, err := mockStore.GetOperation(id)
+			Expect(err).NotTo(HaveOccurred())
+			state = op.State
+			return models.OperationFinished(state)
+		}).Should(BeTrue())
+		return state
+	}
+
 	Describe("Health Check", func() {
 		It("should return healthy status", func() {
 			req := httptest.NewRequest(http.MethodGet, "/health", nil)
@@ -48,169 +71,1675 @@ var _ = Describe("Server API Handlers", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(resp.StatusCode).To(Equal(http.StatusOK))
 
-			var result map[string]interface{}
-			err = json.NewDecoder(resp.Body).Decode(&result)
+			var result map[string]interface{}
+			err = json.NewDecoder(resp.Body).Decode(&result)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result["status"]).To(Equal("healthy"))
+			Expect(result["service"]).To(Equal("backend-api"))
+		})
+	})
+
+	Describe("GET /api/v1/datacenters", func() {
+		It("should return all datacenters", func() {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/datacenters", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var result models.DatacenterCollection
+			err = json.NewDecoder(resp.Body).Decode(&result)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(result.Datacenters)).To(Equal(2))
+			Expect(result.Datacenters[0].ID).To(Equal("dc-test-1"))
+			Expect(result.Datacenters[1].ID).To(Equal("dc-test-2"))
+		})
+
+		It("should filter VMs by label selector", func() {
+			_, err := mockStore.AddVM("dc-test-1", models.VM{
+				ID:     "vm-labeled",
+				Name:   "labeled-vm",
+				Status: "running",
+				Labels: map[string]string{"app": "web", "tier": "frontend"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/datacenters?labelSelector=app%3Dweb", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var result models.DatacenterCollection
+			err = json.NewDecoder(resp.Body).Decode(&result)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(result.Datacenters)).To(Equal(2))
+			Expect(len(result.Datacenters[0].VMs)).To(Equal(1))
+			Expect(result.Datacenters[0].VMs[0].ID).To(Equal("vm-labeled"))
+			Expect(result.Datacenters[1].VMs).To(BeEmpty())
+		})
+
+		It("should reject an invalid label selector", func() {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/datacenters?labelSelector=app%3D%3D%3D", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should handle store errors gracefully", func() {
+			mockStore.SetShouldError(true, "database connection failed")
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/datacenters", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			// Even with store errors, GetDatacenters shouldn't fail in our mock
+			// but would return empty data
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+
+	Describe("GET /api/v1/status", func() {
+		It("should return correct status information", func() {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var result map[string]interface{}
+			err = json.NewDecoder(resp.Body).Decode(&result)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result["datacenters"]).To(Equal(float64(2)))
+			Expect(result["total_vms"]).To(Equal(float64(2)))
+			Expect(result["running_vms"]).To(Equal(float64(1)))
+			Expect(result["stopped_vms"]).To(Equal(float64(1)))
+		})
+
+		It("should report utilization against the configured capacity", func() {
+			mockStore.SetDatacenterCapacity("dc-test-1", &models.Resources{CPU: 16, Memory: 32768, Disk: 400}, nil)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var result struct {
+				Utilization           models.Utilization             `json:"utilization"`
+				DatacenterUtilization []models.DatacenterUtilization `json:"datacenter_utilization"`
+			}
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			Expect(result.DatacenterUtilization).To(HaveLen(2))
+			Expect(result.DatacenterUtilization[0].CPUPercent).To(Equal(25.0))
+			Expect(result.DatacenterUtilization[0].DiskPercent).To(Equal(25.0))
+			// The stopped VM in dc-test-2 holds only its disk
+			Expect(result.DatacenterUtilization[1].Allocated).To(Equal(models.Resources{Disk: 50}))
+			Expect(result.Utilization.Allocated.CPU).To(Equal(4))
+		})
+	})
+
+	Describe("GET /api/v1/datacenters/:id/utilization", func() {
+		BeforeEach(func() {
+			mockStore.SetDatacenterClusters("dc-test-2", "beta")
+			_, err := mockStore.AddVM("dc-test-2", models.VM{ID: "vm-on-beta", Name: "on-beta", Status: "running", CPU: 8, Memory: 16384, Disk: 20, Cluster: "beta"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		utilization := func() models.DatacenterUtilization {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/datacenters/dc-test-2/utilization", nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var result models.DatacenterUtilization
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			return result
+		}
+
+		It("should use the configured cluster capacity", func() {
+			mockStore.SetDatacenterCapacity("dc-test-2", nil, map[string]models.Resources{"beta": {CPU: 32, Memory: 65536, Disk: 200}})
+
+			result := utilization()
+			Expect(result.Capacity).To(Equal(models.Resources{CPU: 32, Memory: 65536, Disk: 200}))
+			Expect(result.Clusters["beta"].CPUPercent).To(Equal(25.0))
+			Expect(result.Clusters["beta"].MemoryPercent).To(Equal(25.0))
+		})
+
+		It("should size clusters from their nodes when no capacity is configured", func() {
+			Expect(mockStore.UpsertNode(models.Node{Name: "n1", Cluster: "beta", AllocatableCPU: 16, AllocatableMemory: 32768})).To(Succeed())
+			Expect(mockStore.UpsertNode(models.Node{Name: "n2", Cluster: "beta", AllocatableCPU: 16, AllocatableMemory: 32768})).To(Succeed())
+
+			result := utilization()
+			Expect(result.Capacity).To(Equal(models.Resources{CPU: 32, Memory: 65536}))
+			Expect(result.Clusters["beta"].CPUPercent).To(Equal(25.0))
+			Expect(result.DiskPercent).To(BeZero())
+		})
+
+		It("should return 404 for unknown datacenters", func() {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/datacenters/dc-nowhere/utilization", nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("POST /api/v1/migrate", func() {
+		Context("with valid migration request", func() {
+			It("should migrate VM successfully", func() {
+				migrateReq := models.MigrateRequest{
+					VMID:   "vm-001",
+					FromDC: "dc-test-1",
+					ToDC:   "dc-test-2",
+				}
+				body, _ := json.Marshal(migrateReq)
+
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+
+				var result models.MigrateResponse
+				err = json.NewDecoder(resp.Body).Decode(&result)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Success).To(BeTrue())
+				Expect(result.OperationID).NotTo(BeEmpty())
+				Expect(settled(result.OperationID)).To(Equal(models.OperationSucceeded))
+				_, err = mockStore.GetVM("dc-test-2", "vm-001")
+				Expect(err).NotTo(HaveOccurred())
+
+				op, err := mockStore.GetOperation(result.OperationID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(op.Type).To(Equal("migration"))
+				Expect(op.State).To(Equal(models.OperationSucceeded))
+				Expect(op.Progress).To(Equal(100))
+				Expect(op.Steps).To(HaveLen(1))
+				Expect(op.Result).To(HaveKeyWithValue("to", "dc-test-2"))
+			})
+		})
+
+		Context("with capacity limits", func() {
+			migrate := func(force bool) *http.Response {
+				body, _ := json.Marshal(models.MigrateRequest{VMID: "vm-001", FromDC: "dc-test-1", ToDC: "dc-test-2", Force: force})
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				return resp
+			}
+
+			// moved expects an admitted migration and waits for it
+			moved := func(resp *http.Response) {
+				Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+				var result models.MigrateResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+				Expect(settled(result.OperationID)).To(Equal(models.OperationSucceeded))
+			}
+
+			BeforeEach(func() {
+				// vm-001 needs 8192MB; dc-test-2 only has 6144MB
+				mockStore.SetDatacenterCapacity("dc-test-2", &models.Resources{CPU: 16, Memory: 6144, Disk: 500}, nil)
+			})
+
+			It("should reject a migration that would overcommit the target", func() {
+				resp := migrate(false)
+				Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+
+				var result models.MigrateResponse
+				Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+				Expect(result.Success).To(BeFalse())
+				Expect(result.Message).To(ContainSubstring("memory"))
+				Expect(result.Message).To(ContainSubstring("dc-test-2"))
+
+				_, err := mockStore.GetVM("dc-test-1", "vm-001")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should migrate anyway when forced", func() {
+				moved(migrate(true))
+			})
+
+			It("should admit the migration within the overcommit ratio", func() {
+				server.SetOvercommitRatio(2)
+				defer server.SetOvercommitRatio(1)
+				moved(migrate(false))
+			})
+		})
+
+		Context("with invalid migration request", func() {
+			It("should return error for missing fields", func() {
+				migrateReq := models.MigrateRequest{
+					VMID: "vm-001",
+					// Missing FromDC and ToDC
+				}
+				body, _ := json.Marshal(migrateReq)
+
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+				var result models.MigrateResponse
+				err = json.NewDecoder(resp.Body).Decode(&result)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Success).To(BeFalse())
+				Expect(result.Message).To(ContainSubstring("required"))
+			})
+
+			It("should return error for same source and target", func() {
+				migrateReq := models.MigrateRequest{
+					VMID:   "vm-001",
+					FromDC: "dc-test-1",
+					ToDC:   "dc-test-1", // Same as fromDC
+				}
+				body, _ := json.Marshal(migrateReq)
+
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+				var result models.MigrateResponse
+				err = json.NewDecoder(resp.Body).Decode(&result)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Success).To(BeFalse())
+				Expect(result.Message).To(ContainSubstring("cannot be the same"))
+			})
+
+			It("should return error for invalid JSON", func() {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", strings.NewReader("invalid json"))
+				req.Header.Set("Content-Type", "application/json")
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+				var result models.MigrateResponse
+				err = json.NewDecoder(resp.Body).Decode(&result)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Success).To(BeFalse())
+				Expect(result.Message).To(Equal("Invalid request body"))
+			})
+		})
+	})
+
+	Describe("POST /api/v1/migrate/bulk", func() {
+		bulk := func(body string) (int, map[string]interface{}) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate/bulk", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			var result map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			return resp.StatusCode, result
+		}
+
+		operation := func(id string) func() models.Operation {
+			return func() models.Operation {
+				op, err := mockStore.GetOperation(id)
+				Expect(err).NotTo(HaveOccurred())
+				return *op
+			}
+		}
+
+		BeforeEach(func() {
+			_, err := mockStore.AddVM("dc-test-1", models.VM{ID: "vm-003", Name: "web-2", Status: "running", CPU: 1, Memory: 1024, Labels: map[string]string{"app": "web"}})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should show the moves of a dry run in order", func() {
+			mockStore.SetDatacenterCapacity("dc-test-2", &models.Resources{CPU: 4}, nil)
+
+			status, result := bulk(`{"selector":{"datacenter":"dc-test-1"},"toDC":"dc-test-2","order":"smallest","dryRun":true}`)
+			Expect(status).To(Equal(http.StatusOK))
+			moves := result["moves"].([]interface{})
+			Expect(moves).To(HaveLen(2))
+			Expect(moves[0]).To(HaveKeyWithValue("vmId", "vm-003"))
+			Expect(moves[0]).To(HaveKeyWithValue("admitted", true))
+			Expect(moves[1]).To(HaveKeyWithValue("vmId", "vm-001"))
+			Expect(moves[1]).To(HaveKeyWithValue("admitted", false))
+			Expect(moves[1]).To(HaveKeyWithValue("reason", ContainSubstring("insufficient capacity")))
+
+			Expect(mockStore.GetDatacenters().Datacenters[0].VMs).To(HaveLen(2))
+		})
+
+		It("should select VMs by labels and name pattern", func() {
+			status, result := bulk(`{"selector":{"labels":"app=web","name":"web-*"},"toDC":"dc-test-2","dryRun":true}`)
+			Expect(status).To(Equal(http.StatusOK))
+			moves := result["moves"].([]interface{})
+			Expect(moves).To(HaveLen(1))
+			Expect(moves[0]).To(HaveKeyWithValue("vmName", "web-2"))
+		})
+
+		It("should move the VMs as one operation", func() {
+			status, result := bulk(`{"vmIds":["vm-003","vm-001","vm-002"],"toDC":"dc-test-2","concurrency":2}`)
+			Expect(status).To(Equal(http.StatusAccepted))
+			op := result["operation"].(map[string]interface{})
+			Expect(op["type"]).To(Equal("bulk-migration"))
+			Expect(op["steps"]).To(HaveLen(2))
+
+			Eventually(operation(op["id"].(string))).Should(HaveField("State", models.OperationSucceeded))
+			Expect(operation(op["id"].(string))().Result).To(HaveKeyWithValue("moved", BeNumerically("==", 2)))
+			Expect(mockStore.GetDatacenters().Datacenters[1].VMs).To(HaveLen(3))
+		})
+
+		It("should stop on the first failure when asked to", func() {
+			mockStore.SetDatacenterCapacity("dc-test-2", &models.Resources{CPU: 3}, nil)
+
+			status, result := bulk(`{"vmIds":["vm-001","vm-003"],"toDC":"dc-test-2","onFailure":"stop"}`)
+			Expect(status).To(Equal(http.StatusAccepted))
+			id := result["operation"].(map[string]interface{})["id"].(string)
+
+			Eventually(operation(id)).Should(HaveField("State", models.OperationFailed))
+			steps := operation(id)().Steps
+			Expect(steps[0].State).To(Equal(models.OperationFailed))
+			Expect(steps[1].State).To(Equal(models.OperationCancelled))
+			Expect(mockStore.GetDatacenters().Datacenters[0].VMs).To(HaveLen(2))
+		})
+
+		It("should reject invalid requests", func() {
+			status, _ := bulk(`{"vmIds":["vm-001"]}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
+
+			status, _ = bulk(`{"vmIds":["vm-001"],"selector":{},"toDC":"dc-test-2"}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
+
+			status, _ = bulk(`{"selector":{},"toDC":"dc-test-2","order":"listed"}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
+
+			status, _ = bulk(`{"vmIds":["vm-404"],"toDC":"dc-test-2"}`)
+			Expect(status).To(Equal(http.StatusNotFound))
+
+			status, _ = bulk(`{"vmIds":["vm-001"],"toDC":"dc-missing"}`)
+			Expect(status).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("GET /api/v1/migrate", func() {
+		autoMigrate := func(url string, status int) map[string]interface{} {
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(status))
+
+			var result map[string]interface{}
+			err = json.NewDecoder(resp.Body).Decode(&result)
+			Expect(err).NotTo(HaveOccurred())
+			return result
+		}
+
+		Context("auto migration", func() {
+			BeforeEach(func() {
+				// dc-test-1 runs three VMs and dc-test-2 one, so a move evens them out
+				for _, id := range []string{"vm-003", "vm-004"} {
+					_, err := mockStore.AddVM("dc-test-1", models.VM{ID: id, Name: "test-" + id, Status: "running", CPU: 4, Memory: 1024})
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			It("should auto migrate a VM", func() {
+				result := autoMigrate("/api/v1/migrate", http.StatusAccepted)
+				Expect(result["ok"]).To(BeTrue())
+				Expect(result["migrated"]).To(BeTrue())
+				Expect(result["operationId"]).NotTo(BeEmpty())
+				Expect(result["vmId"]).To(Not(BeEmpty()))
+				Expect(result["from"]).To(Equal("dc-test-1"))
+				Expect(result["to"]).To(Equal("dc-test-2"))
+				Expect(result["strategy"]).To(Equal("balance-count"))
+				Expect(settled(result["operationId"].(string))).To(Equal(models.OperationSucceeded))
+			})
+
+			It("should support dry-run mode", func() {
+				result := autoMigrate("/api/v1/migrate?dry-run=1", http.StatusOK)
+				Expect(result["ok"]).To(BeTrue())
+				Expect(result["migrated"]).To(BeTrue())
+				Expect(result["reason"]).To(ContainSubstring("Dry run"))
+				Expect(result["reasons"]).NotTo(BeEmpty())
+				Expect(result["scores"]).To(HaveLen(3))
+
+				// Nothing moved
+				Expect(mockStore.GetDatacenters().Datacenters[0].VMs).To(HaveLen(3))
+			})
+
+			It("should use the requested strategy", func() {
+				result := autoMigrate("/api/v1/migrate?dry-run=1&strategy=balance-memory", http.StatusOK)
+				Expect(result["strategy"]).To(Equal("balance-memory"))
+				Expect(result["vmId"]).To(Equal("vm-001"))
+				Expect(result["reasons"]).To(ContainElement("dc-test-1 memory 10240MB -> 2048MB"))
+			})
+
+			It("should not migrate when the datacenters are balanced", func() {
+				Expect(mockStore.RemoveVM("dc-test-1", "vm-003")).To(Succeed())
+				Expect(mockStore.RemoveVM("dc-test-1", "vm-004")).To(Succeed())
+
+				result := autoMigrate("/api/v1/migrate", http.StatusOK)
+				Expect(result["ok"]).To(BeTrue())
+				Expect(result["migrated"]).To(BeFalse())
+				Expect(result["reason"]).To(Equal("No migration improves the placement"))
+				Expect(result["scores"]).To(HaveLen(1))
+			})
+
+			It("should skip targets without capacity", func() {
+				mockStore.SetDatacenterCapacity("dc-test-2", &models.Resources{CPU: 2}, nil)
+
+				result := autoMigrate("/api/v1/migrate?dry-run=1", http.StatusOK)
+				Expect(result["migrated"]).To(BeFalse())
+				scores := result["scores"].([]interface{})
+				Expect(scores[0].(map[string]interface{})["reasons"]).To(ContainElement(ContainSubstring("insufficient capacity")))
+			})
+
+			It("should reject unknown strategies", func() {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/migrate?strategy=nope", nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		It("should list the placement strategies", func() {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/placement/strategies", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var result map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			Expect(result["default"]).To(Equal("balance-count"))
+			Expect(result["strategies"]).To(HaveKey("anti-affinity"))
+		})
+	})
+
+	Describe("POST /api/v1/admin/datacenters/:id/maintenance", func() {
+		maintenance := func(dcID, body string) (int, map[string]interface{}) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/datacenters/"+dcID+"/maintenance", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			var result map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			return resp.StatusCode, result
+		}
+
+		operationState := func(id string) func() string {
+			return func() string {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/operations/"+id, nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+
+				var op models.Operation
+				Expect(json.NewDecoder(resp.Body).Decode(&op)).To(Succeed())
+				return op.State
+			}
+		}
+
+		datacenterOf := func(vmID string) string {
+			for _, dc := range mockStore.GetDatacenters().Datacenters {
+				for _, vm := range dc.VMs {
+					if vm.ID == vmID {
+						return dc.ID
+					}
+				}
+			}
+			return ""
+		}
+
+		It("should exclude a datacenter under maintenance as a target", func() {
+			status, result := maintenance("dc-test-2", `{"action":"enter","reason":"firmware upgrade"}`)
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(result["datacenter"]).To(HaveKeyWithValue("maintenance", HaveKeyWithValue("reason", "firmware upgrade")))
+
+			body, _ := json.Marshal(models.MigrateRequest{VMID: "vm-001", FromDC: "dc-test-1", ToDC: "dc-test-2"})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+
+			req = httptest.NewRequest(http.MethodGet, "/api/v1/migrate?strategy=random", nil)
+			resp, err = app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			var auto map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&auto)).To(Succeed())
+			Expect(auto["migrated"]).To(BeFalse())
+		})
+
+		It("should evacuate and bring the VMs back", func() {
+			status, result := maintenance("dc-test-1", `{"action":"enter","evacuate":true,"parallelism":1}`)
+			Expect(status).To(Equal(http.StatusAccepted))
+			op := result["operation"].(map[string]interface{})
+			Expect(op["type"]).To(Equal("evacuation"))
+			Expect(op["steps"]).To(HaveLen(1))
+
+			Eventually(operationState(op["id"].(string))).Should(Equal(models.OperationSucceeded))
+			Expect(datacenterOf("vm-001")).To(Equal("dc-test-2"))
+			dc := mockStore.GetDatacenters().Datacenters[0]
+			Expect(dc.Maintenance.Evacuated).To(ConsistOf(models.EvacuatedVM{VMID: "vm-001", VMName: "test-vm-1", ToDC: "dc-test-2"}))
+
+			status, result = maintenance("dc-test-1", `{"action":"exit","bringBack":true}`)
+			Expect(status).To(Equal(http.StatusAccepted))
+			op = result["operation"].(map[string]interface{})
+			Expect(op["type"]).To(Equal("return"))
+
+			Eventually(operationState(op["id"].(string))).Should(Equal(models.OperationSucceeded))
+			Expect(datacenterOf("vm-001")).To(Equal("dc-test-1"))
+			Expect(mockStore.GetDatacenters().Datacenters[0].Maintenance).To(BeNil())
+		})
+
+		It("should report VMs that cannot be evacuated", func() {
+			mockStore.SetDatacenterCapacity("dc-test-2", &models.Resources{CPU: 1}, nil)
+
+			status, result := maintenance("dc-test-1", `{"action":"enter","evacuate":true}`)
+			Expect(status).To(Equal(http.StatusAccepted))
+			id := result["operation"].(map[string]interface{})["id"].(string)
+
+			Eventually(operationState(id)).Should(Equal(models.OperationFailed))
+			Expect(datacenterOf("vm-001")).To(Equal("dc-test-1"))
+		})
+
+		It("should reject invalid requests", func() {
+			status, _ := maintenance("dc-missing", `{"action":"enter"}`)
+			Expect(status).To(Equal(http.StatusNotFound))
+
+			status, _ = maintenance("dc-test-1", `{"action":"drain"}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
+
+			status, _ = maintenance("dc-test-1", `{"action":"exit"}`)
+			Expect(status).To(Equal(http.StatusConflict))
+
+			status, _ = maintenance("dc-test-1", `{"action":"enter"}`)
+			Expect(status).To(Equal(http.StatusOK))
+			status, _ = maintenance("dc-test-1", `{"action":"enter"}`)
+			Expect(status).To(Equal(http.StatusConflict))
+		})
+
+		It("should return 404 for unknown operations", func() {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/operations/op-missing", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("/api/v1/operations", func() {
+		get := func(url string) (int, []byte) {
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			var body bytes.Buffer
+			_, err = body.ReadFrom(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return resp.StatusCode, body.Bytes()
+		}
+
+		cancel := func(id string) int {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/operations/"+id+"/cancel", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			return resp.StatusCode
+		}
+
+		migrate := func(vmID, from, to string) string {
+			body, _ := json.Marshal(models.MigrateRequest{VMID: vmID, FromDC: from, ToDC: to})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+
+			var result models.MigrateResponse
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			settled(result.OperationID)
+			return result.OperationID
+		}
+
+		It("should list operations newest first and filter them", func() {
+			first := migrate("vm-001", "dc-test-1", "dc-test-2")
+			time.Sleep(time.Millisecond)
+			second := migrate("vm-002", "dc-test-2", "dc-test-1")
+
+			status, body := get("/api/v1/operations")
+			Expect(status).To(Equal(http.StatusOK))
+			var ops []models.Operation
+			Expect(json.Unmarshal(body, &ops)).To(Succeed())
+			Expect(ops).To(HaveLen(2))
+			Expect(ops[0].ID).To(Equal(second))
+			Expect(ops[1].ID).To(Equal(first))
+
+			_, body = get("/api/v1/operations?target=vm-001&state=succeeded")
+			Expect(json.Unmarshal(body, &ops)).To(Succeed())
+			Expect(ops).To(HaveLen(1))
+			Expect(ops[0].ID).To(Equal(first))
+
+			_, body = get("/api/v1/operations?type=evacuation")
+			Expect(json.Unmarshal(body, &ops)).To(Succeed())
+			Expect(ops).To(BeEmpty())
+		})
+
+		It("should only cancel running operations that support it", func() {
+			id := migrate("vm-001", "dc-test-1", "dc-test-2")
+			Expect(cancel(id)).To(Equal(http.StatusConflict))
+			Expect(cancel("op-missing")).To(Equal(http.StatusNotFound))
+		})
+
+		It("should fail operations interrupted by a restart", func() {
+			dbPath := filepath.Join(GinkgoT().TempDir(), "operations.db")
+			store, err := data.NewStore(dbPath, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.SaveOperation(models.Operation{
+				ID:    "op-interrupted",
+				Type:  "evacuation",
+				State: models.OperationRunning,
+				Steps: []models.OperationStep{
+					{Name: "test-vm-1", State: models.OperationSucceeded},
+					{Name: "test-vm-2", State: models.OperationRunning},
+				},
+			})).To(Succeed())
+			Expect(store.Close()).To(Succeed())
+
+			Expect(server.InitDataStore(dbPath, "")).To(Succeed())
+			status, body := get("/api/v1/operations/op-interrupted")
+			Expect(status).To(Equal(http.StatusOK))
+
+			var op models.Operation
+			Expect(json.Unmarshal(body, &op)).To(Succeed())
+			Expect(op.State).To(Equal(models.OperationFailed))
+			Expect(op.Error).To(ContainSubstring("restart"))
+			Expect(op.Progress).To(Equal(100))
+			Expect(op.Steps[0].State).To(Equal(models.OperationSucceeded))
+			Expect(op.Steps[1].State).To(Equal(models.OperationFailed))
+		})
+	})
+
+	Describe("/api/v1/schedules", func() {
+		send := func(method, url, body string) (int, []byte) {
+			req := httptest.NewRequest(method, url, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			var buf bytes.Buffer
+			_, err = buf.ReadFrom(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return resp.StatusCode, buf.Bytes()
+		}
+
+		create := func(body string) models.Schedule {
+			status, result := send(http.MethodPost, "/api/v1/schedules", body)
+			Expect(status).To(Equal(http.StatusCreated), string(result))
+
+			var schedule models.Schedule
+			Expect(json.Unmarshal(result, &schedule)).To(Succeed())
+			return schedule
+		}
+
+		run := func(id string) models.ScheduleRun {
+			status, result := send(http.MethodPost, "/api/v1/schedules/"+id+"/run", "")
+			Expect(status).To(Equal(http.StatusAccepted))
+
+			var run models.ScheduleRun
+			Expect(json.Unmarshal(result, &run)).To(Succeed())
+			return run
+		}
+
+		It("should create a recurring migration and record its runs", func() {
+			schedule := create(`{"name":"nightly","cron":"0 2 * * *","job":{"type":"migrate","migrate":{"vmId":"vm-001","toDC":"dc-test-2"}}}`)
+			Expect(schedule.ID).To(HavePrefix("sched-"))
+			Expect(schedule.NextRun).NotTo(BeNil())
+			Expect(schedule.NextRun.Hour()).To(Equal(2))
+
+			first := run(schedule.ID)
+			Expect(first.State).To(Equal(models.RunStarted))
+			Expect(first.OperationID).To(HavePrefix("op-"))
+			Expect(settled(first.OperationID)).To(Equal(models.OperationSucceeded))
+			Expect(mockStore.GetDatacenters().Datacenters[1].VMs).To(HaveLen(2))
+
+			second := run(schedule.ID)
+			Expect(second.State).To(Equal(models.RunSkipped))
+			Expect(second.Message).To(ContainSubstring("already in dc-test-2"))
+
+			status, result := send(http.MethodGet, "/api/v1/schedules/"+schedule.ID, "")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(json.Unmarshal(result, &schedule)).To(Succeed())
+			Expect(schedule.History).To(HaveLen(2))
+			Expect(schedule.History[0].State).To(Equal(models.RunSkipped))
+		})
+
+		It("should run bulk and auto jobs", func() {
+			bulk := create(`{"at":"2099-01-01T00:00:00Z","job":{"type":"bulk","bulk":{"selector":{"datacenter":"dc-test-1"},"toDC":"dc-test-2"}}}`)
+			Expect(bulk.Job.Bulk.Concurrency).To(Equal(1))
+			Expect(bulk.NextRun.Year()).To(Equal(2099))
+
+			result := run(bulk.ID)
+			Expect(result.State).To(Equal(models.RunStarted))
+			Eventually(func() string {
+				op, err := mockStore.GetOperation(result.OperationID)
+				Expect(err).NotTo(HaveOccurred())
+				return op.State
+			}).Should(Equal(models.OperationSucceeded))
+
+			Expect(run(bulk.ID).State).To(Equal(models.RunSkipped))
+
+			auto := create(`{"cron":"@every 10m","job":{"type":"auto"}}`)
+			autoRun := run(auto.ID)
+			Expect(autoRun.State).To(BeElementOf(models.RunStarted, models.RunSkipped))
+			if autoRun.OperationID != "" {
+				settled(autoRun.OperationID)
+			}
+		})
+
+		It("should list, pause, resume and delete schedules", func() {
+			schedule := create(`{"cron":"@hourly","job":{"type":"auto","strategy":"balance-count"}}`)
+
+			status, result := send(http.MethodGet, "/api/v1/schedules", "")
+			Expect(status).To(Equal(http.StatusOK))
+			var list struct {
+				Schedules []models.Schedule `json:"schedules"`
+			}
+			Expect(json.Unmarshal(result, &list)).To(Succeed())
+			Expect(list.Schedules).To(HaveLen(1))
+
+			status, result = send(http.MethodPost, "/api/v1/schedules/"+schedule.ID+"/pause", "")
+			Expect(status).To(Equal(http.StatusOK))
+			var paused models.Schedule
+			Expect(json.Unmarshal(result, &paused)).To(Succeed())
+			Expect(paused.Paused).To(BeTrue())
+			Expect(paused.NextRun).To(BeNil())
+
+			status, result = send(http.MethodPost, "/api/v1/schedules/"+schedule.ID+"/resume", "")
+			Expect(status).To(Equal(http.StatusOK))
+			var resumed models.Schedule
+			Expect(json.Unmarshal(result, &resumed)).To(Succeed())
+			Expect(resumed.Paused).To(BeFalse())
+			Expect(resumed.NextRun).NotTo(BeNil())
+
+			status, _ = send(http.MethodDelete, "/api/v1/schedules/"+schedule.ID, "")
+			Expect(status).To(Equal(http.StatusNoContent))
+			status, _ = send(http.MethodGet, "/api/v1/schedules/"+schedule.ID, "")
+			Expect(status).To(Equal(http.StatusNotFound))
+			status, _ = send(http.MethodPost, "/api/v1/schedules/"+schedule.ID+"/run", "")
+			Expect(status).To(Equal(http.StatusNotFound))
+		})
+
+		It("should reject invalid schedules", func() {
+			for _, body := range []string{
+				`{"cron":"@hourly","job":{"type":"shuffle"}}`,
+				`{"cron":"@hourly","job":{"type":"migrate","migrate":{"vmId":"vm-001"}}}`,
+				`{"cron":"@hourly","job":{"type":"migrate","migrate":{"vmId":"vm-404","toDC":"dc-test-2"}}}`,
+				`{"cron":"@hourly","job":{"type":"auto","strategy":"unknown"}}`,
+				`{"cron":"@hourly","job":{"type":"bulk","bulk":{"vmIds":["vm-001"],"toDC":"dc-test-2","dryRun":true}}}`,
+				`{"cron":"61 * * * *","job":{"type":"auto"}}`,
+				`{"job":{"type":"auto"}}`,
+				`{"cron":"@hourly","at":"2099-01-01T00:00:00Z","job":{"type":"auto"}}`,
+				`{"at":"2000-01-01T00:00:00Z","job":{"type":"auto"}}`,
+			} {
+				status, _ := send(http.MethodPost, "/api/v1/schedules", body)
+				Expect(status).To(Equal(http.StatusBadRequest), body)
+			}
+		})
+
+		It("should keep schedules across a restart", func() {
+			dbPath := filepath.Join(GinkgoT().TempDir(), "schedules.db")
+			store, err := data.NewStore(dbPath, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(store.SaveSchedule(models.Schedule{
+				ID:   "sched-stored",
+				Cron: "@daily",
+				Job:  models.ScheduleJob{Type: models.JobAuto},
+			})).To(Succeed())
+			Expect(store.Close()).To(Succeed())
+
+			Expect(server.InitDataStore(dbPath, "")).To(Succeed())
+			status, result := send(http.MethodGet, "/api/v1/schedules/sched-stored", "")
+			Expect(status).To(Equal(http.StatusOK))
+
+			var schedule models.Schedule
+			Expect(json.Unmarshal(result, &schedule)).To(Succeed())
+			Expect(schedule.Cron).To(Equal("@daily"))
+		})
+	})
+
+	Describe("POST /api/v1/admin/datacenters/:id/fail", func() {
+		BeforeEach(func() {
+			server.SetFailoverDelayForTesting(10 * time.Millisecond)
+		})
+
+		post := func(path, body string) (int, map[string]interface{}) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/datacenters/"+path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			var result map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			return resp.StatusCode, result
+		}
+
+		failoverState := func(dcID string) func() string {
+			return func() string {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/datacenters/"+dcID+"/failover", nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+
+				var result map[string]interface{}
+				Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+				state, _ := result["state"].(string)
+				return state
+			}
+		}
+
+		timeline := func(dcID string) []string {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/datacenters/"+dcID+"/failover", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			var result struct {
+				Timeline []models.TimelineEntry `json:"timeline"`
+			}
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			var events []string
+			for _, entry := range result.Timeline {
+				events = append(events, entry.Event+" "+entry.VMID)
+			}
+			return events
+		}
+
+		// shownIn returns where GET /datacenters shows a VM and its status
+		shownIn := func(vmID string) func() string {
+			return func() string {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/datacenters", nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+
+				var collection models.DatacenterCollection
+				Expect(json.NewDecoder(resp.Body).Decode(&collection)).To(Succeed())
+				for _, dc := range collection.Datacenters {
+					for _, vm := range dc.VMs {
+						if vm.ID == vmID {
+							return dc.ID + " " + vm.Status
+						}
+					}
+				}
+				return ""
+			}
+		}
+
+		recoverDatacenter := func(dcID string) {
+			status, _ := post(dcID+"/recover", "")
+			Expect(status).To(Equal(http.StatusAccepted))
+			Eventually(failoverState(dcID)).Should(Equal("recovered"))
+		}
+
+		It("should restart the VMs elsewhere and fail them back on recovery", func() {
+			status, result := post("dc-test-1/fail", `{"reason":"power outage"}`)
+			Expect(status).To(Equal(http.StatusAccepted))
+			Expect(result["state"]).To(Equal("failed"))
+
+			Eventually(shownIn("vm-001")).Should(Equal("dc-test-2 running"))
+			Expect(mockStore.GetDatacenters().Datacenters[0].VMs[0].ID).To(Equal("vm-001"))
+
+			body, _ := json.Marshal(models.MigrateRequest{VMID: "vm-002", FromDC: "dc-test-2", ToDC: "dc-test-1", Force: true})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+
+			status, _ = post("dc-test-1/fail", "")
+			Expect(status).To(Equal(http.StatusConflict))
+
+			recoverDatacenter("dc-test-1")
+			Expect(shownIn("vm-001")()).To(Equal("dc-test-1 running"))
+			Expect(timeline("dc-test-1")).To(Equal([]string{
+				"datacenter-failed ",
+				"vm-unavailable vm-001",
+				"vm-restarted vm-001",
+				"failover-complete ",
+				"datacenter-recovering ",
+				"vm-failed-back vm-001",
+				"datacenter-recovered ",
+			}))
+		})
+
+		It("should restart VMs in priority order", func() {
+			_, err := mockStore.AddVM("dc-test-1", models.VM{ID: "vm-003", Name: "db", Status: "running", Labels: map[string]string{"tier": "db"}})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = mockStore.AddVM("dc-test-1", models.VM{ID: "vm-004", Name: "cache", Status: "running", Labels: map[string]string{"recovery-priority": "5"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			status, _ := post("dc-test-1/fail", `{"plan":{"rules":[{"selector":"tier=db","priority":10,"target":"dc-test-2"}]}}`)
+			Expect(status).To(Equal(http.StatusAccepted))
+			Eventually(failoverState("dc-test-1")).Should(Equal("failed"))
+			Eventually(func() []string { return timeline("dc-test-1") }).Should(ContainElement("failover-complete "))
+
+			var restarted []string
+			for _, event := range timeline("dc-test-1") {
+				if strings.HasPrefix(event, "vm-restarted ") {
+					restarted = append(restarted, strings.TrimPrefix(event, "vm-restarted "))
+				}
+			}
+			Expect(restarted).To(Equal([]string{"vm-003", "vm-004", "vm-001"}))
+			Expect(shownIn("vm-003")()).To(Equal("dc-test-2 running"))
+
+			recoverDatacenter("dc-test-1")
+		})
+
+		It("should mark VMs that cannot be restarted", func() {
+			mockStore.SetDatacenterCapacity("dc-test-2", &models.Resources{CPU: 1}, nil)
+
+			status, _ := post("dc-test-1/fail", "")
+			Expect(status).To(Equal(http.StatusAccepted))
+			Eventually(func() []string { return timeline("dc-test-1") }).Should(ContainElement("vm-unrecoverable vm-001"))
+			Expect(shownIn("vm-001")()).To(Equal("dc-test-1 unavailable"))
+
+			recoverDatacenter("dc-test-1")
+		})
+
+		It("should reject invalid requests", func() {
+			status, _ := post("dc-missing/fail", "")
+			Expect(status).To(Equal(http.StatusNotFound))
+
+			status, _ = post("dc-test-1/fail", `{"plan":{"rules":[{"selector":"tier in"}]}}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
+
+			status, _ = post("dc-test-2/recover", "")
+			Expect(status).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Describe("/api/v1/admin/webhooks", func() {
+		send := func(method, url, body string) (int, []byte) {
+			req := httptest.NewRequest(method, url, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req, 5000)
+			Expect(err).NotTo(HaveOccurred())
+
+			var buf bytes.Buffer
+			_, err = buf.ReadFrom(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return resp.StatusCode, buf.Bytes()
+		}
+
+		var (
+			signatures chan string
+			receiver   *httptest.Server
+		)
+
+		BeforeEach(func() {
+			signatures = make(chan string, 10)
+			receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if r.Header.Get(webhooks.HeaderSignature) == webhooks.Sign("s3cret", body) {
+					signatures <- r.Header.Get(webhooks.HeaderEvent)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			DeferCleanup(receiver.Close)
+		})
+
+		create := func(body string) models.Webhook {
+			status, result := send(http.MethodPost, "/api/v1/admin/webhooks", body)
+			Expect(status).To(Equal(http.StatusCreated), string(result))
+
+			var webhook models.Webhook
+			Expect(json.Unmarshal(result, &webhook)).To(Succeed())
+			return webhook
+		}
+
+		It("should manage webhooks and only show the secret on creation", func() {
+			webhook := create(`{"name":"chat","url":"` + receiver.URL + `","events":["migration:*"]}`)
+			Expect(webhook.ID).To(HavePrefix("wh-"))
+			Expect(webhook.Secret).NotTo(BeEmpty())
+			Expect(webhook.Enabled).To(BeTrue())
+
+			status, result := send(http.MethodGet, "/api/v1/admin/webhooks", "")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(string(result)).NotTo(ContainSubstring(webhook.Secret))
+			var list struct {
+				Webhooks []models.Webhook `json:"webhooks"`
+			}
+			Expect(json.Unmarshal(result, &list)).To(Succeed())
+			Expect(list.Webhooks).To(HaveLen(1))
+
+			status, result = send(http.MethodPatch, "/api/v1/admin/webhooks/"+webhook.ID, `{"enabled":false,"events":["vm:*"]}`)
+			Expect(status).To(Equal(http.StatusOK))
+			var updated models.Webhook
+			Expect(json.Unmarshal(result, &updated)).To(Succeed())
+			Expect(updated.Enabled).To(BeFalse())
+			Expect(updated.Events).To(Equal([]string{"vm:*"}))
+			Expect(updated.Name).To(Equal("chat"))
+			Expect(updated.Secret).To(BeEmpty())
+
+			status, _ = send(http.MethodPatch, "/api/v1/admin/webhooks/"+webhook.ID, `{"secret":""}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
+
+			stored, err := mockStore.GetWebhooks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored[0].Secret).To(Equal(webhook.Secret))
+
+			status, _ = send(http.MethodDelete, "/api/v1/admin/webhooks/"+webhook.ID, "")
+			Expect(status).To(Equal(http.StatusNoContent))
+			status, _ = send(http.MethodGet, "/api/v1/admin/webhooks/"+webhook.ID, "")
+			Expect(status).To(Equal(http.StatusNotFound))
+		})
+
+		It("should test-fire a signed delivery and log it", func() {
+			webhook := create(`{"url":"` + receiver.URL + `","secret":"s3cret"}`)
+
+			status, result := send(http.MethodPost, "/api/v1/admin/webhooks/"+webhook.ID+"/test", "")
+			Expect(status).To(Equal(http.StatusOK))
+			var delivery webhooks.Delivery
+			Expect(json.Unmarshal(result, &delivery)).To(Succeed())
+			Expect(delivery.State).To(Equal(webhooks.DeliverySucceeded))
+			Expect(delivery.Attempts[0].StatusCode).To(Equal(http.StatusNoContent))
+			Expect(signatures).To(Receive(Equal(webhooks.TestEvent)))
+
+			status, result = send(http.MethodGet, "/api/v1/admin/webhooks/"+webhook.ID+"/deliveries", "")
+			Expect(status).To(Equal(http.StatusOK))
+			var deliveries struct {
+				Deliveries []webhooks.Delivery `json:"deliveries"`
+			}
+			Expect(json.Unmarshal(result, &deliveries)).To(Succeed())
+			Expect(deliveries.Deliveries).To(HaveLen(1))
+			Expect(deliveries.Deliveries[0].ID).To(Equal(delivery.ID))
+		})
+
+		It("should reject invalid webhooks", func() {
+			status, _ := send(http.MethodPost, "/api/v1/admin/webhooks", `{"url":"not a url"}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
+			status, _ = send(http.MethodPost, "/api/v1/admin/webhooks", `{"url":"http://example.com","events":["["]}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
+			status, _ = send(http.MethodPost, "/api/v1/admin/webhooks/wh-missing/test", "")
+			Expect(status).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("GET /api/v1/events", func() {
+		var baseURL string
+
+		BeforeEach(func() {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			go func() { _ = app.Listener(ln) }()
+			baseURL = "http://" + ln.Addr().String()
+			DeferCleanup(func() { _ = app.ShutdownWithTimeout(100 * time.Millisecond) })
+		})
+
+		type message struct {
+			event, id, data string
+		}
+
+		connect := func(path, lastEventID string) *bufio.Reader {
+			req, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
+			Expect(err).NotTo(HaveOccurred())
+			if lastEventID != "" {
+				req.Header.Set("Last-Event-ID", lastEventID)
+			}
+			client := &http.Client{Timeout: 5 * time.Second}
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(resp.Body.Close)
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/event-stream"))
+			return bufio.NewReader(resp.Body)
+		}
+
+		next := func(r *bufio.Reader) message {
+			var msg message
+			for {
+				line, err := r.ReadString('\n')
+				Expect(err).NotTo(HaveOccurred())
+				line = strings.TrimSuffix(line, "\n")
+				if line == "" {
+					return msg
+				}
+				field, value, _ := strings.Cut(line, ": ")
+				switch field {
+				case "event":
+					msg.event = value
+				case "id":
+					msg.id = value
+				case "data":
+					msg.data = value
+				}
+			}
+		}
+
+		// nextOfType skips messages of other types broadcast by other
+		// parts of the server
+		nextOfType := func(r *bufio.Reader, eventType string) message {
+			for {
+				msg := next(r)
+				if strings.Contains(msg.data, `"type":"`+eventType+`"`) || msg.event == eventType {
+					return msg
+				}
+			}
+		}
+
+		hub := watcher.DefaultHub
+
+		It("should send every event with its ID", func() {
+			stream := connect("/api/v1/events", "")
+			Expect(next(stream).event).To(Equal("hello"))
+
+			hub.BroadcastEvent("test:sse", map[string]string{"n": "1"})
+			msg := nextOfType(stream, "test:sse")
+			Expect(msg.id).To(Equal(strconv.FormatUint(hub.LastID(), 10)))
+			Expect(msg.data).To(ContainSubstring(`"id":` + msg.id))
+		})
+
+		It("should replay the events missed since Last-Event-ID", func() {
+			hub.BroadcastEvent("test:sse", map[string]string{"n": "1"})
+			first := hub.LastID()
+			hub.BroadcastEvent("test:sse", map[string]string{"n": "2"})
+			hub.BroadcastEvent("test:sse", map[string]string{"n": "3"})
+
+			stream := connect("/api/v1/events", strconv.FormatUint(first, 10))
+			Expect(next(stream).event).To(Equal("hello"))
+			Expect(nextOfType(stream, "test:sse").data).To(ContainSubstring(`"n":"2"`))
+			Expect(nextOfType(stream, "test:sse").data).To(ContainSubstring(`"n":"3"`))
+		})
+
+		It("should replay the events since ?since=", func() {
+			hub.BroadcastEvent("test:sse", map[string]string{"n": "1"})
+			since := hub.LastID()
+			hub.BroadcastEvent("test:sse", map[string]string{"n": "2"})
+
+			stream := connect("/api/v1/events?since="+strconv.FormatUint(since, 10), "")
+			next(stream)
+			msg := nextOfType(stream, "test:sse")
+			Expect(msg.data).To(ContainSubstring(`"n":"2"`))
+			Expect(msg.id).To(Equal(strconv.FormatUint(since+1, 10)))
+		})
+
+		It("should ask for a resync when the missed events are gone", func() {
+			hub.BroadcastEvent("test:sse", nil)
+			since := hub.LastID()
+			for i := 0; i < watcher.DefaultBufferSize+1; i++ {
+				hub.BroadcastEvent("test:sse", nil)
+			}
+
+			stream := connect("/api/v1/events?since="+strconv.FormatUint(since, 10), "")
+			next(stream)
+			msg := next(stream)
+			Expect(msg.event).To(Equal("resync-required"))
+			Expect(msg.id).To(Equal(strconv.FormatUint(hub.LastID(), 10)))
+			Expect(msg.data).To(ContainSubstring(`"lastEventId":` + strconv.FormatUint(since, 10)))
+
+			stream = connect("/api/v1/events", strconv.FormatUint(hub.LastID()+100, 10))
+			next(stream)
+			Expect(next(stream).event).To(Equal("resync-required"))
+		})
+
+		It("should reject an invalid event ID", func() {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events?since=yesterday", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should name events after their type", func() {
+			stream := connect("/api/v1/events?types=test:named", "")
+			next(stream)
+
+			hub.BroadcastEvent("test:named", nil)
+			msg := next(stream)
+			Expect(msg.event).To(Equal("test:named"))
+			Expect(msg.id).To(Equal(strconv.FormatUint(hub.LastID(), 10)))
+		})
+
+		It("should only send the events matching ?types= and ?datacenter=", func() {
+			stream := connect("/api/v1/events?types=test:filter:*,test:other&datacenter=dc-solna", "")
+			next(stream)
+
+			hub.BroadcastEvent("test:unmatched", map[string]interface{}{"datacenter": "dc-solna"})
+			hub.BroadcastEvent("test:filter:a", map[string]interface{}{"datacenter": "dc-kista"})
+			hub.BroadcastEvent("test:filter:b", map[string]interface{}{"datacenter": "dc-solna"})
+			hub.BroadcastEvent("test:filter:c", map[string]string{"msg": "no datacenter"})
+			hub.BroadcastEvent("test:other", map[string]interface{}{"datacenters": []string{"dc-kista", "dc-solna"}})
+
+			Expect(next(stream).event).To(Equal("test:filter:b"))
+			Expect(next(stream).event).To(Equal("test:other"))
+		})
+
+		It("should only replay the events matching the filter", func() {
+			hub.BroadcastEvent("test:replay", map[string]string{"n": "1"})
+			since := hub.LastID()
+			hub.BroadcastEvent("test:skipped", nil)
+			hub.BroadcastEvent("test:replay", map[string]string{"n": "2"})
+
+			stream := connect("/api/v1/events?types=test:replay&since="+strconv.FormatUint(since, 10), "")
+			next(stream)
+			msg := next(stream)
+			Expect(msg.event).To(Equal("test:replay"))
+			Expect(msg.data).To(ContainSubstring(`"n":"2"`))
+		})
+
+		It("should reject an invalid type pattern", func() {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events?types=vm:%5B", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should send heartbeats", func() {
+			server.SetHeartbeatIntervalForTesting(20 * time.Millisecond)
+			DeferCleanup(server.SetHeartbeatIntervalForTesting, time.Duration(0))
+
+			stream := connect("/api/v1/events?types=test:none", "")
+			next(stream)
+			line, err := stream.ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+			Expect(line).To(Equal(": heartbeat\n"))
+		})
+
+		It("should report the clients and their dropped events", func() {
+			connect("/api/v1/events?types=test:stats&datacenter=dc-solna", "")
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/events/stats", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var stats watcher.HubStats
+			Expect(json.NewDecoder(resp.Body).Decode(&stats)).To(Succeed())
+			Expect(stats.LastEventID).To(Equal(hub.LastID()))
+			Expect(stats.Clients).To(ContainElement(And(
+				HaveField("Filter", watcher.Filter{Types: []string{"test:stats"}, Datacenters: []string{"dc-solna"}}),
+				HaveField("Dropped", uint64(0)),
+			)))
+		})
+	})
+
+	Describe("GET /api/v1/ws", func() {
+		var wsURL string
+
+		BeforeEach(func() {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			go func() { _ = app.Listener(ln) }()
+			wsURL = "ws://" + ln.Addr().String()
+			DeferCleanup(func() { _ = app.ShutdownWithTimeout(100 * time.Millisecond) })
+		})
+
+		type frame struct {
+			Type      string                 `json:"type"`
+			Event     string                 `json:"event"`
+			EventID   uint64                 `json:"eventId"`
+			Data      map[string]interface{} `json:"data"`
+			RequestID string                 `json:"requestId"`
+			Status    int                    `json:"status"`
+			Result    map[string]interface{} `json:"result"`
+			Error     string                 `json:"error"`
+		}
+
+		dial := func(path string) *websocket.Conn {
+			conn, resp, err := websocket.DefaultDialer.Dial(wsURL+path, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+			DeferCleanup(conn.Close)
+
+			var hello frame
+			Expect(conn.ReadJSON(&hello)).To(Succeed())
+			Expect(hello.Type).To(Equal("hello"))
+			return conn
+		}
+
+		read := func(conn *websocket.Conn) frame {
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			var f frame
+			Expect(conn.ReadJSON(&f)).To(Succeed())
+			return f
+		}
+
+		// request sends a command and returns its reply, skipping events
+		request := func(conn *websocket.Conn, command string) frame {
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte(command))).To(Succeed())
+			for {
+				if f := read(conn); f.Type == "reply" {
+					return f
+				}
+			}
+		}
+
+		hub := watcher.DefaultHub
+
+		It("should send the events matching ?types=", func() {
+			conn := dial("/api/v1/ws?types=test:ws:*")
+
+			hub.BroadcastEvent("test:other", nil)
+			hub.BroadcastEvent("test:ws:a", map[string]string{"n": "1"})
+			f := read(conn)
+			Expect(f.Type).To(Equal("event"))
+			Expect(f.Event).To(Equal("test:ws:a"))
+			Expect(f.EventID).To(Equal(hub.LastID()))
+			Expect(f.Data).To(HaveKeyWithValue("payload", HaveKeyWithValue("n", "1")))
+		})
+
+		It("should replay the events since ?since=", func() {
+			hub.BroadcastEvent("test:ws", map[string]string{"n": "1"})
+			since := hub.LastID()
+			hub.BroadcastEvent("test:ws", map[string]string{"n": "2"})
+
+			conn := dial("/api/v1/ws?types=test:ws&since=" + strconv.FormatUint(since, 10))
+			f := read(conn)
+			Expect(f.EventID).To(Equal(since + 1))
+		})
+
+		It("should subscribe and unsubscribe", func() {
+			conn := dial("/api/v1/ws?types=test:ws:a")
+
+			reply := request(conn, `{"id":"1","type":"subscribe","params":{"types":["test:ws:b"]}}`)
+			Expect(reply.RequestID).To(Equal("1"))
+			Expect(reply.Status).To(Equal(http.StatusOK))
+			Expect(reply.Result).To(HaveKeyWithValue("types", ConsistOf("test:ws:a", "test:ws:b")))
+
+			reply = request(conn, `{"id":"2","type":"unsubscribe","params":{"types":["test:ws:a","test:ws:b"]}}`)
+			Expect(reply.Result).To(HaveKeyWithValue("types", BeEmpty()))
+			hub.BroadcastEvent("test:ws:a", nil)
+
+			request(conn, `{"id":"3","type":"subscribe","params":{"types":["test:ws:c"]}}`)
+			hub.BroadcastEvent("test:ws:c", nil)
+			Expect(read(conn).Event).To(Equal("test:ws:c"))
+		})
+
+		It("should send no events until the client subscribes", func() {
+			conn := dial("/api/v1/ws")
+
+			hub.BroadcastEvent("test:ws:a", nil)
+			reply := request(conn, `{"id":"1","type":"subscribe","params":{"types":["test:ws:b"]}}`)
+			Expect(reply.Result).To(HaveKeyWithValue("types", ConsistOf("test:ws:b")))
+
+			hub.BroadcastEvent("test:other", nil)
+			hub.BroadcastEvent("test:ws:b", nil)
+			Expect(read(conn).Event).To(Equal("test:ws:b"))
+		})
+
+		It("should reply to commands in the order they were sent", func() {
+			conn := dial("/api/v1/ws?types=test:none")
+
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"m1","type":"migrate","params":{"vmId":"vm-001","fromDC":"dc-test-1","toDC":"dc-test-2"}}`))).To(Succeed())
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"s1","type":"subscribe","params":{"types":["test:ws"]}}`))).To(Succeed())
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"x1","type":"reboot-everything"}`))).To(Succeed())
+
+			ids := []string{}
+			for len(ids) < 3 {
+				if f := read(conn); f.Type == "reply" {
+					ids = append(ids, f.RequestID)
+					if f.RequestID == "m1" {
+						settled(f.Result["operationId"].(string))
+					}
+				}
+			}
+			Expect(ids).To(Equal([]string{"m1", "s1", "x1"}))
+		})
+
+		It("should reply with a snapshot", func() {
+			conn := dial("/api/v1/ws")
+
+			reply := request(conn, `{"id":"snap","type":"snapshot"}`)
+			Expect(reply.RequestID).To(Equal("snap"))
+			Expect(reply.Status).To(Equal(http.StatusOK))
+			Expect(reply.Result).To(HaveKeyWithValue("lastEventId", BeNumerically("<=", hub.LastID())))
+			Expect(reply.Result).To(HaveKeyWithValue("datacenters", HaveKey("datacenters")))
+			Expect(reply.Result).To(HaveKey("migrations"))
+		})
+
+		It("should migrate a VM", func() {
+			conn := dial("/api/v1/ws?types=test:none")
+
+			reply := request(conn, `{"id":"m1","type":"migrate","params":{"vmId":"vm-001","fromDC":"dc-test-1","toDC":"dc-test-2"}}`)
+			Expect(reply.RequestID).To(Equal("m1"))
+			Expect(reply.Status).To(Equal(http.StatusAccepted))
+			Expect(reply.Result).To(HaveKeyWithValue("success", true))
+			settled(reply.Result["operationId"].(string))
+
+			reply = request(conn, `{"id":"m2","type":"migrate","params":{"vmId":"vm-001"}}`)
+			Expect(reply.RequestID).To(Equal("m2"))
+			Expect(reply.Status).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should run a power action", func() {
+			server.SetSimulatedPowerDelayForTesting(10 * time.Millisecond)
+			conn := dial("/api/v1/ws?types=test:none")
+
+			reply := request(conn, `{"id":"p1","type":"power","params":{"vmId":"vm-002","action":"start"}}`)
+			Expect(reply.RequestID).To(Equal("p1"))
+			Expect(reply.Status).To(Equal(http.StatusAccepted))
+
+			reply = request(conn, `{"id":"p2","type":"power","params":{"vmId":"vm-404","action":"start"}}`)
+			Expect(reply.Status).To(Equal(http.StatusNotFound))
+
+			Eventually(func() string {
+				vm, err := mockStore.GetVM("dc-test-2", "vm-002")
+				Expect(err).NotTo(HaveOccurred())
+				return vm.Status
+			}).Should(Equal("running"))
+		})
+
+		It("should reject unknown commands and invalid messages", func() {
+			conn := dial("/api/v1/ws?types=test:none")
+
+			reply := request(conn, `{"id":"x","type":"reboot-everything"}`)
+			Expect(reply.RequestID).To(Equal("x"))
+			Expect(reply.Status).To(Equal(http.StatusBadRequest))
+			Expect(reply.Error).To(ContainSubstring("unknown command"))
+
+			reply = request(conn, `not json`)
+			Expect(reply.Status).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should require a WebSocket upgrade", func() {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/ws", nil)
+			resp, err := app.Test(req)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(result["status"]).To(Equal("healthy"))
-			Expect(result["service"]).To(Equal("backend-api"))
+			Expect(resp.StatusCode).To(Equal(http.StatusUpgradeRequired))
 		})
 	})
 
-	Describe("GET /api/v1/datacenters", func() {
-		It("should return all datacenters", func() {
-			req := httptest.NewRequest(http.MethodGet, "/api/v1/datacenters", nil)
+	Describe("POST /api/v1/bus/events", func() {
+		post := func(token, body string) *http.Response {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/bus/events", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(eventbus.HeaderToken, token)
 			resp, err := app.Test(req)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			return resp
+		}
+		batch := `{"origin":"replica-b","boot":"1","events":[{"seq":1,"type":"vm:added","payload":{"datacenter":"dc-solna"}}]}`
 
-			var result models.DatacenterCollection
-			err = json.NewDecoder(resp.Body).Decode(&result)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(len(result.Datacenters)).To(Equal(2))
-			Expect(result.Datacenters[0].ID).To(Equal("dc-test-1"))
-			Expect(result.Datacenters[1].ID).To(Equal("dc-test-2"))
+		It("should not be found unless events are shared", func() {
+			Expect(post("s3cret", batch).StatusCode).To(Equal(http.StatusNotFound))
 		})
 
-		It("should handle store errors gracefully", func() {
-			mockStore.SetShouldError(true, "database connection failed")
+		Context("with a bus", func() {
+			var bus *eventbus.Bus
 
-			req := httptest.NewRequest(http.MethodGet, "/api/v1/datacenters", nil)
-			resp, err := app.Test(req)
-			Expect(err).NotTo(HaveOccurred())
-			// Even with store errors, GetDatacenters shouldn't fail in our mock
-			// but would return empty data
-			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			BeforeEach(func() {
+				options := eventbus.DefaultOptions()
+				options.Self = "replica-a"
+				options.Secret = "s3cret"
+				bus = eventbus.New(options)
+				server.SetEventBusForTesting(bus)
+				DeferCleanup(server.SetEventBusForTesting, (*eventbus.Bus)(nil))
+			})
+
+			It("should broadcast the events of a peer", func() {
+				ch := bus.Register()
+				DeferCleanup(bus.Unregister, ch)
+
+				Expect(post("s3cret", batch).StatusCode).To(Equal(http.StatusNoContent))
+				var event watcher.Event
+				Expect(ch).To(Receive(&event))
+				Expect(event.Type).To(Equal("vm:added"))
+				Expect(event.Origin).To(Equal("replica-b"))
+				Expect(event.Datacenters).To(Equal([]string{"dc-solna"}))
+			})
+
+			It("should reject a wrong token", func() {
+				Expect(post("wrong", batch).StatusCode).To(Equal(http.StatusUnauthorized))
+				Expect(bus.LastID()).To(BeZero())
+			})
+
+			It("should reject an invalid batch", func() {
+				Expect(post("s3cret", `{"events":[]}`).StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(post("s3cret", `not json`).StatusCode).To(Equal(http.StatusBadRequest))
+			})
 		})
 	})
 
-	Describe("GET /api/v1/status", func() {
-		It("should return correct status information", func() {
-			req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+	Describe("/api/v1/admin/rebalancer", func() {
+		rebalancer := func(body string) (int, map[string]interface{}) {
+			method := http.MethodGet
+			if body != "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/api/v1/admin/rebalancer", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
 			resp, err := app.Test(req)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(resp.StatusCode).To(Equal(http.StatusOK))
 
 			var result map[string]interface{}
-			err = json.NewDecoder(resp.Body).Decode(&result)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(result["datacenters"]).To(Equal(float64(2)))
-			Expect(result["total_vms"]).To(Equal(float64(2)))
-			Expect(result["running_vms"]).To(Equal(float64(1)))
-			Expect(result["stopped_vms"]).To(Equal(float64(1)))
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			return resp.StatusCode, result
+		}
+
+		AfterEach(func() {
+			rebalancer(`{"action":"stop"}`)
 		})
-	})
 
-	Describe("POST /api/v1/migrate", func() {
-		Context("with valid migration request", func() {
-			It("should migrate VM successfully", func() {
-				migrateReq := models.MigrateRequest{
-					VMID:   "vm-001",
-					FromDC: "dc-test-1",
-					ToDC:   "dc-test-2",
-				}
-				body, _ := json.Marshal(migrateReq)
+		It("should report a stopped rebalancer", func() {
+			status, result := rebalancer("")
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(result["running"]).To(BeFalse())
+			Expect(result["state"]).To(Equal("stopped"))
+		})
 
-				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
-				req.Header.Set("Content-Type", "application/json")
-				resp, err := app.Test(req)
+		It("should start, rebalance and stop", func() {
+			for _, id := range []string{"vm-003", "vm-004"} {
+				_, err := mockStore.AddVM("dc-test-1", models.VM{ID: id, Name: "test-" + id, Status: "running", CPU: 1, Memory: 1024})
 				Expect(err).NotTo(HaveOccurred())
-				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			}
 
-				var result models.MigrateResponse
-				err = json.NewDecoder(resp.Body).Decode(&result)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(result.Success).To(BeTrue())
-				Expect(result.VM.ID).To(Equal("vm-001"))
-			})
+			status, result := rebalancer(`{"action":"start","interval":"1h","cooldown":"5m"}`)
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(result["running"]).To(BeTrue())
+			Expect(result["config"]).To(HaveKeyWithValue("interval", "1h0m0s"))
+
+			Eventually(func() interface{} {
+				_, result := rebalancer("")
+				return result["history"]
+			}).Should(HaveLen(1))
+			Expect(mockStore.GetDatacenters().Datacenters[1].VMs).To(HaveLen(2))
+
+			status, result = rebalancer(`{"action":"stop"}`)
+			Expect(status).To(Equal(http.StatusOK))
+			Expect(result["running"]).To(BeFalse())
 		})
 
-		Context("with invalid migration request", func() {
-			It("should return error for missing fields", func() {
-				migrateReq := models.MigrateRequest{
-					VMID: "vm-001",
-					// Missing FromDC and ToDC
-				}
-				body, _ := json.Marshal(migrateReq)
+		It("should reject invalid requests", func() {
+			status, _ := rebalancer(`{"action":"pause"}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
 
-				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
-				req.Header.Set("Content-Type", "application/json")
-				resp, err := app.Test(req)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			status, _ = rebalancer(`{"action":"start","interval":"soon"}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
 
-				var result models.MigrateResponse
-				err = json.NewDecoder(resp.Body).Decode(&result)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(result.Success).To(BeFalse())
-				Expect(result.Message).To(ContainSubstring("required"))
-			})
+			status, _ = rebalancer(`{"action":"start","blackouts":["25:00-26:00"]}`)
+			Expect(status).To(Equal(http.StatusBadRequest))
+		})
+	})
 
-			It("should return error for same source and target", func() {
-				migrateReq := models.MigrateRequest{
-					VMID:   "vm-001",
-					FromDC: "dc-test-1",
-					ToDC:   "dc-test-1", // Same as fromDC
-				}
-				body, _ := json.Marshal(migrateReq)
+	Describe("POST /api/v1/vms/:id/:action", func() {
+		BeforeEach(func() {
+			server.SetSimulatedPowerDelayForTesting(10 * time.Millisecond)
+		})
 
-				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", bytes.NewReader(body))
-				req.Header.Set("Content-Type", "application/json")
-				resp, err := app.Test(req)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		vmStatus := func(dcID, vmID string) string {
+			vm, err := mockStore.GetVM(dcID, vmID)
+			Expect(err).NotTo(HaveOccurred())
+			return vm.Status
+		}
 
-				var result models.MigrateResponse
-				err = json.NewDecoder(resp.Body).Decode(&result)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(result.Success).To(BeFalse())
-				Expect(result.Message).To(ContainSubstring("cannot be the same"))
-			})
+		It("should start a stopped VM through the starting state", func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/vms/vm-002/start", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
 
-			It("should return error for invalid JSON", func() {
-				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrate", strings.NewReader("invalid json"))
-				req.Header.Set("Content-Type", "application/json")
-				resp, err := app.Test(req)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			var result map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&result)).To(Succeed())
+			Expect(result["status"]).To(Equal("starting"))
+			Eventually(func() string { return vmStatus("dc-test-2", "vm-002") }).Should(Equal("running"))
+		})
 
-				var result models.MigrateResponse
-				err = json.NewDecoder(resp.Body).Decode(&result)
+		It("should restart a running VM back to running", func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/vms/vm-001/restart", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+			Expect(vmStatus("dc-test-1", "vm-001")).To(Equal("stopping"))
+
+			Eventually(func() string { return vmStatus("dc-test-1", "vm-001") }).Should(Equal("running"))
+		})
+
+		It("should pause and unpause a running VM", func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/vms/vm-001/pause", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+			Eventually(func() string { return vmStatus("dc-test-1", "vm-001") }).Should(Equal("paused"))
+
+			Eventually(func() int {
+				resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/api/v1/vms/vm-001/unpause", nil))
 				Expect(err).NotTo(HaveOccurred())
-				Expect(result.Success).To(BeFalse())
-				Expect(result.Message).To(Equal("Invalid request body"))
-			})
+				return resp.StatusCode
+			}).Should(Equal(http.StatusAccepted))
+			Expect(vmStatus("dc-test-1", "vm-001")).To(Equal("running"))
+		})
+
+		It("should reject actions that don't apply to the current state", func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/vms/vm-002/stop", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+		})
+
+		It("should reject unknown actions", func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/vms/vm-001/explode", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should return 404 for unknown VMs", func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/vms/vm-999/start", nil)
+			resp, err := app.Test(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
 		})
 	})
 
-	Describe("GET /api/v1/migrate", func() {
-		Context("auto migration", func() {
-			It("should auto migrate a VM", func() {
-				req := httptest.NewRequest(http.MethodGet, "/api/v1/migrate", nil)
-				resp, err := app.Test(req)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	Describe("GET /api/v1/clusters/:name/nodes", func() {
+		It("should list the nodes of a cluster with their VMs", func() {
+			_, err := mockStore.AddVM("dc-test-1", models.VM{ID: "vm-placed", Name: "placed-vm", Status: "running", Cluster: "alpha", NodeName: "alpha-worker-2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mockStore.UpsertNode(models.Node{Name: "alpha-worker-2", Cluster: "alpha", DatacenterID: "dc-test-1", Ready: true, Schedulable: true, Zone: "zone-2"})).To(Succeed())
+			Expect(mockStore.UpsertNode(models.Node{Name: "alpha-worker-1", Cluster: "alpha", DatacenterID: "dc-test-1", Ready: true})).To(Succeed())
+			Expect(mockStore.UpsertNode(models.Node{Name: "beta-worker-1", Cluster: "beta", DatacenterID: "dc-test-2"})).To(Succeed())
 
-				var result map[string]interface{}
-				err = json.NewDecoder(resp.Body).Decode(&result)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(result["ok"]).To(BeTrue())
-				Expect(result["migrated"]).To(BeTrue())
-				Expect(result["vmId"]).To(Not(BeEmpty()))
-			})
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/clusters/alpha/nodes", nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
 
-			It("should support dry-run mode", func() {
-				req := httptest.NewRequest(http.MethodGet, "/api/v1/migrate?dry-run=1", nil)
-				resp, err := app.Test(req)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			var nodes []models.Node
+			Expect(json.NewDecoder(resp.Body).Decode(&nodes)).To(Succeed())
+			Expect(nodes).To(HaveLen(2))
+			Expect(nodes[0].Name).To(Equal("alpha-worker-1"))
+			Expect(nodes[0].VMs).To(BeEmpty())
+			Expect(nodes[1].Zone).To(Equal("zone-2"))
+			Expect(nodes[1].VMs).To(ConsistOf("placed-vm"))
+		})
 
-				var result map[string]interface{}
-				err = json.NewDecoder(resp.Body).Decode(&result)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(result["ok"]).To(BeTrue())
-				Expect(result["migrated"]).To(BeTrue())
-				Expect(result["reason"]).To(ContainSubstring("Dry run"))
-			})
+		It("should return 404 for unknown clusters", func() {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/v1/clusters/nowhere/nodes", nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
 		})
 	})
 
@@ -411,6 +1940,100 @@ var _ = Describe("Server API Handlers", func() {
 			})
 		})
 
+		Describe("GET /api/v1/migrations/logical", func() {
+			It("should pair outgoing and incoming migrations by migration ID", func() {
+				start := time.Now().Add(-2 * time.Minute)
+				end := time.Now().Add(-1 * time.Minute)
+				mockStore.AddMigration(models.Migration{
+					ID: "out-1", VMName: "test-vm-1", Namespace: "demo", Cluster: "coruscant", DatacenterID: "dc-test-1",
+					Direction: "outgoing", MigrationID: "mig-abc", Phase: "Succeeded", Completed: true,
+					StartTime: &start, EndTime: &end, CreatedAt: start,
+				})
+				mockStore.AddMigration(models.Migration{
+					ID: "in-1", VMName: "test-vm-1", Namespace: "demo", Cluster: "vulcan", DatacenterID: "dc-test-2",
+					Direction: "incoming", MigrationID: "mig-abc", Phase: "Succeeded", Completed: true,
+					StartTime: &start, EndTime: &end, CreatedAt: start,
+				})
+
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/migrations/logical", nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var result []models.LogicalMigration
+				err = json.NewDecoder(resp.Body).Decode(&result)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(result)).To(Equal(1))
+				Expect(result[0].MigrationID).To(Equal("mig-abc"))
+				Expect(result[0].SourceCluster).To(Equal("coruscant"))
+				Expect(result[0].TargetCluster).To(Equal("vulcan"))
+				Expect(result[0].SourceDatacenterID).To(Equal("dc-test-1"))
+				Expect(result[0].TargetDatacenterID).To(Equal("dc-test-2"))
+				Expect(result[0].Phase).To(Equal("Succeeded"))
+				Expect(result[0].Completed).To(BeTrue())
+				Expect(result[0].DurationSeconds).To(BeNumerically("~", 60, 1))
+			})
+		})
+
+		Describe("POST /api/v1/migrations/:id/abort", func() {
+			It("should mark a running migration aborted", func() {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrations/migration-1/abort", nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				migration, err := mockStore.GetMigration("migration-1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(migration.Phase).To(Equal("Aborted"))
+				Expect(migration.Completed).To(BeTrue())
+				Expect(migration.AbortRequested).To(BeTrue())
+				Expect(migration.EndTime).NotTo(BeNil())
+			})
+
+			It("should abort both halves of a cross-cluster migration", func() {
+				mockStore.AddMigration(models.Migration{ID: "out-1", Cluster: "coruscant", Direction: "outgoing", MigrationID: "mig-abc", Phase: "Running"})
+				mockStore.AddMigration(models.Migration{ID: "in-1", Cluster: "vulcan", Direction: "incoming", MigrationID: "mig-abc", Phase: "Running"})
+
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrations/mig-abc/abort", nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				for _, id := range []string{"out-1", "in-1"} {
+					migration, err := mockStore.GetMigration(id)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(migration.Phase).To(Equal("Aborted"))
+				}
+			})
+
+			It("should refuse to abort a finished migration", func() {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/migrations/migration-2/abort", nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+			})
+		})
+
+		Describe("DELETE /api/v1/migrations/:id", func() {
+			It("should abort the migration", func() {
+				req := httptest.NewRequest(http.MethodDelete, "/api/v1/migrations/migration-1", nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				migration, err := mockStore.GetMigration("migration-1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(migration.Phase).To(Equal("Aborted"))
+			})
+
+			It("should return 404 for non-existent migration", func() {
+				req := httptest.NewRequest(http.MethodDelete, "/api/v1/migrations/non-existent", nil)
+				resp, err := app.Test(req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+
 		Describe("GET /api/v1/migrations/datacenter/:dcId", func() {
 			It("should return migrations for specific datacenter", func() {
 				req := httptest.NewRequest(http.MethodGet, "/api/v1/migrations/datacenter/dc-test-1", nil)
@@ -489,9 +2112,27 @@ func setupRoutes(app *fiber.App) {
 
 	// Use the server package handlers (we'll need to expose them for testing)
 	api.Get("/datacenters", server.GetDatacentersHandler)
+	api.Get("/datacenters/:id/utilization", server.GetDatacenterUtilizationHandler)
 	api.Get("/status", server.GetStatusHandler)
+	api.Get("/events", server.EventsHandler)
+	api.Get("/ws", server.WebSocketHandler)
+	api.Post("/bus/events", server.BusEventsHandler)
 	api.Post("/migrate", server.MigrateVMHandler)
+	api.Post("/migrate/bulk", server.BulkMigrateHandler)
 	api.Get("/migrate", server.AutoMigrateVMHandler)
+	api.Get("/placement/strategies", server.GetPlacementStrategiesHandler)
+	api.Get("/operations", server.GetOperationsHandler)
+	api.Get("/operations/:id", server.GetOperationHandler)
+	api.Post("/operations/:id/cancel", server.CancelOperationHandler)
+	api.Post("/schedules", server.CreateScheduleHandler)
+	api.Get("/schedules", server.GetSchedulesHandler)
+	api.Get("/schedules/:id", server.GetScheduleHandler)
+	api.Post("/schedules/:id/pause", server.PauseScheduleHandler)
+	api.Post("/schedules/:id/resume", server.ResumeScheduleHandler)
+	api.Post("/schedules/:id/run", server.RunScheduleHandler)
+	api.Delete("/schedules/:id", server.DeleteScheduleHandler)
+	api.Post("/vms/:id/:action", server.VMPowerHandler)
+	api.Get("/clusters/:name/nodes", server.ClusterNodesHandler)
 
 	// Admin routes
 	admin := api.Group("/admin")
@@ -500,12 +2141,29 @@ func setupRoutes(app *fiber.App) {
 	admin.Patch("/datacenters/:dcId/vms/:vmId", server.UpdateVMHandler)
 	admin.Post("/datacenters/:dcId/vms", server.AddVMHandler)
 	admin.Delete("/datacenters/:dcId/vms/:vmId", server.RemoveVMHandler)
+	admin.Post("/datacenters/:id/maintenance", server.DatacenterMaintenanceHandler)
+	admin.Post("/datacenters/:id/fail", server.FailDatacenterHandler)
+	admin.Post("/datacenters/:id/recover", server.RecoverDatacenterHandler)
+	admin.Get("/datacenters/:id/failover", server.GetFailoverHandler)
+	admin.Get("/rebalancer", server.GetRebalancerHandler)
+	admin.Post("/rebalancer", server.RebalancerHandler)
+	admin.Get("/webhooks", server.GetWebhooksHandler)
+	admin.Post("/webhooks", server.CreateWebhookHandler)
+	admin.Get("/webhooks/:id", server.GetWebhookHandler)
+	admin.Patch("/webhooks/:id", server.UpdateWebhookHandler)
+	admin.Delete("/webhooks/:id", server.DeleteWebhookHandler)
+	admin.Get("/webhooks/:id/deliveries", server.GetWebhookDeliveriesHandler)
+	admin.Post("/webhooks/:id/test", server.TestWebhookHandler)
+	admin.Get("/events/stats", server.EventStatsHandler)
 
 	// Migration tracking endpoints
 	api.Get("/migrations", server.GetAllMigrationsHandler)
 	api.Get("/migrations/active", server.GetActiveMigrationsHandler)
+	api.Get("/migrations/logical", server.GetLogicalMigrationsHandler)
 	api.Get("/migrations/datacenter/:dcId", server.GetMigrationsByDatacenterHandler)
 	api.Get("/migrations/vm/:vmName", server.GetMigrationsByVMHandler)
 	api.Get("/migrations/direction/:direction", server.GetMigrationsByDirectionHandler)
 	api.Get("/migrations/:id", server.GetMigrationHandler)
+	api.Delete("/migrations/:id", server.AbortMigrationHandler)
+	api.Post("/migrations/:id/abort", server.AbortMigrationHandler)
 }
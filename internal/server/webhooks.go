@@ -0,0 +1,146 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/webhooks"
+)
+
+// webhookStore gives the dispatcher the data store in use, which tests
+// replace
+type webhookStore struct{}
+
+func (webhookStore) SaveWebhook(webhook models.Webhook) error {
+	return dataStore.SaveWebhook(webhook)
+}
+
+func (webhookStore) GetWebhooks() ([]models.Webhook, error) {
+	return dataStore.GetWebhooks()
+}
+
+func (webhookStore) RemoveWebhook(id string) error {
+	return dataStore.RemoveWebhook(id)
+}
+
+// webhookDispatcher delivers the hub's events to the webhooks; it is
+// stopped until StartWebhooks is called
+var webhookDispatcher = webhooks.New(webhookStore{}, webhooks.DefaultOptions())
+
+// StartWebhooks starts delivering the events of the hub to the webhooks
+func StartWebhooks() {
+	webhookDispatcher.Start(watcher.DefaultHub)
+}
+
+// redactWebhook leaves out the secret, which is only shown on creation
+func redactWebhook(webhook models.Webhook) models.Webhook {
+	webhook.Secret = ""
+	return webhook
+}
+
+// CreateWebhookHandler registers a webhook. The response is the only one
+// that shows the secret, which is generated when left out.
+func CreateWebhookHandler(c *fiber.Ctx) error {
+	webhook := models.Webhook{Enabled: true}
+	if err := json.Unmarshal(c.Body(), &webhook); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body: " + err.Error()})
+	}
+	if err := webhooks.Validate(webhook); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	created, err := webhookDispatcher.Create(webhook)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(created)
+}
+
+// GetWebhooksHandler lists the webhooks, oldest first
+func GetWebhooksHandler(c *fiber.Ctx) error {
+	list, err := webhookDispatcher.List()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	redacted := make([]models.Webhook, 0, len(list))
+	for _, webhook := range list {
+		redacted = append(redacted, redactWebhook(webhook))
+	}
+	return c.JSON(fiber.Map{"webhooks": redacted})
+}
+
+// GetWebhookHandler returns a webhook
+func GetWebhookHandler(c *fiber.Ctx) error {
+	webhook, err := webhookDispatcher.Get(c.Params("id"))
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(redactWebhook(webhook))
+}
+
+// UpdateWebhookHandler changes the name, url, events, secret or enabled
+// flag of a webhook; fields left out keep their values
+func UpdateWebhookHandler(c *fiber.Ctx) error {
+	webhook, err := webhookDispatcher.Get(c.Params("id"))
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, createdAt := webhook.ID, webhook.CreatedAt
+	if err := json.Unmarshal(c.Body(), &webhook); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body: " + err.Error()})
+	}
+	webhook.ID, webhook.CreatedAt = id, createdAt
+	if err := webhooks.Validate(webhook); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updated, err := webhookDispatcher.Update(webhook)
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(redactWebhook(updated))
+}
+
+// DeleteWebhookHandler removes a webhook and its delivery log
+func DeleteWebhookHandler(c *fiber.Ctx) error {
+	if err := webhookDispatcher.Delete(c.Params("id")); err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(204)
+}
+
+// GetWebhookDeliveriesHandler returns the recent deliveries of a webhook
+// with their attempts, newest first
+func GetWebhookDeliveriesHandler(c *fiber.Ctx) error {
+	deliveries, err := webhookDispatcher.Deliveries(c.Params("id"))
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"deliveries": deliveries})
+}
+
+// TestWebhookHandler sends a webhook:test event to a webhook once and
+// returns the delivery
+func TestWebhookHandler(c *fiber.Ctx) error {
+	delivery, err := webhookDispatcher.Test(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(delivery)
+}
+
+// webhookErrorStatus maps a dispatcher error to an HTTP status
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, webhooks.ErrNotFound):
+		return 404
+	case errors.Is(err, webhooks.ErrEmptySecret):
+		return 400
+	default:
+		return 500
+	}
+}
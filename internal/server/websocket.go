@@ -0,0 +1,405 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gorilla/websocket"
+	"github.com/valyala/fasthttp"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// wsReadLimit is the largest client message a WebSocket accepts
+const wsReadLimit = 64 * 1024
+
+// wsMaxQueued is how many commands of a client may wait for the ones
+// before them
+const wsMaxQueued = 16
+
+// wsUpgrader accepts WebSockets from every origin, as the CORS settings
+// do for the rest of the API
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsCommand is a message from a WebSocket client. Replies carry its ID
+// as requestId.
+type wsCommand struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"` // subscribe, unsubscribe, snapshot, migrate or power
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// wsTopics are the params of subscribe and unsubscribe
+type wsTopics struct {
+	Types       []string `json:"types"`
+	Datacenters []string `json:"datacenters"`
+}
+
+// wsPowerParams are the params of power
+type wsPowerParams struct {
+	VMID       string `json:"vmId"`
+	Action     string `json:"action"`
+	Datacenter string `json:"datacenter,omitempty"`
+}
+
+// wsFrame is a message to a WebSocket client: a hub event, hello,
+// resync-required, or the reply to a command
+type wsFrame struct {
+	Type      string          `json:"type"`
+	Event     string          `json:"event,omitempty"` // Type of a hub event
+	EventID   uint64          `json:"eventId,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	Status    int             `json:"status,omitempty"`
+	Result    interface{}     `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// WebSocketHandler carries the hub's events over a WebSocket and takes
+// commands from the client. It resumes and filters like EventsHandler, and
+// the client can change its topics, ask for a snapshot, and migrate VMs or
+// run power actions; migrations and power actions go through the same
+// handlers, with the headers of the upgrade request, as the REST API.
+func WebSocketHandler(c *fiber.Ctx) error {
+	r := upgradeRequest(c)
+	if !websocket.IsWebSocketUpgrade(r) {
+		c.Set("Upgrade", "websocket")
+		return c.Status(426).JSON(fiber.Map{"error": "expected a WebSocket upgrade"})
+	}
+	sub, err := parseSubscription(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	// Without ?types= the client picks its events with subscribe
+	if sub.filter.Types == nil {
+		sub.filter.Types = []string{}
+	}
+
+	session := &wsSession{
+		hub:     watcher.DefaultHub,
+		handler: c.App().Server().Handler,
+		remote:  c.Context().RemoteAddr(),
+	}
+	c.Request().Header.CopyTo(&session.header)
+	for _, key := range []string{"Connection", "Upgrade", "Sec-WebSocket-Key", "Sec-WebSocket-Version", "Sec-WebSocket-Extensions", "Sec-WebSocket-Protocol"} {
+		session.header.Del(key)
+	}
+
+	c.Context().HijackSetNoResponse(true)
+	c.Context().Hijack(func(netConn net.Conn) {
+		conn, err := wsUpgrader.Upgrade(&hijackedResponse{conn: netConn, header: http.Header{}}, r, nil)
+		if err != nil {
+			_ = netConn.Close()
+			return
+		}
+		session.conn = conn
+		session.run(sub)
+	})
+	return nil
+}
+
+// upgradeRequest converts the request for the upgrader, which needs it
+// after fasthttp has released the request
+func upgradeRequest(c *fiber.Ctx) *http.Request {
+	r := &http.Request{
+		Method:     c.Method(),
+		URL:        &url.URL{Path: c.Path(), RawQuery: string(c.Context().QueryArgs().QueryString())},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Host:       c.Hostname(),
+	}
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		r.Header.Add(string(key), string(value))
+	})
+	return r
+}
+
+// hijackedResponse lets the upgrader answer on a connection fasthttp
+// handed over
+type hijackedResponse struct {
+	conn   net.Conn
+	header http.Header
+}
+
+func (w *hijackedResponse) Header() http.Header {
+	return w.header
+}
+
+func (w *hijackedResponse) WriteHeader(status int) {
+	fmt.Fprintf(w.conn, "HTTP/1.1 %d %s\r\nConnection: close\r\n", status, http.StatusText(status))
+	_ = w.header.Write(w.conn)
+	fmt.Fprint(w.conn, "\r\n")
+}
+
+func (w *hijackedResponse) Write(b []byte) (int, error) {
+	return w.conn.Write(b)
+}
+
+func (w *hijackedResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.conn, bufio.NewReadWriter(bufio.NewReader(w.conn), bufio.NewWriter(w.conn)), nil
+}
+
+// wsSession is one WebSocket client
+type wsSession struct {
+	conn    *websocket.Conn
+	hub     watcher.EventBus
+	handler fasthttp.RequestHandler // Runs the migrate and power commands
+	header  fasthttp.RequestHeader  // Headers of the upgrade request
+	remote  net.Addr
+
+	writeMu sync.Mutex // Serializes writes to conn
+
+	streamMu sync.Mutex // Guards stream, which subscribe changes
+	stream   *eventStream
+}
+
+// run streams events and reads commands until the client goes away
+func (s *wsSession) run(sub subscription) {
+	defer s.conn.Close()
+
+	ch, missed, ok := sub.register(s.hub)
+	defer s.hub.Unregister(ch)
+	s.stream = &eventStream{emit: s.emitEvent, hub: s.hub, ch: ch, filter: sub.filter, lastID: sub.lastID}
+
+	if err := s.write(wsFrame{Type: "hello", Data: json.RawMessage(`{"msg":"connected"}`)}); err != nil {
+		return
+	}
+	s.streamMu.Lock()
+	err := s.stream.replay(missed, ok)
+	s.streamMu.Unlock()
+	if err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.streamEvents(ch, done)
+
+	// Clients answer the heartbeat pings; one that misses two is gone
+	s.conn.SetReadLimit(wsReadLimit)
+	deadline := func() error { return s.conn.SetReadDeadline(time.Now().Add(2 * heartbeatInterval())) }
+	_ = deadline()
+	s.conn.SetPongHandler(func(string) error { return deadline() })
+
+	// Commands run one at a time in the order they arrive, while reading
+	// goes on to answer pings
+	commands := make(chan wsCommand, wsMaxQueued)
+	defer close(commands)
+	go func() {
+		for cmd := range commands {
+			s.handle(cmd)
+		}
+	}()
+
+	for {
+		_, message, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd wsCommand
+		if err := json.Unmarshal(message, &cmd); err != nil {
+			_ = s.reply("", 400, nil, "invalid message: "+err.Error())
+			continue
+		}
+		select {
+		case commands <- cmd:
+		default:
+			_ = s.reply(cmd.ID, 429, nil, fmt.Sprintf("more than %d commands waiting", wsMaxQueued))
+		}
+	}
+}
+
+// streamEvents writes the hub's events and heartbeat pings until done is
+// closed or a write fails
+func (s *wsSession) streamEvents(ch chan watcher.Event, done chan struct{}) {
+	heartbeat := time.NewTicker(heartbeatInterval())
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case event := <-ch:
+			s.streamMu.Lock()
+			err = s.stream.send(event)
+			s.streamMu.Unlock()
+		case <-heartbeat.C:
+			s.writeMu.Lock()
+			err = s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+			s.writeMu.Unlock()
+			if err == nil {
+				s.streamMu.Lock()
+				err = s.stream.catchUp()
+				s.streamMu.Unlock()
+			}
+		}
+		if err != nil {
+			_ = s.conn.Close()
+			return
+		}
+	}
+}
+
+// handle runs a command and replies to it
+func (s *wsSession) handle(cmd wsCommand) {
+	var err error
+	switch cmd.Type {
+	case "subscribe", "unsubscribe":
+		err = s.changeTopics(cmd)
+	case "snapshot":
+		err = s.snapshot(cmd)
+	case "migrate":
+		status, result := s.call(http.MethodPost, "/api/v1/migrate", cmd.Params)
+		err = s.reply(cmd.ID, status, result, "")
+	case "power":
+		err = s.power(cmd)
+	default:
+		err = s.reply(cmd.ID, 400, nil, fmt.Sprintf("unknown command %q", cmd.Type))
+	}
+	if err != nil {
+		log.Printf("WebSocket reply to %s failed: %v", s.remote, err)
+	}
+}
+
+// changeTopics adds or removes event types and datacenters. A client
+// without types receives no events; without datacenters it receives the
+// events of all of them.
+func (s *wsSession) changeTopics(cmd wsCommand) error {
+	var topics wsTopics
+	if err := json.Unmarshal(cmd.Params, &topics); err != nil {
+		return s.reply(cmd.ID, 400, nil, "invalid params: "+err.Error())
+	}
+	if err := (watcher.Filter{Types: topics.Types}).Validate(); err != nil {
+		return s.reply(cmd.ID, 400, nil, err.Error())
+	}
+
+	s.streamMu.Lock()
+	filter := s.stream.filter
+	if cmd.Type == "subscribe" {
+		filter.Types = addTopics(filter.Types, topics.Types)
+		filter.Datacenters = addTopics(filter.Datacenters, topics.Datacenters)
+	} else {
+		filter.Types = removeTopics(filter.Types, topics.Types)
+		filter.Datacenters = removeTopics(filter.Datacenters, topics.Datacenters)
+	}
+	s.stream.filter = filter
+	s.hub.SetFilter(s.stream.ch, filter)
+	s.streamMu.Unlock()
+
+	return s.reply(cmd.ID, 200, filter, "")
+}
+
+// snapshot replies with the datacenters and migrations and the ID of the
+// latest event before them, so the client can apply later events on top
+func (s *wsSession) snapshot(cmd wsCommand) error {
+	lastID := s.hub.LastID()
+	migrations, err := dataStore.GetAllMigrations()
+	if err != nil {
+		return s.reply(cmd.ID, 500, nil, err.Error())
+	}
+	return s.reply(cmd.ID, 200, map[string]interface{}{
+		"lastEventId": lastID,
+		"datacenters": datacenterView(),
+		"migrations":  migrations,
+	}, "")
+}
+
+// power runs a power action through POST /api/v1/vms/:id/:action
+func (s *wsSession) power(cmd wsCommand) error {
+	var params wsPowerParams
+	if err := json.Unmarshal(cmd.Params, &params); err != nil {
+		return s.reply(cmd.ID, 400, nil, "invalid params: "+err.Error())
+	}
+	if params.VMID == "" || params.Action == "" {
+		return s.reply(cmd.ID, 400, nil, "vmId and action are required")
+	}
+	uri := "/api/v1/vms/" + url.PathEscape(params.VMID) + "/" + url.PathEscape(params.Action)
+	if params.Datacenter != "" {
+		uri += "?datacenter=" + url.QueryEscape(params.Datacenter)
+	}
+	status, result := s.call(http.MethodPost, uri, nil)
+	return s.reply(cmd.ID, status, result, "")
+}
+
+// call runs a request through the app with the headers of the upgrade
+// request and returns the status and JSON body of the response
+func (s *wsSession) call(method, uri string, body []byte) (int, json.RawMessage) {
+	var req fasthttp.Request
+	s.header.CopyTo(&req.Header)
+	req.Header.SetMethod(method)
+	req.SetRequestURI(uri)
+	req.Header.SetContentType("application/json")
+	req.SetBody(body)
+
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&req, s.remote, nil)
+	s.handler(&ctx)
+
+	result := json.RawMessage(ctx.Response.Body())
+	if !json.Valid(result) {
+		result, _ = json.Marshal(string(ctx.Response.Body()))
+	}
+	return ctx.Response.StatusCode(), result
+}
+
+// emitEvent writes a hub event, or resync-required
+func (s *wsSession) emitEvent(name string, id uint64, data string) error {
+	if name == "resync-required" {
+		return s.write(wsFrame{Type: name, EventID: id, Data: json.RawMessage(data)})
+	}
+	return s.write(wsFrame{Type: "event", Event: name, EventID: id, Data: json.RawMessage(data)})
+}
+
+// reply answers a command
+func (s *wsSession) reply(requestID string, status int, result interface{}, errMsg string) error {
+	return s.write(wsFrame{Type: "reply", RequestID: requestID, Status: status, Result: result, Error: errMsg})
+}
+
+func (s *wsSession) write(frame wsFrame) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteJSON(frame)
+}
+
+// addTopics returns list with the topics it does not have yet
+func addTopics(list, topics []string) []string {
+	result := append([]string{}, list...)
+	for _, topic := range topics {
+		if !containsTopic(result, topic) {
+			result = append(result, topic)
+		}
+	}
+	return result
+}
+
+// removeTopics returns list without topics
+func removeTopics(list, topics []string) []string {
+	result := []string{}
+	for _, topic := range list {
+		if !containsTopic(topics, topic) {
+			result = append(result, topic)
+		}
+	}
+	return result
+}
+
+func containsTopic(list []string, topic string) bool {
+	for _, t := range list {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+)
+
+var (
+	vmResource        = schema.GroupResource{Group: kubevirtv1.GroupVersion.Group, Resource: "virtualmachines"}
+	vmiResource       = schema.GroupResource{Group: kubevirtv1.GroupVersion.Group, Resource: "virtualmachineinstances"}
+	migrationResource = schema.GroupResource{Group: kubevirtv1.GroupVersion.Group, Resource: "virtualmachineinstancemigrations"}
+)
+
+// kubevirtClient serves the resources the cluster watchers use from a
+// simulated cluster. Any other call panics on the nil embedded interface.
+type kubevirtClient struct {
+	kubecli.KubevirtClient
+	sim     *Simulator
+	cluster string
+}
+
+func (k *kubevirtClient) VirtualMachine(namespace string) kubecli.VirtualMachineInterface {
+	return &vmClient{sim: k.sim, cluster: k.cluster, namespace: namespace}
+}
+
+func (k *kubevirtClient) VirtualMachineInstance(namespace string) kubecli.VirtualMachineInstanceInterface {
+	return &vmiClient{sim: k.sim, cluster: k.cluster, namespace: namespace}
+}
+
+func (k *kubevirtClient) VirtualMachineInstanceMigration(namespace string) kubecli.VirtualMachineInstanceMigrationInterface {
+	return &migrationClient{sim: k.sim, cluster: k.cluster, namespace: namespace}
+}
+
+// inNamespace reports whether an object is visible to a client scoped to
+// namespace; the empty namespace sees everything
+func inNamespace(namespace, objectNamespace string) bool {
+	return namespace == "" || namespace == objectNamespace
+}
+
+// vmClient serves VirtualMachines
+type vmClient struct {
+	kubecli.VirtualMachineInterface
+	sim       *Simulator
+	cluster   string
+	namespace string
+}
+
+func (c *vmClient) List(_ context.Context, _ metav1.ListOptions) (*kubevirtv1.VirtualMachineList, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+
+	list := &kubevirtv1.VirtualMachineList{}
+	for _, vm := range c.sim.clusters[c.cluster].vms {
+		if inNamespace(c.namespace, vm.Namespace) {
+			list.Items = append(list.Items, *vm.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (c *vmClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	w, err := c.sim.broadcaster(c.cluster, func(cl *cluster) *watch.Broadcaster { return cl.vmEvents })
+	if err != nil {
+		return nil, err
+	}
+	return filterNamespace(w, c.namespace), nil
+}
+
+func (c *vmClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*kubevirtv1.VirtualMachine, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+
+	vm, ok := c.sim.clusters[c.cluster].vms[objectKey(c.namespace, name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(vmResource, name)
+	}
+	return vm.DeepCopy(), nil
+}
+
+func (c *vmClient) Start(_ context.Context, name string, _ *kubevirtv1.StartOptions) error {
+	return c.sim.startVM(c.cluster, c.namespace, name)
+}
+
+func (c *vmClient) Stop(_ context.Context, name string, _ *kubevirtv1.StopOptions) error {
+	return c.sim.stopVM(c.cluster, c.namespace, name)
+}
+
+func (c *vmClient) Restart(_ context.Context, name string, _ *kubevirtv1.RestartOptions) error {
+	return c.sim.restartVM(c.cluster, c.namespace, name)
+}
+
+// vmiClient serves VirtualMachineInstances
+type vmiClient struct {
+	kubecli.VirtualMachineInstanceInterface
+	sim       *Simulator
+	cluster   string
+	namespace string
+}
+
+func (c *vmiClient) List(_ context.Context, _ metav1.ListOptions) (*kubevirtv1.VirtualMachineInstanceList, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+
+	list := &kubevirtv1.VirtualMachineInstanceList{}
+	for _, vmi := range c.sim.clusters[c.cluster].vmis {
+		if inNamespace(c.namespace, vmi.Namespace) {
+			list.Items = append(list.Items, *vmi.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (c *vmiClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	w, err := c.sim.broadcaster(c.cluster, func(cl *cluster) *watch.Broadcaster { return cl.vmiEvents })
+	if err != nil {
+		return nil, err
+	}
+	return filterNamespace(w, c.namespace), nil
+}
+
+func (c *vmiClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*kubevirtv1.VirtualMachineInstance, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+
+	vmi, ok := c.sim.clusters[c.cluster].vmis[objectKey(c.namespace, name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(vmiResource, name)
+	}
+	return vmi.DeepCopy(), nil
+}
+
+func (c *vmiClient) Pause(_ context.Context, name string, _ *kubevirtv1.PauseOptions) error {
+	return c.sim.pauseVM(c.cluster, c.namespace, name, true)
+}
+
+func (c *vmiClient) Unpause(_ context.Context, name string, _ *kubevirtv1.UnpauseOptions) error {
+	return c.sim.pauseVM(c.cluster, c.namespace, name, false)
+}
+
+// migrationClient serves VirtualMachineInstanceMigrations
+type migrationClient struct {
+	kubecli.VirtualMachineInstanceMigrationInterface
+	sim       *Simulator
+	cluster   string
+	namespace string
+}
+
+func (c *migrationClient) List(_ context.Context, _ metav1.ListOptions) (*kubevirtv1.VirtualMachineInstanceMigrationList, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+
+	list := &kubevirtv1.VirtualMachineInstanceMigrationList{}
+	for _, migration := range c.sim.clusters[c.cluster].migrations {
+		if inNamespace(c.namespace, migration.Namespace) {
+			list.Items = append(list.Items, *migration.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (c *migrationClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	w, err := c.sim.broadcaster(c.cluster, func(cl *cluster) *watch.Broadcaster { return cl.migrationEvents })
+	if err != nil {
+		return nil, err
+	}
+	return filterNamespace(w, c.namespace), nil
+}
+
+func (c *migrationClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+
+	migration, ok := c.sim.clusters[c.cluster].migrations[objectKey(c.namespace, name)]
+	if !ok {
+		return nil, apierrors.NewNotFound(migrationResource, name)
+	}
+	return migration.DeepCopy(), nil
+}
+
+func (c *migrationClient) Create(_ context.Context, migration *kubevirtv1.VirtualMachineInstanceMigration, _ metav1.CreateOptions) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+	if c.namespace == "" {
+		return nil, fmt.Errorf("a namespace is required to create a migration")
+	}
+	migration = migration.DeepCopy()
+	migration.Namespace = c.namespace
+	return c.sim.createMigration(c.cluster, migration)
+}
+
+func (c *migrationClient) Delete(_ context.Context, name string, _ metav1.DeleteOptions) error {
+	return c.sim.deleteMigration(c.cluster, c.namespace, name)
+}
+
+// broadcaster starts a watch on one of a cluster's event streams
+func (s *Simulator) broadcaster(clusterName string, pick func(*cluster) *watch.Broadcaster) (watch.Interface, error) {
+	s.mu.Lock()
+	c, ok := s.clusters[clusterName]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("cluster %s is not simulated", clusterName)
+	}
+	return pick(c).Watch()
+}
+
+// filterNamespace limits a watch to one namespace
+func filterNamespace(w watch.Interface, namespace string) watch.Interface {
+	if namespace == "" {
+		return w
+	}
+	return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+		object, err := metaNamespace(e)
+		return e, err == nil && object == namespace
+	})
+}
+
+// metaNamespace returns the namespace of a watch event's object
+func metaNamespace(e watch.Event) (string, error) {
+	accessor, ok := e.Object.(metav1.Object)
+	if !ok {
+		return "", fmt.Errorf("unexpected object %T", e.Object)
+	}
+	return accessor.GetNamespace(), nil
+}
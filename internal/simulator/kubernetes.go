@@ -0,0 +1,132 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+
+	k8sv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+var (
+	nodeResource = schema.GroupResource{Resource: "nodes"}
+	pvcResource  = schema.GroupResource{Resource: "persistentvolumeclaims"}
+)
+
+// simulatedZones are spread over the nodes of each cluster
+var simulatedZones = []string{"zone-1", "zone-2", "zone-3"}
+
+// newNode builds a ready worker node
+func newNode(name, zone string) *k8sv1.Node {
+	return &k8sv1.Node{
+		TypeMeta: metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"kubernetes.io/hostname":         name,
+				"node-role.kubernetes.io/worker": "",
+				"topology.kubernetes.io/zone":    zone,
+				"kubevirt.io/schedulable":        "true",
+				SimulatedLabel:                   "true",
+			},
+		},
+		Status: k8sv1.NodeStatus{
+			Allocatable: k8sv1.ResourceList{
+				k8sv1.ResourceCPU:    resource.MustParse("32"),
+				k8sv1.ResourceMemory: resource.MustParse("128Gi"),
+			},
+			Conditions: []k8sv1.NodeCondition{{
+				Type:               k8sv1.NodeReady,
+				Status:             k8sv1.ConditionTrue,
+				Reason:             "KubeletReady",
+				LastTransitionTime: metav1.Now(),
+			}},
+		},
+	}
+}
+
+// seedNodes creates the worker nodes of a new cluster. Called with the
+// state locked.
+func (s *Simulator) seedNodes(c *cluster) {
+	for i := 1; i <= s.options.NodesPerCluster; i++ {
+		name := fmt.Sprintf("%s-worker-%d", c.name, i)
+		c.nodes = append(c.nodes, name)
+		c.nodeObjects[name] = newNode(name, simulatedZones[(i-1)%len(simulatedZones)])
+	}
+}
+
+// kubernetesClient serves the core resources the cluster watchers use from
+// a simulated cluster. Any other call panics on the nil embedded interface.
+type kubernetesClient struct {
+	kubernetes.Interface
+	sim     *Simulator
+	cluster string
+}
+
+func (k *kubernetesClient) CoreV1() corev1client.CoreV1Interface {
+	return &coreClient{sim: k.sim, cluster: k.cluster}
+}
+
+// coreClient serves nodes and, as simulated disks have no claims,
+// reports every PersistentVolumeClaim as missing
+type coreClient struct {
+	corev1client.CoreV1Interface
+	sim     *Simulator
+	cluster string
+}
+
+func (c *coreClient) Nodes() corev1client.NodeInterface {
+	return &nodeClient{sim: c.sim, cluster: c.cluster}
+}
+
+func (c *coreClient) PersistentVolumeClaims(namespace string) corev1client.PersistentVolumeClaimInterface {
+	return &pvcClient{}
+}
+
+// nodeClient serves Nodes
+type nodeClient struct {
+	corev1client.NodeInterface
+	sim     *Simulator
+	cluster string
+}
+
+func (c *nodeClient) List(_ context.Context, _ metav1.ListOptions) (*k8sv1.NodeList, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+
+	list := &k8sv1.NodeList{}
+	for _, name := range c.sim.clusters[c.cluster].nodes {
+		list.Items = append(list.Items, *c.sim.clusters[c.cluster].nodeObjects[name].DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *nodeClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	return c.sim.broadcaster(c.cluster, func(cl *cluster) *watch.Broadcaster { return cl.nodeEvents })
+}
+
+func (c *nodeClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*k8sv1.Node, error) {
+	c.sim.mu.Lock()
+	defer c.sim.mu.Unlock()
+
+	node, ok := c.sim.clusters[c.cluster].nodeObjects[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(nodeResource, name)
+	}
+	return node.DeepCopy(), nil
+}
+
+// pvcClient has no claims to serve
+type pvcClient struct {
+	corev1client.PersistentVolumeClaimInterface
+}
+
+func (c *pvcClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*k8sv1.PersistentVolumeClaim, error) {
+	return nil, apierrors.NewNotFound(pvcResource, name)
+}
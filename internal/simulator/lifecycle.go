@@ -0,0 +1,266 @@
+package simulator
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// vmState returns a VM and its instance (nil when not running). Called with
+// the state locked.
+func (s *Simulator) vmState(clusterName, namespace, name string) (*cluster, *kubevirtv1.VirtualMachine, *kubevirtv1.VirtualMachineInstance, error) {
+	c, ok := s.clusters[clusterName]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("cluster %s is not simulated", clusterName)
+	}
+	vm, ok := c.vms[objectKey(namespace, name)]
+	if !ok {
+		return nil, nil, nil, apierrors.NewNotFound(vmResource, name)
+	}
+	return c, vm, c.vmis[objectKey(namespace, name)], nil
+}
+
+// conflict is the error KubeVirt returns for an action the VM's state
+// doesn't allow
+func conflict(name string, format string, args ...interface{}) error {
+	return apierrors.NewConflict(vmResource, name, fmt.Errorf(format, args...))
+}
+
+// startVM starts a stopped VM; it boots after BootDuration
+func (s *Simulator) startVM(clusterName, namespace, name string) error {
+	return s.updateErr(func() ([]event, error) {
+		c, vm, vmi, err := s.vmState(clusterName, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if vmi != nil || vm.Status.PrintableStatus == kubevirtv1.VirtualMachineStatusStarting {
+			return nil, conflict(name, "VM is already running")
+		}
+		return []event{s.beginBoot(c, vm)}, nil
+	})
+}
+
+// stopVM stops a running or paused VM
+func (s *Simulator) stopVM(clusterName, namespace, name string) error {
+	return s.updateErr(func() ([]event, error) {
+		c, vm, vmi, err := s.vmState(clusterName, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if vmi == nil {
+			return nil, conflict(name, "VM is not running")
+		}
+		if s.migrating(clusterName, namespace, name) {
+			return nil, conflict(name, "VM is migrating")
+		}
+
+		vm.Spec.RunStrategy = runStrategy(kubevirtv1.RunStrategyHalted)
+		return []event{s.beginShutdown(c, vm, false)}, nil
+	})
+}
+
+// restartVM stops a running VM and boots it again
+func (s *Simulator) restartVM(clusterName, namespace, name string) error {
+	return s.updateErr(func() ([]event, error) {
+		c, vm, vmi, err := s.vmState(clusterName, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if vmi == nil || vmi.Status.Phase != kubevirtv1.Running {
+			return nil, conflict(name, "VM is not running")
+		}
+		if s.migrating(clusterName, namespace, name) {
+			return nil, conflict(name, "VM is migrating")
+		}
+		return []event{s.beginShutdown(c, vm, true)}, nil
+	})
+}
+
+// pauseVM pauses or unpauses a running VM's instance
+func (s *Simulator) pauseVM(clusterName, namespace, name string, pause bool) error {
+	return s.updateErr(func() ([]event, error) {
+		c, vm, vmi, err := s.vmState(clusterName, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if vmi == nil {
+			return nil, apierrors.NewNotFound(vmiResource, name)
+		}
+		if isPaused(vmi) == pause {
+			if pause {
+				return nil, conflict(name, "VMI is already paused")
+			}
+			return nil, conflict(name, "VMI is not paused")
+		}
+		if pause && s.migrating(clusterName, namespace, name) {
+			return nil, conflict(name, "VM is migrating")
+		}
+
+		status := kubevirtv1.VirtualMachineStatusRunning
+		conditions := make([]kubevirtv1.VirtualMachineInstanceCondition, 0, len(vmi.Status.Conditions)+1)
+		for _, condition := range vmi.Status.Conditions {
+			if condition.Type != kubevirtv1.VirtualMachineInstancePaused {
+				conditions = append(conditions, condition)
+			}
+		}
+		if pause {
+			status = kubevirtv1.VirtualMachineStatusPaused
+			conditions = append(conditions, kubevirtv1.VirtualMachineInstanceCondition{
+				Type:               kubevirtv1.VirtualMachineInstancePaused,
+				Status:             k8sv1.ConditionTrue,
+				Reason:             "PausedByUser",
+				LastTransitionTime: metav1.Now(),
+			})
+		}
+		vmi.Status.Conditions = conditions
+		setVMStatus(vm, status)
+
+		return []event{c.putVMI(vmi, watch.Modified), c.putVM(vm, watch.Modified)}, nil
+	})
+}
+
+// isPaused reports whether a VMI has the Paused condition
+func isPaused(vmi *kubevirtv1.VirtualMachineInstance) bool {
+	for _, condition := range vmi.Status.Conditions {
+		if condition.Type == kubevirtv1.VirtualMachineInstancePaused && condition.Status == k8sv1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// beginBoot moves a VM to Starting and schedules its instance to come up.
+// Called with the state locked.
+func (s *Simulator) beginBoot(c *cluster, vm *kubevirtv1.VirtualMachine) event {
+	vm.Spec.RunStrategy = runStrategy(kubevirtv1.RunStrategyAlways)
+	setVMStatus(vm, kubevirtv1.VirtualMachineStatusStarting)
+
+	namespace, name := vm.Namespace, vm.Name
+	s.after(s.options.BootDuration, func() {
+		s.update(func() []event {
+			vm, ok := c.vms[objectKey(namespace, name)]
+			if !ok || vm.Status.PrintableStatus != kubevirtv1.VirtualMachineStatusStarting {
+				return nil
+			}
+			setVMStatus(vm, kubevirtv1.VirtualMachineStatusRunning)
+			return []event{c.putVMI(s.newVMI(c, vm, s.randomNode(c, "")), watch.Added), c.putVM(vm, watch.Modified)}
+		})
+	})
+
+	return c.putVM(vm, watch.Modified)
+}
+
+// beginShutdown moves a VM to Stopping and schedules its instance to go
+// away, booting it again when restart is set. Called with the state locked.
+func (s *Simulator) beginShutdown(c *cluster, vm *kubevirtv1.VirtualMachine, restart bool) event {
+	setVMStatus(vm, kubevirtv1.VirtualMachineStatusStopping)
+
+	namespace, name := vm.Namespace, vm.Name
+	s.after(s.options.BootDuration/2, func() {
+		s.update(func() []event {
+			key := objectKey(namespace, name)
+			vm, ok := c.vms[key]
+			if !ok || vm.Status.PrintableStatus != kubevirtv1.VirtualMachineStatusStopping {
+				return nil
+			}
+
+			var events []event
+			if vmi, ok := c.vmis[key]; ok {
+				events = append(events, c.deleteVMI(vmi))
+			}
+			if restart {
+				return append(events, s.beginBoot(c, vm))
+			}
+			setVMStatus(vm, kubevirtv1.VirtualMachineStatusStopped)
+			return append(events, c.putVM(vm, watch.Modified))
+		})
+	})
+
+	return c.putVM(vm, watch.Modified)
+}
+
+// lifecycleLoop makes a cluster feel alive until the simulator stops: every
+// so often a running VM crashes and comes back, or a stopped VM is booted.
+func (s *Simulator) lifecycleLoop(clusterName string) {
+	for {
+		s.mu.Lock()
+		// Spread events between half and one and a half intervals
+		wait := s.options.CrashInterval/2 + time.Duration(s.rng.Int63n(int64(s.options.CrashInterval)))
+		s.mu.Unlock()
+
+		if !sleep(s.ctx, wait) {
+			return
+		}
+		s.update(func() []event {
+			return s.lifecycleEvent(s.clusters[clusterName])
+		})
+	}
+}
+
+// lifecycleEvent picks a random VM that is not busy and crashes or boots it.
+// Called with the state locked.
+func (s *Simulator) lifecycleEvent(c *cluster) []event {
+	var candidates []*kubevirtv1.VirtualMachine
+	for _, vm := range c.vms {
+		switch vm.Status.PrintableStatus {
+		case kubevirtv1.VirtualMachineStatusRunning, kubevirtv1.VirtualMachineStatusStopped:
+			if !s.migrating(c.name, vm.Namespace, vm.Name) {
+				candidates = append(candidates, vm)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	// Map iteration order is random but not seeded; sort for reproducible runs
+	sortVMs(candidates)
+	vm := candidates[s.rng.Intn(len(candidates))]
+
+	if vm.Status.PrintableStatus == kubevirtv1.VirtualMachineStatusStopped {
+		log.Printf("Simulator: booting VM %s/%s in cluster %s", vm.Namespace, vm.Name, c.name)
+		return []event{s.beginBoot(c, vm)}
+	}
+
+	log.Printf("Simulator: VM %s/%s in cluster %s crashed", vm.Namespace, vm.Name, c.name)
+	return s.crash(c, vm)
+}
+
+// crash fails a VM's instance and puts the VM in CrashLoopBackOff until it
+// is booted again. Called with the state locked.
+func (s *Simulator) crash(c *cluster, vm *kubevirtv1.VirtualMachine) []event {
+	key := objectKey(vm.Namespace, vm.Name)
+	var events []event
+	if vmi, ok := c.vmis[key]; ok {
+		vmi.Status.Phase = kubevirtv1.Failed
+		events = append(events, c.putVMI(vmi, watch.Modified), c.deleteVMI(vmi))
+	}
+	setVMStatus(vm, kubevirtv1.VirtualMachineStatusCrashLoopBackOff)
+	events = append(events, c.putVM(vm, watch.Modified))
+
+	namespace, name := vm.Namespace, vm.Name
+	s.after(2*s.options.BootDuration, func() {
+		s.update(func() []event {
+			vm, ok := c.vms[objectKey(namespace, name)]
+			if !ok || vm.Status.PrintableStatus != kubevirtv1.VirtualMachineStatusCrashLoopBackOff {
+				return nil
+			}
+			return []event{s.beginBoot(c, vm)}
+		})
+	})
+
+	return events
+}
+
+// sortVMs orders VMs by namespace and name
+func sortVMs(vms []*kubevirtv1.VirtualMachine) {
+	sort.Slice(vms, func(i, j int) bool {
+		return objectKey(vms[i].Namespace, vms[i].Name) < objectKey(vms[j].Namespace, vms[j].Name)
+	})
+}
@@ -0,0 +1,504 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// migrationPhases are the phases a running migration steps through before
+// it succeeds or fails
+var migrationPhases = []kubevirtv1.VirtualMachineInstanceMigrationPhase{
+	kubevirtv1.MigrationScheduling,
+	kubevirtv1.MigrationScheduled,
+	kubevirtv1.MigrationPreparingTarget,
+	kubevirtv1.MigrationTargetReady,
+	kubevirtv1.MigrationRunning,
+}
+
+// failureReasons are picked from when a simulated migration fails
+var failureReasons = []string{
+	"Live migration failed: migration job timed out after 150s of no progress",
+	"Live migration failed: target pod could not be scheduled: insufficient memory",
+	"Live migration failed: connection to the target node was reset",
+	"Live migration failed: dirty page rate exceeded the migration bandwidth",
+}
+
+// migrationRun is a migration in progress. A move within a cluster has a
+// single VMIM; a move between clusters pairs a sendTo VMIM in the source
+// cluster with a receive VMIM in the target cluster.
+type migrationRun struct {
+	cancel context.CancelFunc
+
+	namespace string
+	vmName    string
+	source    *cluster
+	target    *cluster
+	outgoing  string
+	incoming  string
+
+	sourceNode string
+	targetNode string
+	sourcePod  string
+	targetPod  string
+	startedAt  metav1.Time
+}
+
+// crossCluster reports whether the run moves a VM between clusters
+func (r *migrationRun) crossCluster() bool {
+	return r.source != r.target
+}
+
+// runKey identifies a run by its source migration
+func runKey(clusterName, namespace, name string) string {
+	return clusterName + "/" + objectKey(namespace, name)
+}
+
+// migrating reports whether a VM takes part in a running migration. Called
+// with the state locked.
+func (s *Simulator) migrating(clusterName, namespace, name string) bool {
+	for _, run := range s.runs {
+		if run.namespace == namespace && run.vmName == name &&
+			(run.source.name == clusterName || run.target.name == clusterName) {
+			return true
+		}
+	}
+	return false
+}
+
+// findRun returns the run a migration belongs to. Called with the state
+// locked.
+func (s *Simulator) findRun(c *cluster, namespace, name string) (string, *migrationRun) {
+	for key, run := range s.runs {
+		if run.namespace != namespace {
+			continue
+		}
+		if (run.source == c && run.outgoing == name) || (run.target == c && run.incoming == name) {
+			return key, run
+		}
+	}
+	return "", nil
+}
+
+// createMigration accepts a new VMIM the way the KubeVirt API would and
+// starts simulating it once it can run
+func (s *Simulator) createMigration(clusterName string, migration *kubevirtv1.VirtualMachineInstanceMigration) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+	var created *kubevirtv1.VirtualMachineInstanceMigration
+	err := s.updateErr(func() ([]event, error) {
+		c, ok := s.clusters[clusterName]
+		if !ok {
+			return nil, fmt.Errorf("cluster %s is not simulated", clusterName)
+		}
+		if migration.Spec.VMIName == "" {
+			return nil, apierrors.NewBadRequest("spec.vmiName is required")
+		}
+		if migration.Name == "" {
+			if migration.GenerateName == "" {
+				return nil, apierrors.NewBadRequest("name or generateName is required")
+			}
+			migration.Name = migration.GenerateName + s.randomSuffix()
+		}
+		if _, exists := c.migrations[objectKey(migration.Namespace, migration.Name)]; exists {
+			return nil, apierrors.NewAlreadyExists(migrationResource, migration.Name)
+		}
+
+		// Only the receiving side may exist before the VM is running here
+		if migration.Spec.Receive == nil {
+			if err := s.checkMigratable(c, migration.Namespace, migration.Spec.VMIName); err != nil {
+				return nil, err
+			}
+		}
+
+		now := metav1.Now()
+		migration.UID = types.UID(fmt.Sprintf("sim-vmim-%s", s.randomSuffix()))
+		migration.CreationTimestamp = now
+		migration.Status = kubevirtv1.VirtualMachineInstanceMigrationStatus{
+			Phase: kubevirtv1.MigrationPending,
+			PhaseTransitionTimestamps: []kubevirtv1.VirtualMachineInstanceMigrationPhaseTransitionTimestamp{
+				{Phase: kubevirtv1.MigrationPending, PhaseTransitionTimestamp: now},
+			},
+		}
+		created = migration.DeepCopy()
+
+		events := []event{c.putMigration(migration, watch.Added)}
+		return append(events, s.startRun(c, migration)...), nil
+	})
+	return created, err
+}
+
+// checkMigratable returns the error KubeVirt gives when a VM can't be
+// migrated. Called with the state locked.
+func (s *Simulator) checkMigratable(c *cluster, namespace, name string) error {
+	vmi, ok := c.vmis[objectKey(namespace, name)]
+	if !ok || vmi.Status.Phase != kubevirtv1.Running {
+		return apierrors.NewBadRequest(fmt.Sprintf("VMI %s/%s is not running", namespace, name))
+	}
+	if isPaused(vmi) {
+		return apierrors.NewBadRequest(fmt.Sprintf("VMI %s/%s is paused", namespace, name))
+	}
+	if s.migrating(c.name, namespace, name) {
+		return apierrors.NewConflict(migrationResource, name, fmt.Errorf("VMI %s/%s is already migrating", namespace, name))
+	}
+	return nil
+}
+
+// startRun starts simulating a migration once all its parts exist: right
+// away within a cluster, or when both halves of a cross-cluster migration
+// have been created. Called with the state locked.
+func (s *Simulator) startRun(c *cluster, migration *kubevirtv1.VirtualMachineInstanceMigration) []event {
+	run := &migrationRun{namespace: migration.Namespace, vmName: migration.Spec.VMIName, source: c, target: c, outgoing: migration.Name}
+
+	switch {
+	case migration.Spec.SendTo != nil:
+		peer, incoming := s.findPeer(c, migration.Namespace, migration.Spec.SendTo.MigrationID, true)
+		if peer == nil {
+			// The receiving side has not been created yet
+			return nil
+		}
+		run.target, run.incoming = peer, incoming.Name
+	case migration.Spec.Receive != nil:
+		peer, outgoing := s.findPeer(c, migration.Namespace, migration.Spec.Receive.MigrationID, false)
+		if peer == nil {
+			return nil
+		}
+		run.source, run.outgoing, run.incoming = peer, outgoing.Name, migration.Name
+	}
+
+	vmi := run.source.vmis[objectKey(run.namespace, run.vmName)]
+	if vmi == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	run.cancel = cancel
+	run.sourceNode = vmi.Status.NodeName
+	run.sourcePod = fmt.Sprintf("virt-launcher-%s-%s", run.vmName, s.randomSuffix())
+	if run.crossCluster() {
+		run.targetNode = s.randomNode(run.target, "")
+	} else {
+		run.targetNode = s.randomNode(run.source, run.sourceNode)
+	}
+	s.runs[runKey(run.source.name, run.namespace, run.outgoing)] = run
+
+	go s.drive(ctx, run)
+
+	log.Printf("Simulator: migrating VM %s/%s from %s/%s to %s/%s", run.namespace, run.vmName,
+		run.source.name, run.sourceNode, run.target.name, run.targetNode)
+	return s.prepareReceiver(run)
+}
+
+// findPeer finds the other half of a cross-cluster migration by its shared
+// migration ID. Called with the state locked.
+func (s *Simulator) findPeer(self *cluster, namespace, migrationID string, receiving bool) (*cluster, *kubevirtv1.VirtualMachineInstanceMigration) {
+	for _, c := range s.clusters {
+		if c == self {
+			continue
+		}
+		for _, migration := range c.migrations {
+			if migration.Namespace != namespace || migration.Status.Phase != kubevirtv1.MigrationPending {
+				continue
+			}
+			if receiving && migration.Spec.Receive != nil && migration.Spec.Receive.MigrationID == migrationID {
+				return c, migration
+			}
+			if !receiving && migration.Spec.SendTo != nil && migration.Spec.SendTo.MigrationID == migrationID {
+				return c, migration
+			}
+		}
+	}
+	return nil, nil
+}
+
+// prepareReceiver creates the VM waiting for the incoming migration in the
+// target cluster. Called with the state locked.
+func (s *Simulator) prepareReceiver(run *migrationRun) []event {
+	if !run.crossCluster() {
+		return nil
+	}
+	sourceVM, ok := run.source.vms[objectKey(run.namespace, run.vmName)]
+	if !ok {
+		return nil
+	}
+
+	receiver := sourceVM.DeepCopy()
+	receiver.ResourceVersion = ""
+	receiver.CreationTimestamp = metav1.Now()
+	receiver.Spec.RunStrategy = runStrategy(kubevirtv1.RunStrategyWaitAsReceiver)
+	setVMStatus(receiver, kubevirtv1.VirtualMachineStatusWaitingForReceiver)
+	return []event{run.target.putVM(receiver, watch.Added)}
+}
+
+// drive steps a migration through its phases until it finishes or is
+// cancelled
+func (s *Simulator) drive(ctx context.Context, run *migrationRun) {
+	step := s.options.MigrationDuration / time.Duration(len(migrationPhases)+1)
+
+	for _, phase := range migrationPhases {
+		if !sleep(ctx, step) {
+			return
+		}
+		phase := phase
+		s.update(func() []event {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return s.advance(run, phase)
+		})
+	}
+
+	if !sleep(ctx, step) {
+		return
+	}
+	s.update(func() []event {
+		if ctx.Err() != nil {
+			return nil
+		}
+		failed := s.rng.Float64() < s.options.MigrationFailureRate
+		return s.finish(run, failed)
+	})
+}
+
+// advance moves a run into its next phase. Called with the state locked.
+func (s *Simulator) advance(run *migrationRun, phase kubevirtv1.VirtualMachineInstanceMigrationPhase) []event {
+	var events []event
+
+	switch phase {
+	case kubevirtv1.MigrationScheduling:
+		run.startedAt = metav1.Now()
+		if vm, ok := run.source.vms[objectKey(run.namespace, run.vmName)]; ok {
+			setVMStatus(vm, kubevirtv1.VirtualMachineStatusMigrating)
+			events = append(events, run.source.putVM(vm, watch.Modified))
+		}
+	case kubevirtv1.MigrationScheduled:
+		run.targetPod = fmt.Sprintf("virt-launcher-%s-%s", run.vmName, s.randomSuffix())
+	}
+
+	state := s.migrationState(run)
+	if phase == kubevirtv1.MigrationTargetReady || phase == kubevirtv1.MigrationRunning {
+		ready := metav1.Now()
+		state.TargetNodeDomainReadyTimestamp = &ready
+		state.TargetNodeDomainDetected = true
+	}
+
+	if vmi, ok := run.source.vmis[objectKey(run.namespace, run.vmName)]; ok {
+		vmi.Status.MigrationState = state.DeepCopy()
+		events = append(events, run.source.putVMI(vmi, watch.Modified))
+	}
+	return append(events, s.setRunPhase(run, phase, state)...)
+}
+
+// finish completes a run. A successful migration moves the VM to its target
+// node (and cluster); a failed one leaves it where it was. Called with the
+// state locked.
+func (s *Simulator) finish(run *migrationRun, failed bool) []event {
+	delete(s.runs, runKey(run.source.name, run.namespace, run.outgoing))
+	run.cancel()
+
+	key := objectKey(run.namespace, run.vmName)
+	end := metav1.Now()
+	state := s.migrationState(run)
+	state.EndTimestamp = &end
+	state.Completed = true
+
+	var events []event
+	phase := kubevirtv1.MigrationSucceeded
+	if failed {
+		phase = kubevirtv1.MigrationFailed
+		state.Failed = true
+		state.FailureReason = failureReasons[s.rng.Intn(len(failureReasons))]
+		log.Printf("Simulator: migration of VM %s/%s failed: %s", run.namespace, run.vmName, state.FailureReason)
+
+		events = append(events, s.restoreSource(run, state)...)
+		return append(events, s.setRunPhase(run, phase, state)...)
+	}
+
+	sourceVMI, hasVMI := run.source.vmis[key]
+	sourceVM, hasVM := run.source.vms[key]
+	if !run.crossCluster() {
+		if hasVMI {
+			sourceVMI.Status.NodeName = run.targetNode
+			sourceVMI.Status.MigrationState = state.DeepCopy()
+			events = append(events, run.source.putVMI(sourceVMI, watch.Modified))
+		}
+		if hasVM {
+			setVMStatus(sourceVM, kubevirtv1.VirtualMachineStatusRunning)
+			events = append(events, run.source.putVM(sourceVM, watch.Modified))
+		}
+		log.Printf("Simulator: VM %s/%s migrated to node %s", run.namespace, run.vmName, run.targetNode)
+		return append(events, s.setRunPhase(run, phase, state)...)
+	}
+
+	// Across clusters the instance comes up in the target and the source
+	// VM goes away
+	if receiver, ok := run.target.vms[key]; ok {
+		receiver.Spec.RunStrategy = runStrategy(kubevirtv1.RunStrategyAlways)
+		setVMStatus(receiver, kubevirtv1.VirtualMachineStatusRunning)
+		targetVMI := s.newVMI(run.target, receiver, run.targetNode)
+		targetVMI.Status.MigrationState = state.DeepCopy()
+		events = append(events, run.target.putVMI(targetVMI, watch.Added), run.target.putVM(receiver, watch.Modified))
+	}
+	if hasVMI {
+		events = append(events, run.source.deleteVMI(sourceVMI))
+	}
+	if hasVM {
+		events = append(events, run.source.deleteVM(sourceVM))
+	}
+	log.Printf("Simulator: VM %s/%s migrated from cluster %s to %s", run.namespace, run.vmName, run.source.name, run.target.name)
+	return append(events, s.setRunPhase(run, phase, state)...)
+}
+
+// restoreSource puts the source VM back to Running and removes the receiver
+// after a failed or aborted run. Called with the state locked.
+func (s *Simulator) restoreSource(run *migrationRun, state *kubevirtv1.VirtualMachineInstanceMigrationState) []event {
+	key := objectKey(run.namespace, run.vmName)
+	var events []event
+	if vmi, ok := run.source.vmis[key]; ok {
+		vmi.Status.MigrationState = state.DeepCopy()
+		events = append(events, run.source.putVMI(vmi, watch.Modified))
+	}
+	if vm, ok := run.source.vms[key]; ok {
+		setVMStatus(vm, kubevirtv1.VirtualMachineStatusRunning)
+		events = append(events, run.source.putVM(vm, watch.Modified))
+	}
+	if run.crossCluster() {
+		if receiver, ok := run.target.vms[key]; ok {
+			events = append(events, run.target.deleteVM(receiver))
+		}
+	}
+	return events
+}
+
+// migrationState builds the migration state both sides of a run report.
+// Called with the state locked.
+func (s *Simulator) migrationState(run *migrationRun) *kubevirtv1.VirtualMachineInstanceMigrationState {
+	bandwidth := resource.MustParse("64Mi")
+	completionTimeout := int64(150)
+	progressTimeout := int64(150)
+	parallel := uint32(5)
+	allowPostCopy := false
+	allowAutoConverge := false
+
+	state := &kubevirtv1.VirtualMachineInstanceMigrationState{
+		SourceNode: run.sourceNode,
+		SourcePod:  run.sourcePod,
+		TargetNode: run.targetNode,
+		TargetPod:  run.targetPod,
+		Mode:       kubevirtv1.MigrationPreCopy,
+		MigrationConfiguration: &kubevirtv1.MigrationConfiguration{
+			ParallelMigrationsPerCluster: &parallel,
+			AllowAutoConverge:            &allowAutoConverge,
+			AllowPostCopy:                &allowPostCopy,
+			BandwidthPerMigration:        &bandwidth,
+			CompletionTimeoutPerGiB:      &completionTimeout,
+			ProgressTimeout:              &progressTimeout,
+		},
+	}
+	if !run.startedAt.IsZero() {
+		started := run.startedAt
+		state.StartTimestamp = &started
+	}
+	return state
+}
+
+// setRunPhase records a phase on every VMIM of a run. Called with the state
+// locked.
+func (s *Simulator) setRunPhase(run *migrationRun, phase kubevirtv1.VirtualMachineInstanceMigrationPhase, state *kubevirtv1.VirtualMachineInstanceMigrationState) []event {
+	var events []event
+	for _, side := range []struct {
+		c    *cluster
+		name string
+	}{{run.source, run.outgoing}, {run.target, run.incoming}} {
+		if side.name == "" {
+			continue
+		}
+		migration, ok := side.c.migrations[objectKey(run.namespace, side.name)]
+		if !ok {
+			continue
+		}
+		setMigrationPhase(migration, phase)
+		migration.Status.MigrationState = state.DeepCopy()
+		events = append(events, side.c.putMigration(migration, watch.Modified))
+	}
+	return events
+}
+
+// setMigrationPhase sets a VMIM phase and records the transition
+func setMigrationPhase(migration *kubevirtv1.VirtualMachineInstanceMigration, phase kubevirtv1.VirtualMachineInstanceMigrationPhase) {
+	if migration.Status.Phase == phase {
+		return
+	}
+	migration.Status.Phase = phase
+	migration.Status.PhaseTransitionTimestamps = append(migration.Status.PhaseTransitionTimestamps,
+		kubevirtv1.VirtualMachineInstanceMigrationPhaseTransitionTimestamp{Phase: phase, PhaseTransitionTimestamp: metav1.Now()})
+}
+
+// deleteMigration removes a VMIM. Deleting a migration that is still in
+// progress aborts it: the VM stays on its source and the other half of a
+// cross-cluster migration reports the abort.
+func (s *Simulator) deleteMigration(clusterName, namespace, name string) error {
+	return s.updateErr(func() ([]event, error) {
+		c, ok := s.clusters[clusterName]
+		if !ok {
+			return nil, fmt.Errorf("cluster %s is not simulated", clusterName)
+		}
+		migration, ok := c.migrations[objectKey(namespace, name)]
+		if !ok {
+			return nil, apierrors.NewNotFound(migrationResource, name)
+		}
+
+		var events []event
+		if migration.Status.Phase != kubevirtv1.MigrationSucceeded && migration.Status.Phase != kubevirtv1.MigrationFailed {
+			// The finalizer holds the object while the abort is processed
+			deleting := metav1.Now()
+			migration.DeletionTimestamp = &deleting
+			events = append(events, c.putMigration(migration, watch.Modified))
+		}
+
+		if key, run := s.findRun(c, namespace, name); run != nil {
+			delete(s.runs, key)
+			run.cancel()
+			events = append(events, s.abortRun(run, c, name)...)
+		}
+
+		return append(events, c.deleteMigration(migration)), nil
+	})
+}
+
+// abortRun ends a run whose VMIM was deleted. Called with the state locked.
+func (s *Simulator) abortRun(run *migrationRun, c *cluster, deleted string) []event {
+	end := metav1.Now()
+	state := s.migrationState(run)
+	state.EndTimestamp = &end
+	state.Completed = true
+	state.Failed = true
+	state.AbortRequested = true
+	state.AbortStatus = kubevirtv1.MigrationAbortSucceeded
+	state.FailureReason = "Live migration aborted"
+
+	log.Printf("Simulator: migration of VM %s/%s aborted", run.namespace, run.vmName)
+	events := s.restoreSource(run, state)
+
+	// Whatever half survives reports the abort
+	for _, side := range []struct {
+		c    *cluster
+		name string
+	}{{run.source, run.outgoing}, {run.target, run.incoming}} {
+		if side.name == "" || (side.c == c && side.name == deleted) {
+			continue
+		}
+		if migration, ok := side.c.migrations[objectKey(run.namespace, side.name)]; ok {
+			setMigrationPhase(migration, kubevirtv1.MigrationFailed)
+			migration.Status.MigrationState = state.DeepCopy()
+			events = append(events, side.c.putMigration(migration, watch.Modified))
+		}
+	}
+	return events
+}
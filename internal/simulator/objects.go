@@ -0,0 +1,176 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+)
+
+const (
+	// simulatedNamespace is where all simulated VMs live
+	simulatedNamespace = "demo"
+	// simulatedStorageClass is the storage class of simulated disks
+	simulatedStorageClass = "sim-ceph-rbd"
+	// SimulatedLabel marks objects created by the simulator
+	SimulatedLabel = "summit-connect.io/simulated"
+)
+
+// vmProfile is a kind of workload the simulator creates
+type vmProfile struct {
+	app      string
+	cores    uint32
+	memoryGi int64
+	diskGi   int64
+}
+
+var vmProfiles = []vmProfile{
+	{app: "web", cores: 2, memoryGi: 4, diskGi: 30},
+	{app: "api", cores: 2, memoryGi: 8, diskGi: 40},
+	{app: "db", cores: 4, memoryGi: 16, diskGi: 100},
+	{app: "cache", cores: 1, memoryGi: 2, diskGi: 20},
+	{app: "worker", cores: 4, memoryGi: 8, diskGi: 50},
+}
+
+// seedCluster fills a new cluster with VMs, most of them running.
+// Called with the state locked.
+func (s *Simulator) seedCluster(c *cluster) {
+	for i := 0; i < s.options.VMsPerCluster; i++ {
+		profile := vmProfiles[i%len(vmProfiles)]
+		name := fmt.Sprintf("%s-%s-%d", c.name, profile.app, i/len(vmProfiles)+1)
+		created := time.Now().Add(-time.Duration(s.rng.Intn(30*24)) * time.Hour)
+
+		vm := newVM(name, simulatedNamespace, profile, created)
+		if s.rng.Float64() < 0.8 {
+			vm.Spec.RunStrategy = runStrategy(kubevirtv1.RunStrategyAlways)
+			setVMStatus(vm, kubevirtv1.VirtualMachineStatusRunning)
+			c.putVMI(s.newVMI(c, vm, s.randomNode(c, "")), watch.Added)
+		}
+		c.putVM(vm, watch.Added)
+	}
+}
+
+// newVM builds a stopped VirtualMachine with a DataVolume root disk
+func newVM(name, namespace string, profile vmProfile, created time.Time) *kubevirtv1.VirtualMachine {
+	guest := resource.MustParse(fmt.Sprintf("%dGi", profile.memoryGi))
+	storageClass := simulatedStorageClass
+	rootDisk := name + "-rootdisk"
+
+	vm := &kubevirtv1.VirtualMachine{
+		TypeMeta: metav1.TypeMeta{Kind: "VirtualMachine", APIVersion: kubevirtv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			UID:               types.UID(fmt.Sprintf("sim-%s-%s", namespace, name)),
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				"app":          profile.app,
+				SimulatedLabel: "true",
+			},
+			Annotations: map[string]string{"description": fmt.Sprintf("Simulated %s server", profile.app)},
+		},
+		Spec: kubevirtv1.VirtualMachineSpec{
+			RunStrategy: runStrategy(kubevirtv1.RunStrategyHalted),
+			DataVolumeTemplates: []kubevirtv1.DataVolumeTemplateSpec{{
+				ObjectMeta: metav1.ObjectMeta{Name: rootDisk},
+				Spec: cdiv1.DataVolumeSpec{
+					Storage: &cdiv1.StorageSpec{
+						StorageClassName: &storageClass,
+						Resources: k8sv1.VolumeResourceRequirements{
+							Requests: k8sv1.ResourceList{k8sv1.ResourceStorage: resource.MustParse(fmt.Sprintf("%dGi", profile.diskGi))},
+						},
+					},
+				},
+			}},
+			Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": profile.app}},
+				Spec: kubevirtv1.VirtualMachineInstanceSpec{
+					Domain: kubevirtv1.DomainSpec{
+						CPU:    &kubevirtv1.CPU{Sockets: 1, Cores: profile.cores, Threads: 1},
+						Memory: &kubevirtv1.Memory{Guest: &guest},
+						Devices: kubevirtv1.Devices{
+							Disks: []kubevirtv1.Disk{{Name: "rootdisk"}},
+						},
+					},
+					Volumes: []kubevirtv1.Volume{{
+						Name:         "rootdisk",
+						VolumeSource: kubevirtv1.VolumeSource{DataVolume: &kubevirtv1.DataVolumeSource{Name: rootDisk}},
+					}},
+				},
+			},
+		},
+	}
+	setVMStatus(vm, kubevirtv1.VirtualMachineStatusStopped)
+	return vm
+}
+
+// newVMI builds a running instance of a VM on a node with a fresh IP.
+// Called with the state locked.
+func (s *Simulator) newVMI(c *cluster, vm *kubevirtv1.VirtualMachine, node string) *kubevirtv1.VirtualMachineInstance {
+	c.nextIP++
+	ip := fmt.Sprintf("10.%d.%d.%d", 128+c.index, c.nextIP/250, c.nextIP%250+2)
+	mac := fmt.Sprintf("02:%02x:%02x:%02x:%02x:%02x", c.index, s.rng.Intn(256), s.rng.Intn(256), s.rng.Intn(256), s.rng.Intn(256))
+
+	return &kubevirtv1.VirtualMachineInstance{
+		TypeMeta: metav1.TypeMeta{Kind: "VirtualMachineInstance", APIVersion: kubevirtv1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              vm.Name,
+			Namespace:         vm.Namespace,
+			UID:               types.UID(fmt.Sprintf("sim-vmi-%s-%s", vm.Name, s.randomSuffix())),
+			CreationTimestamp: metav1.Now(),
+			Labels:            vm.Spec.Template.ObjectMeta.Labels,
+		},
+		Spec: *vm.Spec.Template.Spec.DeepCopy(),
+		Status: kubevirtv1.VirtualMachineInstanceStatus{
+			Phase:    kubevirtv1.Running,
+			NodeName: node,
+			Interfaces: []kubevirtv1.VirtualMachineInstanceNetworkInterface{{
+				Name: "default",
+				IP:   ip,
+				IPs:  []string{ip},
+				MAC:  mac,
+			}},
+			GuestOSInfo: kubevirtv1.VirtualMachineInstanceGuestOSInfo{
+				Name:          "Red Hat Enterprise Linux",
+				Version:       "9.4",
+				ID:            "rhel",
+				KernelRelease: "5.14.0-427.el9.x86_64",
+			},
+			Conditions: []kubevirtv1.VirtualMachineInstanceCondition{{
+				Type:   kubevirtv1.VirtualMachineInstanceReady,
+				Status: k8sv1.ConditionTrue,
+			}},
+		},
+	}
+}
+
+// setVMStatus sets the printable status of a VM and the fields derived
+// from it
+func setVMStatus(vm *kubevirtv1.VirtualMachine, status kubevirtv1.VirtualMachinePrintableStatus) {
+	vm.Status.PrintableStatus = status
+	running := status == kubevirtv1.VirtualMachineStatusRunning || status == kubevirtv1.VirtualMachineStatusMigrating ||
+		status == kubevirtv1.VirtualMachineStatusPaused
+	vm.Status.Ready = running
+	vm.Status.Created = running || status == kubevirtv1.VirtualMachineStatusStopping
+
+	readyStatus := k8sv1.ConditionFalse
+	if running {
+		readyStatus = k8sv1.ConditionTrue
+	}
+	vm.Status.Conditions = []kubevirtv1.VirtualMachineCondition{{
+		Type:               kubevirtv1.VirtualMachineReady,
+		Status:             readyStatus,
+		LastTransitionTime: metav1.Now(),
+	}}
+}
+
+// runStrategy returns a pointer to a run strategy
+func runStrategy(strategy kubevirtv1.VirtualMachineRunStrategy) *kubevirtv1.VirtualMachineRunStrategy {
+	return &strategy
+}
@@ -0,0 +1,290 @@
+// Package simulator runs in-process fake KubeVirt clusters. Each cluster
+// serves synthetic VirtualMachine, VirtualMachineInstance and
+// VirtualMachineInstanceMigration objects through a kubecli.KubevirtClient,
+// and its worker Nodes through a kubernetes.Interface, so the regular
+// cluster watchers can be demoed and tested without a network.
+package simulator
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// Options tunes the simulated clusters
+type Options struct {
+	// VMsPerCluster is the number of VMs each cluster starts with
+	VMsPerCluster int
+	// NodesPerCluster is the number of worker nodes in each cluster
+	NodesPerCluster int
+	// MigrationDuration is how long a migration takes from creation to completion
+	MigrationDuration time.Duration
+	// MigrationFailureRate is the probability (0-1) that a migration fails
+	MigrationFailureRate float64
+	// BootDuration is how long a VM takes to start
+	BootDuration time.Duration
+	// CrashInterval is the mean time between lifecycle events in a cluster:
+	// a running VM crashing and recovering, or a stopped VM booting. Zero
+	// disables them.
+	CrashInterval time.Duration
+	// Seed seeds the random source; zero uses the current time
+	Seed int64
+}
+
+// DefaultOptions returns the default simulation options
+func DefaultOptions() Options {
+	return Options{
+		VMsPerCluster:        6,
+		NodesPerCluster:      3,
+		MigrationDuration:    20 * time.Second,
+		MigrationFailureRate: 0.1,
+		BootDuration:         5 * time.Second,
+		CrashInterval:        2 * time.Minute,
+	}
+}
+
+// Simulator owns the simulated clusters. All cluster state is guarded by a
+// single lock so migrations can move VMs between clusters atomically.
+type Simulator struct {
+	options Options
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	// mu guards the clusters, runs and random source. emitMu keeps watch
+	// events in the order their changes were made; it is taken before mu
+	// and held while events are delivered, after mu has been released.
+	mu       sync.Mutex
+	emitMu   sync.Mutex
+	rng      *rand.Rand
+	clusters map[string]*cluster
+	runs     map[string]*migrationRun
+}
+
+// cluster is one simulated KubeVirt cluster
+type cluster struct {
+	name  string
+	index int
+	nodes []string
+
+	vms        map[string]*kubevirtv1.VirtualMachine
+	vmis       map[string]*kubevirtv1.VirtualMachineInstance
+	migrations map[string]*kubevirtv1.VirtualMachineInstanceMigration
+	// nodeObjects holds the Node behind each name in nodes
+	nodeObjects map[string]*k8sv1.Node
+
+	vmEvents        *watch.Broadcaster
+	vmiEvents       *watch.Broadcaster
+	migrationEvents *watch.Broadcaster
+	nodeEvents      *watch.Broadcaster
+
+	nextIP int
+}
+
+// event is a watch event waiting to be delivered
+type event struct {
+	broadcaster *watch.Broadcaster
+	eventType   watch.EventType
+	object      runtime.Object
+}
+
+// New creates a simulator. Clusters are created on demand by ClientFactory.
+func New(options Options) *Simulator {
+	seed := options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	if options.NodesPerCluster < 2 {
+		// Migrations need somewhere to go
+		options.NodesPerCluster = 2
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Simulator{
+		options:  options,
+		ctx:      ctx,
+		cancel:   cancel,
+		rng:      rand.New(rand.NewSource(seed)),
+		clusters: make(map[string]*cluster),
+		runs:     make(map[string]*migrationRun),
+	}
+}
+
+// ClientFactory creates (or returns) the simulated cluster for a cluster
+// configuration and a KubeVirt client talking to it. It satisfies
+// watcher.ClientFactory.
+func (s *Simulator) ClientFactory(config watcher.ClusterConfig) (*watcher.ClusterClients, error) {
+	s.addCluster(config.Name)
+	return &watcher.ClusterClients{
+		Kubernetes:       &kubernetesClient{sim: s, cluster: config.Name},
+		KubeVirt:         &kubevirtClient{sim: s, cluster: config.Name},
+		MigrationSyncURL: "sim://" + config.Name,
+	}, nil
+}
+
+// Stop ends all background activity and closes the watches
+func (s *Simulator) Stop() {
+	s.cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.clusters {
+		c.vmEvents.Shutdown()
+		c.vmiEvents.Shutdown()
+		c.migrationEvents.Shutdown()
+		c.nodeEvents.Shutdown()
+	}
+}
+
+// addCluster creates and seeds a cluster unless it already exists
+func (s *Simulator) addCluster(name string) {
+	s.update(func() []event {
+		if _, ok := s.clusters[name]; ok {
+			return nil
+		}
+
+		c := &cluster{
+			name:            name,
+			index:           len(s.clusters) + 1,
+			vms:             make(map[string]*kubevirtv1.VirtualMachine),
+			vmis:            make(map[string]*kubevirtv1.VirtualMachineInstance),
+			migrations:      make(map[string]*kubevirtv1.VirtualMachineInstanceMigration),
+			vmEvents:        watch.NewBroadcaster(1000, watch.WaitIfChannelFull),
+			vmiEvents:       watch.NewBroadcaster(1000, watch.WaitIfChannelFull),
+			migrationEvents: watch.NewBroadcaster(1000, watch.WaitIfChannelFull),
+			nodeObjects:     make(map[string]*k8sv1.Node),
+			nodeEvents:      watch.NewBroadcaster(1000, watch.WaitIfChannelFull),
+		}
+		s.clusters[name] = c
+		s.seedNodes(c)
+		s.seedCluster(c)
+		log.Printf("Simulating cluster %s with %d VMs on %d nodes", name, len(c.vms), len(c.nodes))
+		return nil
+	})
+
+	if s.options.CrashInterval > 0 {
+		go s.lifecycleLoop(name)
+	}
+}
+
+// update runs fn with the state locked and then delivers the events it
+// produced, in order
+func (s *Simulator) update(fn func() []event) {
+	s.emitMu.Lock()
+	defer s.emitMu.Unlock()
+
+	s.mu.Lock()
+	events := fn()
+	s.mu.Unlock()
+
+	for _, e := range events {
+		if err := e.broadcaster.Action(e.eventType, e.object); err != nil {
+			log.Printf("Simulator failed to deliver %s event: %v", e.eventType, err)
+		}
+	}
+}
+
+// updateErr is update for changes that can fail
+func (s *Simulator) updateErr(fn func() ([]event, error)) error {
+	var err error
+	s.update(func() []event {
+		var events []event
+		events, err = fn()
+		return events
+	})
+	return err
+}
+
+// after runs fn once d has passed, unless the simulator is stopped first
+func (s *Simulator) after(d time.Duration, fn func()) {
+	go func() {
+		select {
+		case <-s.ctx.Done():
+		case <-time.After(d):
+			fn()
+		}
+	}()
+}
+
+// sleep waits for d, returning false if ctx ends first
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// objectKey returns the namespace/name key used for all objects
+func objectKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// putVM stores a VM and returns its watch event
+func (c *cluster) putVM(vm *kubevirtv1.VirtualMachine, eventType watch.EventType) event {
+	c.vms[objectKey(vm.Namespace, vm.Name)] = vm
+	return event{c.vmEvents, eventType, vm.DeepCopy()}
+}
+
+// deleteVM removes a VM and returns its watch event
+func (c *cluster) deleteVM(vm *kubevirtv1.VirtualMachine) event {
+	delete(c.vms, objectKey(vm.Namespace, vm.Name))
+	return event{c.vmEvents, watch.Deleted, vm.DeepCopy()}
+}
+
+// putVMI stores a VMI and returns its watch event
+func (c *cluster) putVMI(vmi *kubevirtv1.VirtualMachineInstance, eventType watch.EventType) event {
+	c.vmis[objectKey(vmi.Namespace, vmi.Name)] = vmi
+	return event{c.vmiEvents, eventType, vmi.DeepCopy()}
+}
+
+// deleteVMI removes a VMI and returns its watch event
+func (c *cluster) deleteVMI(vmi *kubevirtv1.VirtualMachineInstance) event {
+	delete(c.vmis, objectKey(vmi.Namespace, vmi.Name))
+	return event{c.vmiEvents, watch.Deleted, vmi.DeepCopy()}
+}
+
+// putMigration stores a migration and returns its watch event
+func (c *cluster) putMigration(migration *kubevirtv1.VirtualMachineInstanceMigration, eventType watch.EventType) event {
+	c.migrations[objectKey(migration.Namespace, migration.Name)] = migration
+	return event{c.migrationEvents, eventType, migration.DeepCopy()}
+}
+
+// deleteMigration removes a migration and returns its watch event
+func (c *cluster) deleteMigration(migration *kubevirtv1.VirtualMachineInstanceMigration) event {
+	delete(c.migrations, objectKey(migration.Namespace, migration.Name))
+	return event{c.migrationEvents, watch.Deleted, migration.DeepCopy()}
+}
+
+// randomNode picks a node, avoiding exclude when possible
+func (s *Simulator) randomNode(c *cluster, exclude string) string {
+	candidates := make([]string, 0, len(c.nodes))
+	for _, node := range c.nodes {
+		if node != exclude {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		return exclude
+	}
+	return candidates[s.rng.Intn(len(candidates))]
+}
+
+// randomSuffix returns a short random name suffix like Kubernetes generates
+func (s *Simulator) randomSuffix() string {
+	const letters = "bcdfghjklmnpqrstvwxz2456789"
+	suffix := make([]byte, 5)
+	for i := range suffix {
+		suffix[i] = letters[s.rng.Intn(len(letters))]
+	}
+	return string(suffix)
+}
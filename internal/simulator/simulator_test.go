@@ -0,0 +1,243 @@
+package simulator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/mocks"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// testOptions keeps simulated time short and the outcome predictable
+func testOptions() Options {
+	return Options{
+		VMsPerCluster:     5,
+		NodesPerCluster:   3,
+		MigrationDuration: 60 * time.Millisecond,
+		BootDuration:      20 * time.Millisecond,
+		Seed:              1,
+	}
+}
+
+var _ = Describe("Simulator", func() {
+	var (
+		sim    *Simulator
+		alpha  kubecli.KubevirtClient
+		beta   kubecli.KubevirtClient
+		ctx    = context.Background()
+		client = func(name string) kubecli.KubevirtClient {
+			clients, err := sim.ClientFactory(watcher.ClusterConfig{Name: name})
+			Expect(err).NotTo(HaveOccurred())
+			return clients.KubeVirt
+		}
+		runningVM = func(c kubecli.KubevirtClient) *kubevirtv1.VirtualMachineInstance {
+			vmis, err := c.VirtualMachineInstance("").List(ctx, metav1.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vmis.Items).NotTo(BeEmpty())
+			return &vmis.Items[0]
+		}
+		migrationPhase = func(c kubecli.KubevirtClient, name string) func() kubevirtv1.VirtualMachineInstanceMigrationPhase {
+			return func() kubevirtv1.VirtualMachineInstanceMigrationPhase {
+				migration, err := c.VirtualMachineInstanceMigration(simulatedNamespace).Get(ctx, name, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				return migration.Status.Phase
+			}
+		}
+		vmStatus = func(c kubecli.KubevirtClient, name string) func() kubevirtv1.VirtualMachinePrintableStatus {
+			return func() kubevirtv1.VirtualMachinePrintableStatus {
+				vm, err := c.VirtualMachine(simulatedNamespace).Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					return ""
+				}
+				return vm.Status.PrintableStatus
+			}
+		}
+	)
+
+	JustBeforeEach(func() {
+		alpha = client("alpha")
+		beta = client("beta")
+	})
+
+	AfterEach(func() {
+		sim.Stop()
+	})
+
+	Context("with migrations that succeed", func() {
+		BeforeEach(func() {
+			sim = New(testOptions())
+		})
+
+		It("should seed each cluster with VMs", func() {
+			vms, err := alpha.VirtualMachine("").List(ctx, metav1.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vms.Items).To(HaveLen(5))
+			Expect(vms.Items[0].Name).To(HavePrefix("alpha-"))
+			Expect(runningVM(alpha).Status.Interfaces[0].IP).NotTo(BeEmpty())
+		})
+
+		It("should move a VM to another node within a cluster", func() {
+			vmi := runningVM(alpha)
+			migration, err := alpha.VirtualMachineInstanceMigration(simulatedNamespace).Create(ctx, &kubevirtv1.VirtualMachineInstanceMigration{
+				ObjectMeta: metav1.ObjectMeta{GenerateName: vmi.Name + "-migration-"},
+				Spec:       kubevirtv1.VirtualMachineInstanceMigrationSpec{VMIName: vmi.Name},
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(migration.Status.Phase).To(Equal(kubevirtv1.MigrationPending))
+
+			Eventually(migrationPhase(alpha, migration.Name)).Should(Equal(kubevirtv1.MigrationSucceeded))
+
+			moved, err := alpha.VirtualMachineInstance(simulatedNamespace).Get(ctx, vmi.Name, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(moved.Status.NodeName).NotTo(Equal(vmi.Status.NodeName))
+			Expect(vmStatus(alpha, vmi.Name)()).To(Equal(kubevirtv1.VirtualMachineStatusRunning))
+		})
+
+		It("should move a VM between clusters once both halves exist", func() {
+			vmi := runningVM(alpha)
+			_, err := beta.VirtualMachineInstanceMigration(simulatedNamespace).Create(ctx, &kubevirtv1.VirtualMachineInstanceMigration{
+				ObjectMeta: metav1.ObjectMeta{Name: "m1-receive"},
+				Spec: kubevirtv1.VirtualMachineInstanceMigrationSpec{
+					VMIName: vmi.Name,
+					Receive: &kubevirtv1.VirtualMachineInstanceMigrationTarget{MigrationID: "m1"},
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = alpha.VirtualMachineInstanceMigration(simulatedNamespace).Create(ctx, &kubevirtv1.VirtualMachineInstanceMigration{
+				ObjectMeta: metav1.ObjectMeta{Name: "m1-send"},
+				Spec: kubevirtv1.VirtualMachineInstanceMigrationSpec{
+					VMIName: vmi.Name,
+					SendTo:  &kubevirtv1.VirtualMachineInstanceMigrationSource{MigrationID: "m1", ConnectURL: "sim://beta"},
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(vmStatus(beta, vmi.Name)()).To(Equal(kubevirtv1.VirtualMachineStatusWaitingForReceiver))
+			Eventually(migrationPhase(beta, "m1-receive")).Should(Equal(kubevirtv1.MigrationSucceeded))
+			Expect(migrationPhase(alpha, "m1-send")()).To(Equal(kubevirtv1.MigrationSucceeded))
+
+			Expect(vmStatus(beta, vmi.Name)()).To(Equal(kubevirtv1.VirtualMachineStatusRunning))
+			Expect(vmStatus(alpha, vmi.Name)()).To(BeEmpty())
+		})
+
+		It("should abort a migration whose VMIM is deleted", func() {
+			vmi := runningVM(alpha)
+			migration, err := alpha.VirtualMachineInstanceMigration(simulatedNamespace).Create(ctx, &kubevirtv1.VirtualMachineInstanceMigration{
+				ObjectMeta: metav1.ObjectMeta{Name: "doomed"},
+				Spec:       kubevirtv1.VirtualMachineInstanceMigrationSpec{VMIName: vmi.Name},
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(vmStatus(alpha, vmi.Name)).Should(Equal(kubevirtv1.VirtualMachineStatusMigrating))
+
+			Expect(alpha.VirtualMachineInstanceMigration(simulatedNamespace).Delete(ctx, migration.Name, metav1.DeleteOptions{})).To(Succeed())
+
+			_, err = alpha.VirtualMachineInstanceMigration(simulatedNamespace).Get(ctx, migration.Name, metav1.GetOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(vmStatus(alpha, vmi.Name)()).To(Equal(kubevirtv1.VirtualMachineStatusRunning))
+		})
+
+		It("should stop and start VMs", func() {
+			vmi := runningVM(alpha)
+
+			Expect(alpha.VirtualMachine(simulatedNamespace).Stop(ctx, vmi.Name, &kubevirtv1.StopOptions{})).To(Succeed())
+			Eventually(vmStatus(alpha, vmi.Name)).Should(Equal(kubevirtv1.VirtualMachineStatusStopped))
+			Expect(alpha.VirtualMachine(simulatedNamespace).Stop(ctx, vmi.Name, &kubevirtv1.StopOptions{})).NotTo(Succeed())
+
+			Expect(alpha.VirtualMachine(simulatedNamespace).Start(ctx, vmi.Name, &kubevirtv1.StartOptions{})).To(Succeed())
+			Eventually(vmStatus(alpha, vmi.Name)).Should(Equal(kubevirtv1.VirtualMachineStatusRunning))
+		})
+	})
+
+	Context("with migrations that fail", func() {
+		BeforeEach(func() {
+			options := testOptions()
+			options.MigrationFailureRate = 1
+			sim = New(options)
+		})
+
+		It("should report the failure and leave the VM running on its node", func() {
+			vmi := runningVM(alpha)
+			migration, err := alpha.VirtualMachineInstanceMigration(simulatedNamespace).Create(ctx, &kubevirtv1.VirtualMachineInstanceMigration{
+				ObjectMeta: metav1.ObjectMeta{Name: "unlucky"},
+				Spec:       kubevirtv1.VirtualMachineInstanceMigrationSpec{VMIName: vmi.Name},
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(migrationPhase(alpha, migration.Name)).Should(Equal(kubevirtv1.MigrationFailed))
+
+			failed, err := alpha.VirtualMachineInstanceMigration(simulatedNamespace).Get(ctx, migration.Name, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed.Status.MigrationState.Failed).To(BeTrue())
+			Expect(failed.Status.MigrationState.FailureReason).NotTo(BeEmpty())
+
+			stayed, err := alpha.VirtualMachineInstance(simulatedNamespace).Get(ctx, vmi.Name, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stayed.Status.NodeName).To(Equal(vmi.Status.NodeName))
+		})
+	})
+})
+
+var _ = Describe("Simulated clusters behind the VM watcher", func() {
+	It("should feed the store through the regular watch path", func() {
+		configPath := filepath.Join(GinkgoT().TempDir(), "datacenters.yaml")
+		Expect(os.WriteFile(configPath, []byte(`datacenters:
+  - id: dc-test-1
+    name: "Test DC 1"
+    clusters:
+    - name: alpha
+  - id: dc-test-2
+    name: "Test DC 2"
+    clusters:
+    - name: beta
+`), 0o644)).To(Succeed())
+
+		sim := New(testOptions())
+		defer sim.Stop()
+
+		store := mocks.NewMockStore()
+		store.InitializeWithSampleData()
+
+		options := watcher.DefaultOptions()
+		options.ClientFactory = sim.ClientFactory
+		w, err := watcher.NewVMWatcher(store, configPath, options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Start()).To(Succeed())
+		defer w.Stop()
+
+		clusterVMs := func(dcID, cluster string) func() int {
+			return func() int {
+				count := 0
+				for _, dc := range store.GetDatacenters().Datacenters {
+					for _, vm := range dc.VMs {
+						if dc.ID == dcID && vm.Cluster == cluster {
+							count++
+						}
+					}
+				}
+				return count
+			}
+		}
+		Eventually(clusterVMs("dc-test-1", "alpha"), 5*time.Second).Should(Equal(5))
+		Eventually(clusterVMs("dc-test-2", "beta"), 5*time.Second).Should(Equal(5))
+
+		nodes, err := store.GetNodes("alpha")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes).To(HaveLen(3))
+		placed := 0
+		for _, node := range nodes {
+			Expect(node.Ready).To(BeTrue())
+			Expect(node.Zone).NotTo(BeEmpty())
+			placed += len(node.VMs)
+		}
+		Expect(placed).To(BeNumerically(">", 0))
+	})
+})
@@ -3,8 +3,11 @@ package watcher
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/kubernetes"
+	"kubevirt.io/client-go/kubecli"
 )
 
 // DatacenterConfig represents the datacenter configuration from datacenters.yaml
@@ -25,13 +28,50 @@ type DatacenterDefinition struct {
 type ClusterInfo struct {
 	Name       string `yaml:"name"`
 	Kubeconfig string `yaml:"kubeconfig"`
+	// MigrationSyncURL is the address of the cluster's migration
+	// synchronization controller, used as the sendTo connectURL when
+	// decentralized live migrations target this cluster
+	MigrationSyncURL string `yaml:"migrationSyncURL,omitempty"`
 }
 
 // ClusterConfig represents a cluster configuration
 type ClusterConfig struct {
-	Name         string
-	Kubeconfig   string
-	DatacenterID string
+	Name             string
+	Kubeconfig       string
+	DatacenterID     string
+	MigrationSyncURL string
+}
+
+// ClusterClients are the API clients a cluster watcher talks to
+type ClusterClients struct {
+	Kubernetes kubernetes.Interface
+	KubeVirt   kubecli.KubevirtClient
+	// MigrationSyncURL, when set, replaces the configured migrationSyncURL
+	MigrationSyncURL string
+}
+
+// ClientFactory creates the clients for a cluster
+type ClientFactory func(cluster ClusterConfig) (*ClusterClients, error)
+
+// Options tunes the behaviour of the VM watcher
+type Options struct {
+	// MigrationStatusRetention is how long a finished migration's status,
+	// source and target stay on the VM record before they are cleared
+	MigrationStatusRetention time.Duration
+
+	// ClientFactory creates the cluster clients. When nil, clients are
+	// built from each cluster's kubeconfig.
+	ClientFactory ClientFactory
+
+	// Recorder, when set, receives every event the cluster watchers see
+	Recorder *Recorder
+}
+
+// DefaultOptions returns the default watcher options
+func DefaultOptions() Options {
+	return Options{
+		MigrationStatusRetention: 5 * time.Minute,
+	}
 }
 
 // LoadDatacenterConfig loads the datacenter configuration from the YAML file
@@ -56,9 +96,10 @@ func (dc *DatacenterConfig) GetClusters() []ClusterConfig {
 	for _, datacenter := range dc.Datacenters {
 		for _, clusterInfo := range datacenter.Clusters {
 			clusters = append(clusters, ClusterConfig{
-				Name:         clusterInfo.Name,
-				Kubeconfig:   clusterInfo.Kubeconfig,
-				DatacenterID: datacenter.ID,
+				Name:             clusterInfo.Name,
+				Kubeconfig:       clusterInfo.Kubeconfig,
+				DatacenterID:     datacenter.ID,
+				MigrationSyncURL: clusterInfo.MigrationSyncURL,
 			})
 		}
 	}
@@ -0,0 +1,245 @@
+package watcher
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+// CorrelateMigrations pairs the outgoing (source cluster) and incoming
+// (target cluster) halves of decentralized live migrations by MigrationID
+// into logical migrations. Migrations without a MigrationID are ignored.
+// Halves whose peer has not been seen yet are returned on their own.
+func CorrelateMigrations(migrations []models.Migration) []models.LogicalMigration {
+	type pair struct {
+		outgoing *models.Migration
+		incoming *models.Migration
+	}
+
+	pairs := make(map[string]*pair)
+	var order []string
+	for i := range migrations {
+		migration := &migrations[i]
+		if migration.MigrationID == "" {
+			continue
+		}
+		p, ok := pairs[migration.MigrationID]
+		if !ok {
+			p = &pair{}
+			pairs[migration.MigrationID] = p
+			order = append(order, migration.MigrationID)
+		}
+		switch migration.Direction {
+		case "outgoing":
+			p.outgoing = newerMigration(p.outgoing, migration)
+		case "incoming":
+			p.incoming = newerMigration(p.incoming, migration)
+		}
+	}
+
+	logical := make([]models.LogicalMigration, 0, len(order))
+	for _, id := range order {
+		p := pairs[id]
+		if p.outgoing == nil && p.incoming == nil {
+			continue
+		}
+		logical = append(logical, correlatePair(id, p.outgoing, p.incoming))
+	}
+
+	// Most recent first
+	sort.SliceStable(logical, func(i, j int) bool {
+		return logicalStart(logical[i]).After(logicalStart(logical[j]))
+	})
+
+	return logical
+}
+
+// newerMigration returns the most recently created of two migration records
+func newerMigration(current, candidate *models.Migration) *models.Migration {
+	if current == nil || candidate.CreatedAt.After(current.CreatedAt) {
+		return candidate
+	}
+	return current
+}
+
+// correlatePair builds a logical migration from its two halves, either of
+// which may be nil
+func correlatePair(migrationID string, outgoing, incoming *models.Migration) models.LogicalMigration {
+	logical := models.LogicalMigration{
+		MigrationID: migrationID,
+		Outgoing:    outgoing,
+		Incoming:    incoming,
+	}
+
+	if outgoing != nil {
+		logical.VMName = outgoing.VMName
+		logical.Namespace = outgoing.Namespace
+		logical.SourceCluster = outgoing.Cluster
+		logical.SourceDatacenterID = outgoing.DatacenterID
+	}
+	if incoming != nil {
+		if logical.VMName == "" {
+			logical.VMName = incoming.VMName
+			logical.Namespace = incoming.Namespace
+		}
+		logical.TargetCluster = incoming.Cluster
+		logical.TargetDatacenterID = incoming.DatacenterID
+	}
+
+	logical.Phase, logical.Completed = overallPhase(outgoing, incoming)
+
+	// Overall timing spans the earliest start to the latest end
+	for _, half := range []*models.Migration{outgoing, incoming} {
+		if half == nil {
+			continue
+		}
+		start := half.StartTime
+		if start == nil && !half.CreatedAt.IsZero() {
+			created := half.CreatedAt
+			start = &created
+		}
+		if start != nil && (logical.StartTime == nil || start.Before(*logical.StartTime)) {
+			logical.StartTime = start
+		}
+		if half.EndTime != nil && (logical.EndTime == nil || half.EndTime.After(*logical.EndTime)) {
+			logical.EndTime = half.EndTime
+		}
+	}
+
+	if logical.StartTime != nil {
+		end := time.Now()
+		if logical.Completed && logical.EndTime != nil {
+			end = *logical.EndTime
+		}
+		logical.DurationSeconds = end.Sub(*logical.StartTime).Seconds()
+	}
+
+	return logical
+}
+
+// overallPhase derives the phase and completion of a logical migration.
+// A failure or abort on either side fails the whole migration; it only
+// succeeds once both sides have succeeded.
+func overallPhase(outgoing, incoming *models.Migration) (string, bool) {
+	if outgoing == nil {
+		return incoming.Phase, incoming.Completed && incoming.Phase != "Succeeded"
+	}
+	if incoming == nil {
+		return outgoing.Phase, outgoing.Completed && outgoing.Phase != "Succeeded"
+	}
+
+	for _, phase := range []string{"Failed", "Aborted", "Terminating"} {
+		if outgoing.Phase == phase || incoming.Phase == phase {
+			return phase, true
+		}
+	}
+	if outgoing.Phase == "Succeeded" && incoming.Phase == "Succeeded" {
+		return "Succeeded", true
+	}
+	// Report the side that is further behind while the migration runs
+	if outgoing.Phase == "Succeeded" {
+		return incoming.Phase, false
+	}
+	return outgoing.Phase, false
+}
+
+// logicalStart returns the start time used to order logical migrations
+func logicalStart(logical models.LogicalMigration) time.Time {
+	if logical.StartTime != nil {
+		return *logical.StartTime
+	}
+	return time.Time{}
+}
+
+// correlateMigration fills in the cross-cluster fields of a migration from
+// its peer in the store and updates the peer with this side's information.
+// It returns the correlated logical migration, or nil if the migration is not
+// part of a decentralized live migration.
+func (cw *ClusterWatcher) correlateMigration(migration *models.Migration) *models.LogicalMigration {
+	if migration.MigrationID == "" || (migration.Direction != "outgoing" && migration.Direction != "incoming") {
+		return nil
+	}
+
+	all, err := cw.dataStore.GetAllMigrations()
+	if err != nil {
+		log.Printf("Failed to list migrations to correlate %s: %v", migration.ID, err)
+		return nil
+	}
+
+	var peer *models.Migration
+	for i := range all {
+		candidate := &all[i]
+		if candidate.MigrationID == migration.MigrationID && candidate.Direction != migration.Direction && candidate.ID != migration.ID {
+			peer = newerMigration(peer, candidate)
+		}
+	}
+
+	outgoing, incoming := migration, peer
+	if migration.Direction == "incoming" {
+		outgoing, incoming = peer, migration
+	}
+
+	if outgoing != nil {
+		migration.SourceCluster = outgoing.Cluster
+		migration.SourceDatacenterID = outgoing.DatacenterID
+	}
+	if incoming != nil {
+		migration.TargetCluster = incoming.Cluster
+		migration.TargetDatacenterID = incoming.DatacenterID
+	}
+
+	if peer != nil && (peer.SourceCluster != migration.SourceCluster || peer.TargetCluster != migration.TargetCluster ||
+		peer.SourceDatacenterID != migration.SourceDatacenterID || peer.TargetDatacenterID != migration.TargetDatacenterID) {
+		peer.SourceCluster = migration.SourceCluster
+		peer.TargetCluster = migration.TargetCluster
+		peer.SourceDatacenterID = migration.SourceDatacenterID
+		peer.TargetDatacenterID = migration.TargetDatacenterID
+		if err := cw.dataStore.UpdateMigration(*peer); err != nil {
+			log.Printf("Failed to update correlated migration %s: %v", peer.ID, err)
+		} else {
+			DefaultHub.BroadcastEvent("migration:updated", map[string]interface{}{"datacenter": peer.DatacenterID, "migration": peer})
+		}
+	}
+
+	logical := correlatePair(migration.MigrationID, outgoing, incoming)
+	return &logical
+}
+
+// moveVMForLogicalMigration moves the VM record from the source to the
+// target datacenter once a cross-datacenter migration has succeeded
+func (cw *ClusterWatcher) moveVMForLogicalMigration(logical *models.LogicalMigration) {
+	if logical.Phase != "Succeeded" || logical.Outgoing == nil || logical.Incoming == nil {
+		return
+	}
+	fromDC, toDC := logical.SourceDatacenterID, logical.TargetDatacenterID
+	if fromDC == "" || toDC == "" || fromDC == toDC {
+		return
+	}
+
+	sourceName, targetName := logical.Outgoing.VMName, logical.Incoming.VMName
+	if _, err := cw.dataStore.GetVM(fromDC, sourceName); err != nil {
+		// Already moved (or removed by the source cluster watcher)
+		return
+	}
+
+	// The target cluster watcher may already have added the VM
+	if _, err := cw.dataStore.GetVM(toDC, targetName); err == nil {
+		if err := cw.dataStore.RemoveVM(fromDC, sourceName); err != nil {
+			log.Printf("Failed to remove migrated VM %s from datacenter %s: %v", sourceName, fromDC, err)
+			return
+		}
+		log.Printf("Removed migrated VM %s from datacenter %s (already present in %s)", sourceName, fromDC, toDC)
+		DefaultHub.BroadcastEvent("vm:removed", map[string]interface{}{"datacenter": fromDC, "vmName": sourceName})
+		return
+	}
+
+	vm, err := cw.dataStore.MigrateVM(sourceName, fromDC, toDC)
+	if err != nil {
+		log.Printf("Failed to move VM %s from %s to %s: %v", sourceName, fromDC, toDC, err)
+		return
+	}
+	log.Printf("Moved VM %s from datacenter %s to %s after migration %s", vm.Name, fromDC, toDC, logical.MigrationID)
+	DefaultHub.BroadcastEvent("vm:migrated", map[string]interface{}{"from": fromDC, "to": toDC, "datacenters": []string{fromDC, toDC}, "vm": vm, "migrationId": logical.MigrationID})
+}
@@ -0,0 +1,64 @@
+package watcher
+
+import (
+	"sync"
+	"time"
+)
+
+// debouncer collapses bursts of calls for the same key into a single
+// invocation of fn once the key has been quiet for the configured interval.
+// It is used to merge VirtualMachine and VirtualMachineInstance events for
+// the same object into one store write.
+type debouncer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	timers   map[string]*time.Timer
+	fn       func(key string)
+}
+
+// newDebouncer creates a debouncer calling fn for each key after interval
+func newDebouncer(interval time.Duration, fn func(key string)) *debouncer {
+	return &debouncer{
+		interval: interval,
+		timers:   make(map[string]*time.Timer),
+		fn:       fn,
+	}
+}
+
+// Trigger schedules fn for key, postponing any pending invocation for it
+func (d *debouncer) Trigger(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if t, ok := d.timers[key]; ok {
+		t.Stop()
+	}
+	d.timers[key] = time.AfterFunc(d.interval, func() {
+		d.mu.Lock()
+		delete(d.timers, key)
+		d.mu.Unlock()
+		d.fn(key)
+	})
+}
+
+// Cancel drops a pending invocation for key, if any
+func (d *debouncer) Cancel(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if t, ok := d.timers[key]; ok {
+		t.Stop()
+		delete(d.timers, key)
+	}
+}
+
+// Stop drops all pending invocations
+func (d *debouncer) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key, t := range d.timers {
+		t.Stop()
+		delete(d.timers, key)
+	}
+}
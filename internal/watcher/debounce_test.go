@@ -0,0 +1,67 @@
+package watcher
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Debouncer", func() {
+	var (
+		mu    sync.Mutex
+		calls map[string]int
+		d     *debouncer
+	)
+
+	BeforeEach(func() {
+		calls = make(map[string]int)
+		d = newDebouncer(20*time.Millisecond, func(key string) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[key]++
+		})
+		DeferCleanup(d.Stop)
+	})
+
+	count := func(key string) func() int {
+		return func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return calls[key]
+		}
+	}
+
+	It("should coalesce a burst into one call per key", func() {
+		for i := 0; i < 5; i++ {
+			d.Trigger("demo/web-1")
+		}
+		d.Trigger("demo/web-2")
+
+		Eventually(count("demo/web-1")).Should(Equal(1))
+		Eventually(count("demo/web-2")).Should(Equal(1))
+		Consistently(count("demo/web-1"), 60*time.Millisecond).Should(Equal(1))
+	})
+
+	It("should postpone the call while the key keeps being triggered", func() {
+		for i := 0; i < 5; i++ {
+			d.Trigger("demo/web-1")
+			time.Sleep(5 * time.Millisecond)
+		}
+		Expect(count("demo/web-1")()).To(BeZero())
+		Eventually(count("demo/web-1")).Should(Equal(1))
+	})
+
+	It("should drop cancelled and stopped calls", func() {
+		d.Trigger("demo/web-1")
+		d.Trigger("demo/web-2")
+		d.Cancel("demo/web-1")
+		Eventually(count("demo/web-2")).Should(Equal(1))
+
+		d.Trigger("demo/web-2")
+		d.Stop()
+		Consistently(count("demo/web-1"), 60*time.Millisecond).Should(BeZero())
+		Expect(count("demo/web-2")()).To(Equal(1))
+	})
+})
@@ -2,36 +2,213 @@ package watcher
 
 import (
 	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
 	"sync"
 	"time"
 )
 
+// DefaultBufferSize is how many recent events a hub keeps for replay
+const DefaultBufferSize = 1024
+
+// Event is an event of the hub. IDs start at 1 and increase by one with
+// every event, so a gap tells a client it missed events.
+type Event struct {
+	ID   uint64
+	Type string
+	// Origin is the replica the event happened on, empty for this one
+	Origin string
+	// Datacenters are the datacenters the event is about, taken from the
+	// "datacenter" and "datacenters" fields of its payload
+	Datacenters []string
+	// Data is the event as JSON: id, type, payload and timestamp
+	Data string
+}
+
+// Filter selects the events a client receives. Types are path.Match
+// patterns such as "migration:*": nil matches every type and an empty list
+// none. Datacenters are datacenter IDs and an empty list matches every
+// event; when it is set, events that are not about a datacenter are left
+// out.
+type Filter struct {
+	Types       []string `json:"types"`
+	Datacenters []string `json:"datacenters,omitempty"`
+}
+
+// Validate checks the type patterns of a filter
+func (f Filter) Validate() error {
+	for _, pattern := range f.Types {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid event type pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether an event passes the filter
+func (f Filter) Matches(event Event) bool {
+	return f.matchesType(event.Type) && f.matchesDatacenter(event.Datacenters)
+}
+
+func (f Filter) matchesType(typ string) bool {
+	if f.Types == nil {
+		return true
+	}
+	for _, pattern := range f.Types {
+		if ok, _ := path.Match(pattern, typ); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) matchesDatacenter(datacenters []string) bool {
+	if len(f.Datacenters) == 0 {
+		return true
+	}
+	for _, want := range f.Datacenters {
+		for _, dc := range datacenters {
+			if dc == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClientStats describes a registered client of a hub
+type ClientStats struct {
+	ID          uint64    `json:"id"`
+	Filter      Filter    `json:"filter"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	Delivered   uint64    `json:"delivered"`
+	// Dropped counts the events that matched the filter but were not
+	// sent because the client's channel was full
+	Dropped uint64 `json:"dropped"`
+	Pending int    `json:"pending"` // Events waiting in the channel
+}
+
+// PeerStats describes a replica a bus shares events with
+type PeerStats struct {
+	URL  string `json:"url"`
+	Sent uint64 `json:"sent"` // Events posted to the peer
+	// Failed counts failed posts, which are retried
+	Failed uint64 `json:"failed"`
+	// Dropped counts the events left out because the queue was full
+	Dropped   uint64 `json:"dropped"`
+	Queued    int    `json:"queued"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// HubStats describes a hub and its clients
+type HubStats struct {
+	LastEventID uint64        `json:"lastEventId"`
+	Buffered    int           `json:"buffered"`
+	BufferSize  int           `json:"bufferSize"`
+	Clients     []ClientStats `json:"clients"`
+	Peers       []PeerStats   `json:"peers,omitempty"` // Set by buses that share events with other replicas
+}
+
+// EventBus numbers and buffers events and hands them to registered
+// clients. EventHub keeps them within one process; a bus can also share
+// them with other replicas.
+type EventBus interface {
+	BroadcastEvent(typ string, payload interface{})
+	Register() chan Event
+	RegisterFiltered(filter Filter) chan Event
+	RegisterSince(lastID uint64, filter Filter) (ch chan Event, missed []Event, ok bool)
+	Unregister(ch chan Event)
+	SetFilter(ch chan Event, filter Filter)
+	Dropped(ch chan Event) uint64
+	Since(lastID uint64) (missed []Event, ok bool)
+	LastID() uint64
+	Stats() HubStats
+}
+
+// client is the state of a registered channel
+type client struct {
+	id          uint64
+	filter      Filter
+	connectedAt time.Time
+	delivered   uint64
+	dropped     uint64
+}
+
 // EventHub is a very small in-memory pub/sub hub used to broadcast events
 // from the VM watcher to connected SSE clients. It is intentionally simple
-// (no persistence) and suitable for single-node deployments or as a shim
-// while introducing a production pub/sub (Redis, NATS, etc.).
+// (no persistence) and only sees the events of its own process; the
+// eventbus package shares them between replicas. The most recent events
+// are kept in a ring buffer so that reconnecting clients can replay the
+// ones they missed.
 type EventHub struct {
-	mu      sync.Mutex
-	clients map[chan string]struct{}
+	mu         sync.Mutex
+	clients    map[chan Event]*client
+	lastClient uint64 // ID of the latest client
+	lastID     uint64
+	buffer     []Event // Ring of the most recent events
+	next       int     // Index in buffer of the next event
+	count      int     // Events in buffer
 }
 
 // NewEventHub creates a new event hub
 func NewEventHub() *EventHub {
-	return &EventHub{clients: make(map[chan string]struct{})}
+	return NewEventHubWithBuffer(DefaultBufferSize)
+}
+
+// NewEventHubWithBuffer creates a new event hub that keeps size events for
+// replay
+func NewEventHubWithBuffer(size int) *EventHub {
+	if size < 1 {
+		size = 1
+	}
+	return &EventHub{
+		clients: make(map[chan Event]*client),
+		buffer:  make([]Event, size),
+	}
 }
 
 // Register adds a new subscriber and returns a channel which will receive
-// stringified JSON event payloads. The caller must call Unregister when done.
-func (h *EventHub) Register() chan string {
-	ch := make(chan string, 16)
+// the events. The caller must call Unregister when done.
+func (h *EventHub) Register() chan Event {
+	return h.RegisterFiltered(Filter{})
+}
+
+// RegisterFiltered adds a subscriber that only receives the events
+// matching filter
+func (h *EventHub) RegisterFiltered(filter Filter) chan Event {
 	h.mu.Lock()
-	h.clients[ch] = struct{}{}
-	h.mu.Unlock()
+	defer h.mu.Unlock()
+	return h.register(filter)
+}
+
+// RegisterSince adds a subscriber that has seen the events up to lastID
+// and also returns the buffered events after it that match filter. ok is
+// false when some of those events are no longer buffered, or lastID is
+// from before a restart of the hub; missed then holds what is left.
+func (h *EventHub) RegisterSince(lastID uint64, filter Filter) (ch chan Event, missed []Event, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch = h.register(filter)
+	all, ok := h.since(lastID)
+	missed = []Event{}
+	for _, event := range all {
+		if filter.Matches(event) {
+			missed = append(missed, event)
+		}
+	}
+	return ch, missed, ok
+}
+
+func (h *EventHub) register(filter Filter) chan Event {
+	ch := make(chan Event, 16)
+	h.lastClient++
+	h.clients[ch] = &client{id: h.lastClient, filter: filter, connectedAt: time.Now()}
 	return ch
 }
 
 // Unregister removes a subscriber and closes the channel.
-func (h *EventHub) Unregister(ch chan string) {
+func (h *EventHub) Unregister(ch chan Event) {
 	h.mu.Lock()
 	if _, ok := h.clients[ch]; ok {
 		delete(h.clients, ch)
@@ -40,34 +217,163 @@ func (h *EventHub) Unregister(ch chan string) {
 	h.mu.Unlock()
 }
 
-// Broadcast sends the given message to all registered clients. It does a
-// non-blocking send per-client to avoid a slow/blocked client from stalling
-// the hub. Messages should already be JSON-encoded strings.
-func (h *EventHub) Broadcast(msg string) {
+// SetFilter changes which events a subscriber receives
+func (h *EventHub) SetFilter(ch chan Event, filter Filter) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	for ch := range h.clients {
-		select {
-		case ch <- msg:
-		default:
-			// drop the message for slow listeners
+	if c, ok := h.clients[ch]; ok {
+		c.filter = filter
+	}
+}
+
+// Dropped returns how many events were dropped for a subscriber
+func (h *EventHub) Dropped(ch chan Event) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c, ok := h.clients[ch]; ok {
+		return c.dropped
+	}
+	return 0
+}
+
+// Stats returns the state of the hub and its clients, oldest client first
+func (h *EventHub) Stats() HubStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats := HubStats{
+		LastEventID: h.lastID,
+		Buffered:    h.count,
+		BufferSize:  len(h.buffer),
+		Clients:     []ClientStats{},
+	}
+	for ch, c := range h.clients {
+		stats.Clients = append(stats.Clients, ClientStats{
+			ID:          c.id,
+			Filter:      c.filter,
+			ConnectedAt: c.connectedAt,
+			Delivered:   c.delivered,
+			Dropped:     c.dropped,
+			Pending:     len(ch),
+		})
+	}
+	sort.Slice(stats.Clients, func(i, j int) bool { return stats.Clients[i].ID < stats.Clients[j].ID })
+	return stats
+}
+
+// Since returns the buffered events after lastID, oldest first. ok is
+// false when some of them are no longer buffered.
+func (h *EventHub) Since(lastID uint64) (missed []Event, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.since(lastID)
+}
+
+func (h *EventHub) since(lastID uint64) ([]Event, bool) {
+	if lastID > h.lastID {
+		return nil, false
+	}
+	oldest := h.lastID - uint64(h.count) + 1
+	ok := lastID+1 >= oldest
+
+	missed := []Event{}
+	for i := 0; i < h.count; i++ {
+		event := h.buffer[(h.next-h.count+i+len(h.buffer))%len(h.buffer)]
+		if event.ID > lastID {
+			missed = append(missed, event)
 		}
 	}
+	return missed, ok
+}
+
+// LastID returns the ID of the latest event, 0 before the first
+func (h *EventHub) LastID() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastID
 }
 
-// helper to serialize a generic event with a timestamp
+// BroadcastEvent numbers an event, buffers it and sends it to the
+// registered clients whose filter it matches. It does a non-blocking send
+// per-client to avoid a slow/blocked client from stalling the hub; such a
+// client sees its dropped count go up.
 func (h *EventHub) BroadcastEvent(typ string, payload interface{}) {
+	h.BroadcastFrom("", typ, payload)
+}
+
+// BroadcastFrom broadcasts an event that happened on another replica,
+// named origin
+func (h *EventHub) BroadcastFrom(origin, typ string, payload interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	env := map[string]interface{}{
+		"id":        h.lastID + 1,
 		"type":      typ,
 		"payload":   payload,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	}
+	if origin != "" {
+		env["origin"] = origin
+	}
 	b, err := json.Marshal(env)
 	if err != nil {
 		return
 	}
-	h.Broadcast(string(b))
+	h.lastID++
+	event := Event{ID: h.lastID, Type: typ, Origin: origin, Datacenters: payloadDatacenters(payload), Data: string(b)}
+
+	h.buffer[h.next] = event
+	h.next = (h.next + 1) % len(h.buffer)
+	if h.count < len(h.buffer) {
+		h.count++
+	}
+
+	for ch, c := range h.clients {
+		if !c.filter.Matches(event) {
+			continue
+		}
+		select {
+		case ch <- event:
+			c.delivered++
+		default:
+			// drop the event for slow listeners
+			c.dropped++
+		}
+	}
+}
+
+// payloadDatacenters returns the "datacenter" and "datacenters" fields of
+// a payload map
+func payloadDatacenters(payload interface{}) []string {
+	var datacenters []string
+	switch p := payload.(type) {
+	case map[string]interface{}:
+		if dc, ok := p["datacenter"].(string); ok && dc != "" {
+			datacenters = append(datacenters, dc)
+		}
+		switch list := p["datacenters"].(type) {
+		case []string:
+			for _, dc := range list {
+				if dc != "" {
+					datacenters = append(datacenters, dc)
+				}
+			}
+		case []interface{}: // A payload decoded from JSON
+			for _, item := range list {
+				if dc, ok := item.(string); ok && dc != "" {
+					datacenters = append(datacenters, dc)
+				}
+			}
+		}
+	case map[string]string:
+		if dc := p["datacenter"]; dc != "" {
+			datacenters = append(datacenters, dc)
+		}
+	}
+	return datacenters
 }
 
-// Shared hub instance used by the watcher and HTTP handlers in server package.
-var DefaultHub = NewEventHub()
+// Shared hub instance used by the watcher and HTTP handlers in server
+// package. It is replaced before the server starts when events are shared
+// between replicas.
+var DefaultHub EventBus = NewEventHub()
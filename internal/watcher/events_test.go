@@ -0,0 +1,165 @@
+package watcher
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Event hub", func() {
+	ids := func(events []Event) []uint64 {
+		result := []uint64{}
+		for _, event := range events {
+			result = append(result, event.ID)
+		}
+		return result
+	}
+
+	It("should number events and include the ID in the data", func() {
+		hub := NewEventHub()
+		ch := hub.Register()
+		defer hub.Unregister(ch)
+
+		hub.BroadcastEvent("vm:added", map[string]string{"vmId": "vm-1"})
+		hub.BroadcastEvent("vm:removed", map[string]string{"vmId": "vm-1"})
+
+		first, second := <-ch, <-ch
+		Expect(first.ID).To(Equal(uint64(1)))
+		Expect(second.ID).To(Equal(uint64(2)))
+		Expect(second.Type).To(Equal("vm:removed"))
+
+		var data map[string]interface{}
+		Expect(json.Unmarshal([]byte(second.Data), &data)).To(Succeed())
+		Expect(data).To(HaveKeyWithValue("id", BeNumerically("==", 2)))
+		Expect(data).To(HaveKeyWithValue("type", "vm:removed"))
+		Expect(hub.LastID()).To(Equal(uint64(2)))
+	})
+
+	It("should replay the buffered events after an ID", func() {
+		hub := NewEventHubWithBuffer(4)
+		for i := 0; i < 3; i++ {
+			hub.BroadcastEvent("vm:updated", nil)
+		}
+
+		missed, ok := hub.Since(1)
+		Expect(ok).To(BeTrue())
+		Expect(ids(missed)).To(Equal([]uint64{2, 3}))
+
+		missed, ok = hub.Since(3)
+		Expect(ok).To(BeTrue())
+		Expect(missed).To(BeEmpty())
+
+		missed, ok = hub.Since(0)
+		Expect(ok).To(BeTrue())
+		Expect(ids(missed)).To(Equal([]uint64{1, 2, 3}))
+	})
+
+	It("should report a gap once events fall out of the buffer", func() {
+		hub := NewEventHubWithBuffer(4)
+		for i := 0; i < 10; i++ {
+			hub.BroadcastEvent("vm:updated", nil)
+		}
+
+		missed, ok := hub.Since(6)
+		Expect(ok).To(BeTrue())
+		Expect(ids(missed)).To(Equal([]uint64{7, 8, 9, 10}))
+
+		missed, ok = hub.Since(5)
+		Expect(ok).To(BeFalse())
+		Expect(ids(missed)).To(Equal([]uint64{7, 8, 9, 10}))
+
+		_, ok = hub.Since(42)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should register and replay without missing events in between", func() {
+		hub := NewEventHub()
+		hub.BroadcastEvent("vm:added", nil)
+		hub.BroadcastEvent("vm:added", nil)
+
+		ch, missed, ok := hub.RegisterSince(1, Filter{})
+		defer hub.Unregister(ch)
+		Expect(ok).To(BeTrue())
+		Expect(ids(missed)).To(Equal([]uint64{2}))
+
+		hub.BroadcastEvent("vm:added", nil)
+		Expect((<-ch).ID).To(Equal(uint64(3)))
+	})
+
+	It("should drop events for slow clients", func() {
+		hub := NewEventHub()
+		ch := hub.Register()
+		defer hub.Unregister(ch)
+
+		for i := 0; i < 20; i++ {
+			hub.BroadcastEvent("vm:updated", nil)
+		}
+		Expect(ch).To(HaveLen(cap(ch)))
+		Expect(hub.LastID()).To(Equal(uint64(20)))
+		Expect(hub.Dropped(ch)).To(Equal(uint64(20 - cap(ch))))
+
+		stats := hub.Stats()
+		Expect(stats.LastEventID).To(Equal(uint64(20)))
+		Expect(stats.Clients).To(HaveLen(1))
+		Expect(stats.Clients[0].Delivered).To(Equal(uint64(cap(ch))))
+		Expect(stats.Clients[0].Dropped).To(Equal(uint64(20 - cap(ch))))
+		Expect(stats.Clients[0].Pending).To(Equal(cap(ch)))
+	})
+
+	Describe("filters", func() {
+		It("should only send the matching events", func() {
+			hub := NewEventHub()
+			ch := hub.RegisterFiltered(Filter{Types: []string{"migration:*"}, Datacenters: []string{"dc-solna"}})
+			defer hub.Unregister(ch)
+
+			hub.BroadcastEvent("vm:updated", map[string]interface{}{"datacenter": "dc-solna"})
+			hub.BroadcastEvent("migration:added", map[string]interface{}{"datacenter": "dc-kista"})
+			hub.BroadcastEvent("migration:added", map[string]interface{}{"datacenter": "dc-solna"})
+			hub.BroadcastEvent("migration:logical", map[string]interface{}{"datacenters": []string{"dc-kista", "dc-solna"}})
+			hub.BroadcastEvent("migration:updated", map[string]string{"vmId": "vm-1"})
+
+			var decoded interface{}
+			Expect(json.Unmarshal([]byte(`{"datacenters":["dc-solna"]}`), &decoded)).To(Succeed())
+			hub.BroadcastEvent("migration:logical", decoded)
+
+			Expect((<-ch).ID).To(Equal(uint64(3)))
+			Expect((<-ch).ID).To(Equal(uint64(4)))
+			Expect((<-ch).ID).To(Equal(uint64(6)))
+			Expect(ch).To(BeEmpty())
+			Expect(hub.Stats().Clients[0].Delivered).To(Equal(uint64(3)))
+		})
+
+		It("should only replay the matching events", func() {
+			hub := NewEventHub()
+			hub.BroadcastEvent("vm:added", nil)
+			hub.BroadcastEvent("migration:added", nil)
+			hub.BroadcastEvent("vm:removed", nil)
+
+			ch, missed, ok := hub.RegisterSince(0, Filter{Types: []string{"vm:*"}})
+			defer hub.Unregister(ch)
+			Expect(ok).To(BeTrue())
+			Expect(ids(missed)).To(Equal([]uint64{1, 3}))
+		})
+
+		It("should change the filter of a subscriber", func() {
+			hub := NewEventHub()
+			ch := hub.Register()
+			defer hub.Unregister(ch)
+
+			hub.SetFilter(ch, Filter{Types: []string{}})
+			hub.BroadcastEvent("vm:added", nil)
+			Expect(ch).To(BeEmpty())
+
+			hub.SetFilter(ch, Filter{Types: []string{"vm:*"}})
+			hub.BroadcastEvent("vm:added", nil)
+			Expect((<-ch).ID).To(Equal(uint64(2)))
+			Expect(hub.Stats().Clients[0].Filter.Types).To(Equal([]string{"vm:*"}))
+		})
+
+		It("should reject invalid type patterns", func() {
+			Expect(Filter{Types: []string{"vm:*"}}.Validate()).To(Succeed())
+			Expect(Filter{Types: []string{"vm:["}}.Validate()).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,223 @@
+package watcher
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+// ErrClusterNotWatched is returned when a migration involves a cluster the
+// watcher has no client for
+var ErrClusterNotWatched = errors.New("cluster is not watched")
+
+// MigrationPlan describes a live migration to start on the watched clusters
+type MigrationPlan struct {
+	VMName        string
+	Namespace     string
+	SourceCluster string
+	TargetCluster string
+}
+
+// MigrationResult identifies the migration objects created for a plan
+type MigrationResult struct {
+	MigrationID   string `json:"migrationId"`        // VMIM name, or the shared ID of a cross-cluster pair
+	SourceCluster string `json:"sourceCluster"`      // Cluster the VM migrates from
+	TargetCluster string `json:"targetCluster"`      // Cluster the VM migrates to
+	Outgoing      string `json:"outgoing,omitempty"` // VMIM on the source cluster
+	Incoming      string `json:"incoming,omitempty"` // Receiving VMIM on the target cluster
+}
+
+// IsWatched reports whether a cluster is being watched
+func (w *VMWatcher) IsWatched(clusterName string) bool {
+	return w.clusterWatcher(clusterName) != nil
+}
+
+// clusterWatcher returns the watcher for a cluster, or nil if not watched
+func (w *VMWatcher) clusterWatcher(clusterName string) *ClusterWatcher {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.watchers[clusterName]
+}
+
+// StartMigration creates the KubeVirt migration objects for a plan. A move
+// within one cluster creates a single VirtualMachineInstanceMigration. A
+// move between clusters creates a receiving migration on the target and a
+// sendTo migration on the source sharing one migration ID. Progress is then
+// tracked by the cluster watchers like any other migration.
+func (w *VMWatcher) StartMigration(ctx context.Context, plan MigrationPlan) (*MigrationResult, error) {
+	source := w.clusterWatcher(plan.SourceCluster)
+	if source == nil {
+		return nil, fmt.Errorf("source cluster %s: %w", plan.SourceCluster, ErrClusterNotWatched)
+	}
+
+	if plan.TargetCluster == "" || plan.TargetCluster == plan.SourceCluster {
+		created, err := source.createMigration(ctx, &kubevirtv1.VirtualMachineInstanceMigration{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: plan.VMName + "-migration-", Namespace: plan.Namespace},
+			Spec:       kubevirtv1.VirtualMachineInstanceMigrationSpec{VMIName: plan.VMName},
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Started migration %s of VM %s/%s in cluster %s", created.Name, plan.Namespace, plan.VMName, plan.SourceCluster)
+		return &MigrationResult{
+			MigrationID:   created.Name,
+			SourceCluster: plan.SourceCluster,
+			TargetCluster: plan.SourceCluster,
+			Outgoing:      created.Name,
+		}, nil
+	}
+
+	target := w.clusterWatcher(plan.TargetCluster)
+	if target == nil {
+		return nil, fmt.Errorf("target cluster %s: %w", plan.TargetCluster, ErrClusterNotWatched)
+	}
+	if target.config.MigrationSyncURL == "" {
+		return nil, fmt.Errorf("target cluster %s has no migrationSyncURL configured", plan.TargetCluster)
+	}
+
+	migrationID := newMigrationID(plan.VMName)
+
+	// The receiving side is created first so the source has something to connect to
+	incoming, err := target.createMigration(ctx, &kubevirtv1.VirtualMachineInstanceMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: migrationID + "-receive", Namespace: plan.Namespace},
+		Spec: kubevirtv1.VirtualMachineInstanceMigrationSpec{
+			VMIName: plan.VMName,
+			Receive: &kubevirtv1.VirtualMachineInstanceMigrationTarget{MigrationID: migrationID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	outgoing, err := source.createMigration(ctx, &kubevirtv1.VirtualMachineInstanceMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: migrationID + "-send", Namespace: plan.Namespace},
+		Spec: kubevirtv1.VirtualMachineInstanceMigrationSpec{
+			VMIName: plan.VMName,
+			SendTo: &kubevirtv1.VirtualMachineInstanceMigrationSource{
+				MigrationID: migrationID,
+				ConnectURL:  target.config.MigrationSyncURL,
+			},
+		},
+	})
+	if err != nil {
+		// Don't leave the receiving half waiting for a source that never comes
+		if deleteErr := target.deleteMigration(ctx, plan.Namespace, incoming.Name); deleteErr != nil {
+			log.Printf("Failed to clean up receiving migration %s in cluster %s: %v", incoming.Name, plan.TargetCluster, deleteErr)
+		}
+		return nil, err
+	}
+
+	log.Printf("Started cross-cluster migration %s of VM %s/%s from %s to %s", migrationID, plan.Namespace, plan.VMName, plan.SourceCluster, plan.TargetCluster)
+	return &MigrationResult{
+		MigrationID:   migrationID,
+		SourceCluster: plan.SourceCluster,
+		TargetCluster: plan.TargetCluster,
+		Outgoing:      outgoing.Name,
+		Incoming:      incoming.Name,
+	}, nil
+}
+
+// AbortMigration deletes a migration on its cluster, which KubeVirt treats
+// as an abort request. Once the VMIM is gone its record is kept, marked
+// completed with phase Aborted, rather than removed.
+func (w *VMWatcher) AbortMigration(ctx context.Context, clusterName, namespace, name string) error {
+	cw := w.clusterWatcher(clusterName)
+	if cw == nil {
+		return fmt.Errorf("cluster %s: %w", clusterName, ErrClusterNotWatched)
+	}
+
+	cw.setAborting(name, true)
+	if err := cw.deleteMigration(ctx, namespace, name); err != nil {
+		cw.setAborting(name, false)
+		return err
+	}
+	log.Printf("Requested abort of migration %s/%s in cluster %s", namespace, name, clusterName)
+	return nil
+}
+
+// MarkMigrationAborted marks a migration record as completed with phase
+// Aborted. A migration that already succeeded keeps its phase.
+func MarkMigrationAborted(migration *models.Migration, now time.Time) {
+	migration.AbortRequested = true
+	migration.Completed = true
+	if migration.Phase == "Succeeded" {
+		return
+	}
+	migration.Phase = "Aborted"
+	migration.AbortStatus = string(kubevirtv1.MigrationAbortSucceeded)
+	if migration.EndTime == nil {
+		migration.EndTime = &now
+	}
+}
+
+// setAborting records whether a migration is being aborted through the API
+func (cw *ClusterWatcher) setAborting(name string, aborting bool) {
+	cw.cacheMu.Lock()
+	defer cw.cacheMu.Unlock()
+	if !aborting {
+		delete(cw.aborting, name)
+		return
+	}
+	if cw.aborting == nil {
+		cw.aborting = make(map[string]bool)
+	}
+	cw.aborting[name] = true
+}
+
+// isAborting reports whether a migration is being aborted through the API
+func (cw *ClusterWatcher) isAborting(name string) bool {
+	cw.cacheMu.RLock()
+	defer cw.cacheMu.RUnlock()
+	return cw.aborting[name]
+}
+
+// finishAbortedMigration marks the record of a migration deleted through
+// AbortMigration as aborted instead of removing it
+func (cw *ClusterWatcher) finishAbortedMigration(name string) error {
+	cw.setAborting(name, false)
+
+	migration, err := cw.dataStore.GetMigration(name)
+	if err != nil {
+		log.Printf("Aborted migration %s was not in store: %v", name, err)
+		return nil
+	}
+	MarkMigrationAborted(migration, time.Now())
+	log.Printf("Migration %s in cluster %s was aborted", name, cw.config.Name)
+	return cw.updateMigrationInDatabase(migration)
+}
+
+// newMigrationID returns a unique ID shared by both halves of a
+// cross-cluster migration
+func newMigrationID(vmName string) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		panic(fmt.Sprintf("failed to generate migration ID: %v", err))
+	}
+	return fmt.Sprintf("%s-%s", vmName, hex.EncodeToString(suffix))
+}
+
+// createMigration creates a VirtualMachineInstanceMigration in this cluster
+func (cw *ClusterWatcher) createMigration(ctx context.Context, migration *kubevirtv1.VirtualMachineInstanceMigration) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+	created, err := cw.kubevirtClient.VirtualMachineInstanceMigration(migration.Namespace).Create(ctx, migration, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration for VM %s/%s in cluster %s: %w", migration.Namespace, migration.Spec.VMIName, cw.config.Name, err)
+	}
+	return created, nil
+}
+
+// deleteMigration deletes a VirtualMachineInstanceMigration in this cluster
+func (cw *ClusterWatcher) deleteMigration(ctx context.Context, namespace, name string) error {
+	if err := cw.kubevirtClient.VirtualMachineInstanceMigration(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("failed to delete migration %s/%s in cluster %s: %w", namespace, name, cw.config.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/mocks"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+// fakeKubevirtClient serves the generated kubecli mocks for the resources a
+// test uses. The vendored MockKubevirtClient lags behind the KubevirtClient
+// interface, so it can't be used directly; any other call panics.
+type fakeKubevirtClient struct {
+	kubecli.KubevirtClient
+	migrations kubecli.VirtualMachineInstanceMigrationInterface
+	vms        kubecli.VirtualMachineInterface
+	vmis       kubecli.VirtualMachineInstanceInterface
+}
+
+func (f *fakeKubevirtClient) VirtualMachineInstanceMigration(string) kubecli.VirtualMachineInstanceMigrationInterface {
+	return f.migrations
+}
+
+func (f *fakeKubevirtClient) VirtualMachine(string) kubecli.VirtualMachineInterface {
+	return f.vms
+}
+
+func (f *fakeKubevirtClient) VirtualMachineInstance(string) kubecli.VirtualMachineInstanceInterface {
+	return f.vmis
+}
+
+var _ = Describe("Migration executor", func() {
+	var (
+		ctrl          *gomock.Controller
+		vulcanMigs    *kubecli.MockVirtualMachineInstanceMigrationInterface
+		coruscantMigs *kubecli.MockVirtualMachineInstanceMigrationInterface
+		w             *VMWatcher
+		echoMigration = func(_ context.Context, migration *kubevirtv1.VirtualMachineInstanceMigration, _ metav1.CreateOptions) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+			created := migration.DeepCopy()
+			if created.Name == "" {
+				created.Name = created.GenerateName + "abcde"
+			}
+			return created, nil
+		}
+	)
+
+	newClusterWatcher := func(config ClusterConfig, migrations *kubecli.MockVirtualMachineInstanceMigrationInterface) *ClusterWatcher {
+		return &ClusterWatcher{config: config, kubevirtClient: &fakeKubevirtClient{migrations: migrations}}
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		vulcanMigs = kubecli.NewMockVirtualMachineInstanceMigrationInterface(ctrl)
+		coruscantMigs = kubecli.NewMockVirtualMachineInstanceMigrationInterface(ctrl)
+		w = &VMWatcher{watchers: map[string]*ClusterWatcher{
+			"vulcan":    newClusterWatcher(ClusterConfig{Name: "vulcan", DatacenterID: "dc-solna"}, vulcanMigs),
+			"coruscant": newClusterWatcher(ClusterConfig{Name: "coruscant", DatacenterID: "dc-sollentuna", MigrationSyncURL: "https://sync.coruscant:9185"}, coruscantMigs),
+		}}
+	})
+
+	It("should create a single migration for a move within a cluster", func() {
+		vulcanMigs.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(echoMigration)
+
+		result, err := w.StartMigration(context.Background(), MigrationPlan{VMName: "web-1", Namespace: "demo", SourceCluster: "vulcan", TargetCluster: "vulcan"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.MigrationID).To(Equal("web-1-migration-abcde"))
+		Expect(result.TargetCluster).To(Equal("vulcan"))
+		Expect(result.Incoming).To(BeEmpty())
+	})
+
+	It("should create paired sendTo and receive migrations across clusters", func() {
+		var receive, send *kubevirtv1.VirtualMachineInstanceMigration
+		gomock.InOrder(
+			coruscantMigs.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, m *kubevirtv1.VirtualMachineInstanceMigration, opts metav1.CreateOptions) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+					receive = m
+					return echoMigration(ctx, m, opts)
+				}),
+			vulcanMigs.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, m *kubevirtv1.VirtualMachineInstanceMigration, opts metav1.CreateOptions) (*kubevirtv1.VirtualMachineInstanceMigration, error) {
+					send = m
+					return echoMigration(ctx, m, opts)
+				}),
+		)
+
+		result, err := w.StartMigration(context.Background(), MigrationPlan{VMName: "web-1", Namespace: "demo", SourceCluster: "vulcan", TargetCluster: "coruscant"})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(receive.Spec.Receive).NotTo(BeNil())
+		Expect(send.Spec.SendTo).NotTo(BeNil())
+		Expect(receive.Spec.Receive.MigrationID).To(Equal(result.MigrationID))
+		Expect(send.Spec.SendTo.MigrationID).To(Equal(result.MigrationID))
+		Expect(send.Spec.SendTo.ConnectURL).To(Equal("https://sync.coruscant:9185"))
+		Expect(result.Outgoing).To(Equal(send.Name))
+		Expect(result.Incoming).To(Equal(receive.Name))
+	})
+
+	It("should remove the receiving migration when the source side fails", func() {
+		coruscantMigs.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(echoMigration)
+		vulcanMigs.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("forbidden"))
+		coruscantMigs.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := w.StartMigration(context.Background(), MigrationPlan{VMName: "web-1", Namespace: "demo", SourceCluster: "vulcan", TargetCluster: "coruscant"})
+
+		Expect(err).To(MatchError(ContainSubstring("forbidden")))
+	})
+
+	It("should refuse clusters that are not watched", func() {
+		_, err := w.StartMigration(context.Background(), MigrationPlan{VMName: "web-1", Namespace: "demo", SourceCluster: "borg"})
+
+		Expect(errors.Is(err, ErrClusterNotWatched)).To(BeTrue())
+	})
+
+	It("should require a sync URL on the target cluster", func() {
+		_, err := w.StartMigration(context.Background(), MigrationPlan{VMName: "web-1", Namespace: "demo", SourceCluster: "coruscant", TargetCluster: "vulcan"})
+
+		Expect(err).To(MatchError(ContainSubstring("migrationSyncURL")))
+	})
+})
+
+var _ = Describe("Migration abort", func() {
+	var (
+		ctrl       *gomock.Controller
+		migrations *kubecli.MockVirtualMachineInstanceMigrationInterface
+		store      *mocks.MockStore
+		cw         *ClusterWatcher
+		w          *VMWatcher
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		migrations = kubecli.NewMockVirtualMachineInstanceMigrationInterface(ctrl)
+		store = mocks.NewMockStore()
+		cw = &ClusterWatcher{
+			config:         ClusterConfig{Name: "vulcan", DatacenterID: "dc-test-1"},
+			options:        DefaultOptions(),
+			dataStore:      store,
+			kubevirtClient: &fakeKubevirtClient{migrations: migrations},
+		}
+		w = &VMWatcher{watchers: map[string]*ClusterWatcher{"vulcan": cw}}
+
+		Expect(store.AddMigration(models.Migration{
+			ID: "mig-1", VMName: "test-vm-1", Namespace: "demo", Cluster: "vulcan", DatacenterID: "dc-test-1", Phase: "Running",
+		})).To(Succeed())
+	})
+
+	It("should delete the VMIM and keep the record as aborted once it is gone", func() {
+		migrations.EXPECT().Delete(gomock.Any(), "mig-1", gomock.Any()).Return(nil)
+
+		Expect(w.AbortMigration(context.Background(), "vulcan", "demo", "mig-1")).To(Succeed())
+
+		deleting := &kubevirtv1.VirtualMachineInstanceMigration{
+			ObjectMeta: metav1.ObjectMeta{Name: "mig-1", Namespace: "demo", DeletionTimestamp: &metav1.Time{Time: time.Now()}},
+			Spec:       kubevirtv1.VirtualMachineInstanceMigrationSpec{VMIName: "test-vm-1"},
+			Status:     kubevirtv1.VirtualMachineInstanceMigrationStatus{Phase: kubevirtv1.MigrationRunning},
+		}
+		Expect(cw.convertToModelMigration(deleting).Phase).To(Equal("Aborted"))
+
+		Expect(cw.handleMigrationEvent(watch.Event{Type: watch.Deleted, Object: deleting})).To(Succeed())
+
+		migration, err := store.GetMigration("mig-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(migration.Phase).To(Equal("Aborted"))
+		Expect(migration.Completed).To(BeTrue())
+		Expect(cw.isAborting("mig-1")).To(BeFalse())
+	})
+
+	It("should still remove records of migrations deleted elsewhere", func() {
+		deleted := &kubevirtv1.VirtualMachineInstanceMigration{ObjectMeta: metav1.ObjectMeta{Name: "mig-1", Namespace: "demo"}}
+
+		Expect(cw.handleMigrationEvent(watch.Event{Type: watch.Deleted, Object: deleted})).To(Succeed())
+
+		_, err := store.GetMigration("mig-1")
+		Expect(err).To(HaveOccurred())
+	})
+})
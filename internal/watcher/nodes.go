@@ -0,0 +1,201 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+const (
+	// zoneLabel is the well-known topology label carrying a node's zone
+	zoneLabel = "topology.kubernetes.io/zone"
+	// roleLabelPrefix prefixes the labels that name a node's roles
+	roleLabelPrefix = "node-role.kubernetes.io/"
+)
+
+// convertToModelNode converts a Kubernetes node to our node model
+func (cw *ClusterWatcher) convertToModelNode(node *k8sv1.Node) *models.Node {
+	modelNode := &models.Node{
+		Name:         node.Name,
+		Cluster:      cw.config.Name,
+		DatacenterID: cw.config.DatacenterID,
+		Schedulable:  !node.Spec.Unschedulable,
+		Zone:         node.Labels[zoneLabel],
+		Labels:       node.Labels,
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == k8sv1.NodeReady {
+			modelNode.Ready = condition.Status == k8sv1.ConditionTrue
+		}
+	}
+
+	if cpu, ok := node.Status.Allocatable[k8sv1.ResourceCPU]; ok {
+		modelNode.AllocatableCPU = int(cpu.MilliValue() / 1000)
+	}
+	if memory, ok := node.Status.Allocatable[k8sv1.ResourceMemory]; ok {
+		modelNode.AllocatableMemory = int(memory.Value() / bytesPerMB)
+	}
+
+	for label := range node.Labels {
+		if role := strings.TrimPrefix(label, roleLabelPrefix); role != label && role != "" {
+			modelNode.Roles = append(modelNode.Roles, role)
+		}
+	}
+	sort.Strings(modelNode.Roles)
+
+	return modelNode
+}
+
+// syncExistingNodes fetches all existing nodes and updates the database
+func (cw *ClusterWatcher) syncExistingNodes() error {
+	if cw.k8sClient == nil {
+		return nil
+	}
+	log.Printf("Syncing existing nodes for cluster %s", cw.config.Name)
+
+	nodes, err := cw.k8sClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	log.Printf("Found %d nodes in cluster %s", len(nodes.Items), cw.config.Name)
+
+	listed := make(map[string]bool, len(nodes.Items))
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		listed[node.Name] = true
+		cw.record(ResourceNode, watch.Added, node)
+		if err := cw.updateNodeInDatabase(cw.convertToModelNode(node)); err != nil {
+			log.Printf("Failed to update node %s in database: %v", node.Name, err)
+		}
+	}
+
+	// Forget the nodes deleted while the server was not watching
+	stored, err := cw.dataStore.GetNodes(cw.config.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get stored nodes: %w", err)
+	}
+	for _, node := range stored {
+		if listed[node.Name] {
+			continue
+		}
+		if err := cw.removeNodeFromDatabase(node.Name); err != nil {
+			log.Printf("Failed to remove node %s from database: %v", node.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// watchNodes sets up a watch for node changes
+func (cw *ClusterWatcher) watchNodes() error {
+	if cw.k8sClient == nil {
+		return nil
+	}
+	log.Printf("Starting node watch for cluster %s", cw.config.Name)
+
+	for {
+		select {
+		case <-cw.ctx.Done():
+			log.Printf("Node watcher for cluster %s stopped", cw.config.Name)
+			return nil
+		default:
+		}
+
+		watcher, err := cw.k8sClient.CoreV1().Nodes().Watch(cw.ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Printf("Failed to create node watcher for cluster %s: %v", cw.config.Name, err)
+			time.Sleep(30 * time.Second)
+			continue
+		}
+
+		// Process events in a loop
+	eventLoop:
+		for {
+			select {
+			case <-cw.ctx.Done():
+				log.Printf("Node watcher for cluster %s stopped", cw.config.Name)
+				watcher.Stop()
+				return nil
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					log.Printf("Node watcher channel closed for cluster %s, restarting...", cw.config.Name)
+					watcher.Stop()
+					time.Sleep(5 * time.Second)
+					break eventLoop
+				}
+
+				cw.record(ResourceNode, event.Type, event.Object)
+				if err := cw.handleNodeEvent(event); err != nil {
+					log.Printf("Failed to handle node event for cluster %s: %v", cw.config.Name, err)
+				}
+			}
+		}
+	}
+}
+
+// handleNodeEvent processes a node watch event
+func (cw *ClusterWatcher) handleNodeEvent(event watch.Event) error {
+	node, ok := event.Object.(*k8sv1.Node)
+	if !ok {
+		return nil
+	}
+
+	switch event.Type {
+	case watch.Added, watch.Modified:
+		return cw.updateNodeInDatabase(cw.convertToModelNode(node))
+	case watch.Deleted:
+		return cw.removeNodeFromDatabase(node.Name)
+	}
+	return nil
+}
+
+// updateNodeInDatabase stores a node and notifies listeners. Nodes report a
+// heartbeat every few seconds, so unchanged nodes are skipped.
+func (cw *ClusterWatcher) updateNodeInDatabase(node *models.Node) error {
+	cw.cacheMu.Lock()
+	previous, known := cw.nodes[node.Name]
+	if known && reflect.DeepEqual(previous, *node) {
+		cw.cacheMu.Unlock()
+		return nil
+	}
+	cw.nodes[node.Name] = *node
+	cw.cacheMu.Unlock()
+
+	if err := cw.dataStore.UpsertNode(*node); err != nil {
+		return fmt.Errorf("failed to store node: %w", err)
+	}
+
+	eventType := "node:updated"
+	if !known {
+		eventType = "node:added"
+	}
+	DefaultHub.BroadcastEvent(eventType, map[string]interface{}{"cluster": cw.config.Name, "datacenter": cw.config.DatacenterID, "node": node})
+	return nil
+}
+
+// removeNodeFromDatabase removes a node and notifies listeners
+func (cw *ClusterWatcher) removeNodeFromDatabase(name string) error {
+	cw.cacheMu.Lock()
+	delete(cw.nodes, name)
+	cw.cacheMu.Unlock()
+
+	if err := cw.dataStore.RemoveNode(cw.config.Name, name); err != nil {
+		return fmt.Errorf("failed to remove node from database: %w", err)
+	}
+
+	log.Printf("Removed node %s from cluster %s", name, cw.config.Name)
+	DefaultHub.BroadcastEvent("node:removed", map[string]interface{}{"cluster": cw.config.Name, "datacenter": cw.config.DatacenterID, "nodeName": name})
+	return nil
+}
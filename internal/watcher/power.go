@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// PowerAction is a VM power operation
+type PowerAction string
+
+const (
+	PowerStart   PowerAction = "start"
+	PowerStop    PowerAction = "stop"
+	PowerRestart PowerAction = "restart"
+	PowerPause   PowerAction = "pause"
+	PowerUnpause PowerAction = "unpause"
+)
+
+// ParsePowerAction validates a power action name
+func ParsePowerAction(action string) (PowerAction, error) {
+	switch PowerAction(action) {
+	case PowerStart, PowerStop, PowerRestart, PowerPause, PowerUnpause:
+		return PowerAction(action), nil
+	default:
+		return "", fmt.Errorf("unknown power action %q", action)
+	}
+}
+
+// PowerAction runs a power operation on a VM through the KubeVirt
+// subresource API. Start, stop and restart act on the VirtualMachine; pause
+// and unpause on its running instance. The resulting state changes reach the
+// store through the regular VM and VMI watches.
+func (w *VMWatcher) PowerAction(ctx context.Context, clusterName, namespace, name string, action PowerAction) error {
+	cw := w.clusterWatcher(clusterName)
+	if cw == nil {
+		return fmt.Errorf("cluster %s: %w", clusterName, ErrClusterNotWatched)
+	}
+
+	var err error
+	switch action {
+	case PowerStart:
+		err = cw.kubevirtClient.VirtualMachine(namespace).Start(ctx, name, &kubevirtv1.StartOptions{})
+	case PowerStop:
+		err = cw.kubevirtClient.VirtualMachine(namespace).Stop(ctx, name, &kubevirtv1.StopOptions{})
+	case PowerRestart:
+		err = cw.kubevirtClient.VirtualMachine(namespace).Restart(ctx, name, &kubevirtv1.RestartOptions{})
+	case PowerPause:
+		err = cw.kubevirtClient.VirtualMachineInstance(namespace).Pause(ctx, name, &kubevirtv1.PauseOptions{})
+	case PowerUnpause:
+		err = cw.kubevirtClient.VirtualMachineInstance(namespace).Unpause(ctx, name, &kubevirtv1.UnpauseOptions{})
+	default:
+		return fmt.Errorf("unknown power action %q", action)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s VM %s/%s in cluster %s: %w", action, namespace, name, clusterName, err)
+	}
+
+	log.Printf("Requested %s of VM %s/%s in cluster %s", action, namespace, name, clusterName)
+	return nil
+}
@@ -0,0 +1,51 @@
+package watcher
+
+import (
+	"context"
+
+	"kubevirt.io/client-go/kubecli"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("VM power actions", func() {
+	var (
+		vms  *kubecli.MockVirtualMachineInterface
+		vmis *kubecli.MockVirtualMachineInstanceInterface
+		w    *VMWatcher
+	)
+
+	BeforeEach(func() {
+		ctrl := gomock.NewController(GinkgoT())
+		vms = kubecli.NewMockVirtualMachineInterface(ctrl)
+		vmis = kubecli.NewMockVirtualMachineInstanceInterface(ctrl)
+		w = &VMWatcher{watchers: map[string]*ClusterWatcher{
+			"vulcan": {config: ClusterConfig{Name: "vulcan"}, kubevirtClient: &fakeKubevirtClient{vms: vms, vmis: vmis}},
+		}}
+	})
+
+	It("should start, stop and restart through the VirtualMachine subresources", func() {
+		vms.EXPECT().Start(gomock.Any(), "web-1", gomock.Any()).Return(nil)
+		vms.EXPECT().Stop(gomock.Any(), "web-1", gomock.Any()).Return(nil)
+		vms.EXPECT().Restart(gomock.Any(), "web-1", gomock.Any()).Return(nil)
+
+		for _, action := range []PowerAction{PowerStart, PowerStop, PowerRestart} {
+			Expect(w.PowerAction(context.Background(), "vulcan", "demo", "web-1", action)).To(Succeed())
+		}
+	})
+
+	It("should pause and unpause through the VirtualMachineInstance subresources", func() {
+		vmis.EXPECT().Pause(gomock.Any(), "web-1", gomock.Any()).Return(nil)
+		vmis.EXPECT().Unpause(gomock.Any(), "web-1", gomock.Any()).Return(nil)
+
+		Expect(w.PowerAction(context.Background(), "vulcan", "demo", "web-1", PowerPause)).To(Succeed())
+		Expect(w.PowerAction(context.Background(), "vulcan", "demo", "web-1", PowerUnpause)).To(Succeed())
+	})
+
+	It("should reject unknown actions", func() {
+		_, err := ParsePowerAction("explode")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,167 @@
+package watcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// RecordedResource names the kind of object in a recorded event
+type RecordedResource string
+
+const (
+	ResourceVM        RecordedResource = "vm"
+	ResourceVMI       RecordedResource = "vmi"
+	ResourceMigration RecordedResource = "migration"
+	ResourceNode      RecordedResource = "node"
+)
+
+// RecordedEvent is one line of a recording: a watch event as a cluster
+// watcher received it. Objects found by the initial sync are recorded as
+// ADDED events so a replay rebuilds the same starting state.
+type RecordedEvent struct {
+	Time     time.Time        `json:"time"`
+	Cluster  string           `json:"cluster"`
+	Resource RecordedResource `json:"resource"`
+	Type     watch.EventType  `json:"type"`
+	Object   json.RawMessage  `json:"object"`
+}
+
+// Decode returns the KubeVirt or Kubernetes object carried by the event
+func (e RecordedEvent) Decode() (runtime.Object, error) {
+	var object runtime.Object
+	switch e.Resource {
+	case ResourceVM:
+		object = &kubevirtv1.VirtualMachine{}
+	case ResourceVMI:
+		object = &kubevirtv1.VirtualMachineInstance{}
+	case ResourceMigration:
+		object = &kubevirtv1.VirtualMachineInstanceMigration{}
+	case ResourceNode:
+		object = &k8sv1.Node{}
+	default:
+		return nil, fmt.Errorf("unknown resource %q", e.Resource)
+	}
+	if err := json.Unmarshal(e.Object, object); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", e.Resource, err)
+	}
+	return object, nil
+}
+
+// Recorder writes the events all cluster watchers receive to an NDJSON file
+type Recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewRecorder creates (or truncates) a recording file
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording %s: %w", path, err)
+	}
+	writer := bufio.NewWriter(file)
+	return &Recorder{file: file, writer: writer, encoder: json.NewEncoder(writer)}, nil
+}
+
+// Record appends an event to the recording. Failures are logged rather
+// than returned so a full disk never stops the watchers.
+func (r *Recorder) Record(cluster string, resource RecordedResource, eventType watch.EventType, object runtime.Object) {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		log.Printf("Failed to record %s event for cluster %s: %v", resource, cluster, err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	event := RecordedEvent{Time: time.Now(), Cluster: cluster, Resource: resource, Type: eventType, Object: raw}
+	if err := r.encoder.Encode(event); err != nil {
+		log.Printf("Failed to record %s event for cluster %s: %v", resource, cluster, err)
+		return
+	}
+	// Flush every event so the recording survives a crash
+	if err := r.writer.Flush(); err != nil {
+		log.Printf("Failed to flush recording: %v", err)
+	}
+}
+
+// Close flushes and closes the recording
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.writer.Flush()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.file = nil
+	return err
+}
+
+// ReadRecording reads all events of a recording
+func ReadRecording(reader io.Reader) ([]RecordedEvent, error) {
+	var events []RecordedEvent
+	scanner := bufio.NewScanner(reader)
+	// Objects with long status histories make for long lines
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event RecordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// LoadRecording reads a recording file
+func LoadRecording(path string) ([]RecordedEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording %s: %w", path, err)
+	}
+	defer file.Close()
+
+	events, err := ReadRecording(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording %s: %w", path, err)
+	}
+	return events, nil
+}
+
+// record passes an object event to the recorder, if one is configured.
+// Watch errors and bookmarks carry no object state and are skipped.
+func (cw *ClusterWatcher) record(resource RecordedResource, eventType watch.EventType, object runtime.Object) {
+	switch eventType {
+	case watch.Added, watch.Modified, watch.Deleted:
+	default:
+		return
+	}
+	if cw.options.Recorder != nil {
+		cw.options.Recorder.Record(cw.config.Name, resource, eventType, object)
+	}
+}
@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Event recorder", func() {
+	var (
+		path     string
+		recorder *Recorder
+		cw       *ClusterWatcher
+	)
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "events.ndjson")
+		var err error
+		recorder, err = NewRecorder(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		options := DefaultOptions()
+		options.Recorder = recorder
+		cw = &ClusterWatcher{config: ClusterConfig{Name: "vulcan"}, options: options}
+	})
+
+	It("should write events that decode back to the original objects", func() {
+		cw.record(ResourceVM, watch.Added, &kubevirtv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "demo"}})
+		cw.record(ResourceMigration, watch.Modified, &kubevirtv1.VirtualMachineInstanceMigration{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1-migration", Namespace: "demo"},
+			Status:     kubevirtv1.VirtualMachineInstanceMigrationStatus{Phase: kubevirtv1.MigrationRunning},
+		})
+		cw.record(ResourceVM, watch.Error, &metav1.Status{Message: "too old resource version"})
+		Expect(recorder.Close()).To(Succeed())
+
+		events, err := LoadRecording(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).To(HaveLen(2))
+		Expect(events[0].Cluster).To(Equal("vulcan"))
+		Expect(events[0].Type).To(Equal(watch.Added))
+		Expect(events[0].Time).NotTo(BeZero())
+
+		object, err := events[1].Decode()
+		Expect(err).NotTo(HaveOccurred())
+		migration, ok := object.(*kubevirtv1.VirtualMachineInstanceMigration)
+		Expect(ok).To(BeTrue())
+		Expect(migration.Name).To(Equal("web-1-migration"))
+		Expect(migration.Status.Phase).To(Equal(kubevirtv1.MigrationRunning))
+	})
+
+	It("should report the line of a malformed recording", func() {
+		Expect(os.WriteFile(path, []byte("{\"cluster\":\"vulcan\"}\nnot json\n"), 0o644)).To(Succeed())
+
+		_, err := LoadRecording(path)
+		Expect(err).To(MatchError(ContainSubstring("line 2")))
+	})
+})
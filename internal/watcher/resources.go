@@ -0,0 +1,409 @@
+package watcher
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	instancetypeapi "kubevirt.io/api/instancetype"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+const (
+	bytesPerMB = 1024 * 1024
+	bytesPerGB = 1024 * 1024 * 1024
+)
+
+// lookupTTL is how long an instancetype, preference or PVC fetched while
+// converting VMs is reused before it is fetched again
+const lookupTTL = time.Minute
+
+// lookupCache keeps the objects fetched while converting VMs, so that the
+// flushes of busy VMs don't fetch them over and over. A nil cache keeps
+// nothing.
+type lookupCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]lookupEntry
+	pruned  time.Time // When expired entries were last removed
+}
+
+// lookupEntry is a cached lookup result
+type lookupEntry struct {
+	value   interface{}
+	expires time.Time // Zero for results that never change
+}
+
+// newLookupCache creates a cache keeping results for ttl
+func newLookupCache(ttl time.Duration) *lookupCache {
+	return &lookupCache{ttl: ttl, entries: make(map[string]lookupEntry)}
+}
+
+// get returns the cached result for key
+func (c *lookupCache) get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || (!entry.expires.IsZero() && time.Now().After(entry.expires)) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// put caches a result; pinned results, such as those of a
+// ControllerRevision, are kept for good
+func (c *lookupCache) put(key string, value interface{}, pinned bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if now.Sub(c.pruned) > c.ttl {
+		for k, entry := range c.entries {
+			if !entry.expires.IsZero() && now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.pruned = now
+	}
+	entry := lookupEntry{value: value}
+	if !pinned {
+		entry.expires = now.Add(c.ttl)
+	}
+	c.entries[key] = entry
+}
+
+// instancetypeResources are the vCPUs and memory (MB) of an instancetype
+type instancetypeResources struct {
+	cpu, memory int
+}
+
+// cpuCount returns the number of vCPUs described by a domain CPU topology
+// (sockets x cores x threads). Unset values count as one.
+func cpuCount(cpu *kubevirtv1.CPU) int {
+	if cpu == nil || (cpu.Sockets == 0 && cpu.Cores == 0 && cpu.Threads == 0) {
+		return 0
+	}
+	sockets, cores, threads := cpu.Sockets, cpu.Cores, cpu.Threads
+	if sockets == 0 {
+		sockets = 1
+	}
+	if cores == 0 {
+		cores = 1
+	}
+	if threads == 0 {
+		threads = 1
+	}
+	return int(sockets * cores * threads)
+}
+
+// memoryMB returns the guest memory of a domain in MB, falling back to the
+// memory resource request and then the limit when no guest memory is set
+func memoryMB(domain *kubevirtv1.DomainSpec) int {
+	if domain.Memory != nil && domain.Memory.Guest != nil && !domain.Memory.Guest.IsZero() {
+		return int(domain.Memory.Guest.Value() / bytesPerMB)
+	}
+	if request, ok := domain.Resources.Requests[k8sv1.ResourceMemory]; ok && !request.IsZero() {
+		return int(request.Value() / bytesPerMB)
+	}
+	if limit, ok := domain.Resources.Limits[k8sv1.ResourceMemory]; ok && !limit.IsZero() {
+		return int(limit.Value() / bytesPerMB)
+	}
+	return 0
+}
+
+// quantityGB converts a storage quantity to whole GB
+func quantityGB(q resource.Quantity) int {
+	if q.IsZero() {
+		return 0
+	}
+	return int(q.Value() / bytesPerGB)
+}
+
+// totalDiskGB sums the size of all disks
+func totalDiskGB(disks []models.Disk) int {
+	total := 0
+	for _, disk := range disks {
+		total += disk.SizeGB
+	}
+	return total
+}
+
+// applyDomainResources sets CPU and memory on the model from a domain spec
+func applyDomainResources(modelVM *models.VM, domain *kubevirtv1.DomainSpec) {
+	if cpu := cpuCount(domain.CPU); cpu > 0 {
+		modelVM.CPU = cpu
+	}
+	if memory := memoryMB(domain); memory > 0 {
+		modelVM.Memory = memory
+	}
+}
+
+// enrichVMWithSpecInfo fills in resources for a VM without a running instance
+// from its template, resolving instancetype and preference references
+func (cw *ClusterWatcher) enrichVMWithSpecInfo(vm *kubevirtv1.VirtualMachine, modelVM *models.VM) {
+	if vm.Spec.Template == nil {
+		return
+	}
+
+	applyDomainResources(modelVM, &vm.Spec.Template.Spec.Domain)
+
+	// An instancetype overrides the template CPU and memory
+	if vm.Spec.Instancetype != nil {
+		cpu, memory, err := cw.resolveInstancetype(vm.Namespace, vm.Spec.Instancetype)
+		if err != nil {
+			log.Printf("Failed to resolve instancetype %s for VM %s: %v", vm.Spec.Instancetype.Name, vm.Name, err)
+		} else {
+			if cpu > 0 {
+				modelVM.CPU = cpu
+			}
+			if memory > 0 {
+				modelVM.Memory = memory
+			}
+		}
+	}
+
+	// A preference may supply the storage class for DataVolume templates
+	preferredStorageClass := ""
+	if vm.Spec.Preference != nil {
+		storageClass, err := cw.resolvePreferredStorageClass(vm.Namespace, vm.Spec.Preference)
+		if err != nil {
+			log.Printf("Failed to resolve preference %s for VM %s: %v", vm.Spec.Preference.Name, vm.Name, err)
+		}
+		preferredStorageClass = storageClass
+	}
+
+	modelVM.Disks = cw.extractDisks(vm.Namespace, vm.Spec.Template.Spec.Domain.Devices.Disks,
+		vm.Spec.Template.Spec.Volumes, vm.Spec.DataVolumeTemplates, nil, preferredStorageClass)
+	modelVM.Disk = totalDiskGB(modelVM.Disks)
+}
+
+// resolveInstancetype returns the vCPU count and memory (MB) of a namespaced
+// or cluster-wide instancetype. Results are cached, for good once the VM is
+// pinned to a revision of the instancetype.
+func (cw *ClusterWatcher) resolveInstancetype(namespace string, matcher *kubevirtv1.InstancetypeMatcher) (int, int, error) {
+	namespaced := matcher.Kind == instancetypeapi.SingularResourceName || matcher.Kind == "VirtualMachineInstancetype"
+	if !namespaced {
+		namespace = ""
+	}
+	key := "instancetype/" + namespace + "/" + matcher.Name + "@" + matcher.RevisionName
+	if cached, ok := cw.lookups.get(key); ok {
+		resources := cached.(instancetypeResources)
+		return resources.cpu, resources.memory, nil
+	}
+
+	var resources instancetypeResources
+	if namespaced {
+		instancetype, err := cw.kubevirtClient.VirtualMachineInstancetype(namespace).Get(context.TODO(), matcher.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, err
+		}
+		resources = instancetypeResources{int(instancetype.Spec.CPU.Guest), int(instancetype.Spec.Memory.Guest.Value() / bytesPerMB)}
+	} else {
+		// Kind defaults to the cluster-wide instancetype
+		instancetype, err := cw.kubevirtClient.VirtualMachineClusterInstancetype().Get(context.TODO(), matcher.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, err
+		}
+		resources = instancetypeResources{int(instancetype.Spec.CPU.Guest), int(instancetype.Spec.Memory.Guest.Value() / bytesPerMB)}
+	}
+	cw.lookups.put(key, resources, matcher.RevisionName != "")
+	return resources.cpu, resources.memory, nil
+}
+
+// resolvePreferredStorageClass returns the preferred storage class of a
+// namespaced or cluster-wide preference, if it defines one. Results are
+// cached like those of resolveInstancetype.
+func (cw *ClusterWatcher) resolvePreferredStorageClass(namespace string, matcher *kubevirtv1.PreferenceMatcher) (string, error) {
+	namespaced := matcher.Kind == instancetypeapi.SingularPreferenceResourceName || matcher.Kind == "VirtualMachinePreference"
+	if !namespaced {
+		namespace = ""
+	}
+	key := "preference/" + namespace + "/" + matcher.Name + "@" + matcher.RevisionName
+	if cached, ok := cw.lookups.get(key); ok {
+		return cached.(string), nil
+	}
+
+	storageClass := ""
+	if namespaced {
+		preference, err := cw.kubevirtClient.VirtualMachinePreference(namespace).Get(context.TODO(), matcher.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		if preference.Spec.Volumes != nil {
+			storageClass = preference.Spec.Volumes.PreferredStorageClassName
+		}
+	} else {
+		// Kind defaults to the cluster-wide preference
+		preference, err := cw.kubevirtClient.VirtualMachineClusterPreference().Get(context.TODO(), matcher.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		if preference.Spec.Volumes != nil {
+			storageClass = preference.Spec.Volumes.PreferredStorageClassName
+		}
+	}
+	cw.lookups.put(key, storageClass, matcher.RevisionName != "")
+	return storageClass, nil
+}
+
+// extractDisks builds the disk list for every domain disk. Sizes come from
+// the VMI volume status when available, then DataVolume templates, then the
+// backing PVC; storage classes from DataVolume templates or the PVC.
+func (cw *ClusterWatcher) extractDisks(namespace string, disks []kubevirtv1.Disk, volumes []kubevirtv1.Volume,
+	dataVolumeTemplates []kubevirtv1.DataVolumeTemplateSpec, volumeStatus []kubevirtv1.VolumeStatus, preferredStorageClass string) []models.Disk {
+
+	volumesByName := make(map[string]kubevirtv1.Volume, len(volumes))
+	for _, volume := range volumes {
+		volumesByName[volume.Name] = volume
+	}
+	statusByName := make(map[string]kubevirtv1.VolumeStatus, len(volumeStatus))
+	for _, status := range volumeStatus {
+		statusByName[status.Name] = status
+	}
+	templatesByName := make(map[string]kubevirtv1.DataVolumeTemplateSpec, len(dataVolumeTemplates))
+	for _, template := range dataVolumeTemplates {
+		templatesByName[template.Name] = template
+	}
+
+	var result []models.Disk
+	for _, disk := range disks {
+		volume, ok := volumesByName[disk.Name]
+		if !ok {
+			continue
+		}
+
+		volumeType, claimName := volumeTypeAndClaim(volume)
+		modelDisk := models.Disk{
+			Name:       disk.Name,
+			VolumeType: volumeType,
+		}
+
+		switch {
+		case volume.EmptyDisk != nil:
+			modelDisk.SizeGB = quantityGB(volume.EmptyDisk.Capacity)
+		case volume.HostDisk != nil:
+			modelDisk.SizeGB = quantityGB(volume.HostDisk.Capacity)
+		}
+
+		// Size reported by a running instance
+		if status, ok := statusByName[volume.Name]; ok && status.PersistentVolumeClaimInfo != nil {
+			if storage, exists := status.PersistentVolumeClaimInfo.Capacity[k8sv1.ResourceStorage]; exists {
+				modelDisk.SizeGB = quantityGB(storage)
+			}
+		}
+
+		// Size and storage class requested by a DataVolume template
+		if template, ok := templatesByName[claimName]; ok && claimName != "" {
+			if storage := template.Spec.Storage; storage != nil {
+				if modelDisk.SizeGB == 0 {
+					modelDisk.SizeGB = quantityGB(storage.Resources.Requests[k8sv1.ResourceStorage])
+				}
+				if storage.StorageClassName != nil {
+					modelDisk.StorageClass = *storage.StorageClassName
+				}
+			} else if pvc := template.Spec.PVC; pvc != nil {
+				if modelDisk.SizeGB == 0 {
+					modelDisk.SizeGB = quantityGB(pvc.Resources.Requests[k8sv1.ResourceStorage])
+				}
+				if pvc.StorageClassName != nil {
+					modelDisk.StorageClass = *pvc.StorageClassName
+				}
+			}
+			if modelDisk.StorageClass == "" {
+				modelDisk.StorageClass = preferredStorageClass
+			}
+		}
+
+		// Fill any remaining gaps from the backing PVC
+		if claimName != "" && (modelDisk.SizeGB == 0 || modelDisk.StorageClass == "") {
+			if pvc := cw.getPVC(namespace, claimName); pvc != nil {
+				if modelDisk.SizeGB == 0 {
+					if storage, exists := pvc.Status.Capacity[k8sv1.ResourceStorage]; exists {
+						modelDisk.SizeGB = quantityGB(storage)
+					} else {
+						modelDisk.SizeGB = quantityGB(pvc.Spec.Resources.Requests[k8sv1.ResourceStorage])
+					}
+				}
+				if modelDisk.StorageClass == "" && pvc.Spec.StorageClassName != nil {
+					modelDisk.StorageClass = *pvc.Spec.StorageClassName
+				}
+			}
+		}
+
+		result = append(result, modelDisk)
+	}
+
+	return result
+}
+
+// getPVC fetches a PVC, returning nil when it can't be read. PVCs, and
+// those that don't exist, are cached for lookupTTL.
+func (cw *ClusterWatcher) getPVC(namespace, name string) *k8sv1.PersistentVolumeClaim {
+	if cw.k8sClient == nil {
+		return nil
+	}
+	key := "pvc/" + namespace + "/" + name
+	if cached, ok := cw.lookups.get(key); ok {
+		return cached.(*k8sv1.PersistentVolumeClaim)
+	}
+	pvc, err := cw.k8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			cw.lookups.put(key, (*k8sv1.PersistentVolumeClaim)(nil), false)
+		}
+		log.Printf("Failed to get PVC %s/%s in cluster %s: %v", namespace, name, cw.config.Name, err)
+		return nil
+	}
+	cw.lookups.put(key, pvc, false)
+	return pvc
+}
+
+// volumeTypeAndClaim returns the source type of a volume and the name of the
+// PVC or DataVolume backing it, if any
+func volumeTypeAndClaim(volume kubevirtv1.Volume) (string, string) {
+	switch {
+	case volume.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim", volume.PersistentVolumeClaim.ClaimName
+	case volume.DataVolume != nil:
+		return "dataVolume", volume.DataVolume.Name
+	case volume.ContainerDisk != nil:
+		return "containerDisk", ""
+	case volume.EmptyDisk != nil:
+		return "emptyDisk", ""
+	case volume.Ephemeral != nil:
+		if volume.Ephemeral.PersistentVolumeClaim != nil {
+			return "ephemeral", volume.Ephemeral.PersistentVolumeClaim.ClaimName
+		}
+		return "ephemeral", ""
+	case volume.HostDisk != nil:
+		return "hostDisk", ""
+	case volume.CloudInitNoCloud != nil:
+		return "cloudInitNoCloud", ""
+	case volume.CloudInitConfigDrive != nil:
+		return "cloudInitConfigDrive", ""
+	case volume.Sysprep != nil:
+		return "sysprep", ""
+	case volume.ConfigMap != nil:
+		return "configMap", ""
+	case volume.Secret != nil:
+		return "secret", ""
+	case volume.ServiceAccount != nil:
+		return "serviceAccount", ""
+	case volume.DownwardAPI != nil:
+		return "downwardAPI", ""
+	default:
+		return "unknown", ""
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
@@ -22,6 +24,7 @@ import (
 // VMWatcher watches for VM changes across multiple clusters
 type VMWatcher struct {
 	dataStore models.Store
+	options   Options
 	clusters  []ClusterConfig
 	watchers  map[string]*ClusterWatcher
 	ctx       context.Context
@@ -29,20 +32,46 @@ type VMWatcher struct {
 	mu        sync.RWMutex
 }
 
+// defaultDebounceInterval is how long VM and VMI events for the same object
+// are collected before the merged result is written to the store
+const defaultDebounceInterval = 500 * time.Millisecond
+
 // ClusterWatcher watches VMs in a specific cluster
 type ClusterWatcher struct {
-	config           ClusterConfig
-	k8sClient        kubernetes.Interface
-	kubevirtClient   kubecli.KubevirtClient
-	dataStore        models.Store
-	vmWatcher        watch.Interface
-	migrationWatcher watch.Interface
-	ctx              context.Context
-	cancel           context.CancelFunc
+	config         ClusterConfig
+	options        Options
+	k8sClient      kubernetes.Interface
+	kubevirtClient kubecli.KubevirtClient
+	dataStore      models.Store
+	// ctx ends the watches; each watch loop stops its own watch.Interface
+	// when it is done
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Last seen VM and VMI objects keyed by namespace/name. VM and VMI
+	// events only update these caches and trigger the debouncer, which
+	// converts the merged pair and writes it to the store once.
+	cacheMu   sync.RWMutex
+	vms       map[string]*kubevirtv1.VirtualMachine
+	vmis      map[string]*kubevirtv1.VirtualMachineInstance
+	debouncer *debouncer
+	// vmisSynced is set once the VMIs have been listed; from then on a VMI
+	// missing from the cache does not exist
+	vmisSynced bool
+
+	// Instancetypes, preferences and PVCs fetched while converting VMs
+	lookups *lookupCache
+
+	// Last stored node models keyed by name, to skip heartbeat-only updates
+	nodes map[string]models.Node
+
+	// Migrations deleted through AbortMigration. Their records are kept and
+	// marked Aborted instead of being removed when the VMIM goes away.
+	aborting map[string]bool
 }
 
 // NewVMWatcher creates a new VM watcher
-func NewVMWatcher(dataStore models.Store, configPath string) (*VMWatcher, error) {
+func NewVMWatcher(dataStore models.Store, configPath string, options Options) (*VMWatcher, error) {
 	// Load datacenter configuration
 	dcConfig, err := LoadDatacenterConfig(configPath)
 	if err != nil {
@@ -58,6 +87,7 @@ func NewVMWatcher(dataStore models.Store, configPath string) (*VMWatcher, error)
 
 	watcher := &VMWatcher{
 		dataStore: dataStore,
+		options:   options,
 		clusters:  clusters,
 		watchers:  make(map[string]*ClusterWatcher),
 		ctx:       ctx,
@@ -118,6 +148,41 @@ func (w *VMWatcher) Stop() {
 
 // createClusterWatcher creates a watcher for a specific cluster
 func (w *VMWatcher) createClusterWatcher(cluster ClusterConfig) (*ClusterWatcher, error) {
+	factory := w.options.ClientFactory
+	if factory == nil {
+		factory = kubeconfigClients
+	}
+	clients, err := factory(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if clients.MigrationSyncURL != "" {
+		cluster.MigrationSyncURL = clients.MigrationSyncURL
+	}
+	k8sClient, kubevirtClient := clients.Kubernetes, clients.KubeVirt
+
+	ctx, cancel := context.WithCancel(w.ctx)
+
+	cw := &ClusterWatcher{
+		config:         cluster,
+		options:        w.options,
+		k8sClient:      k8sClient,
+		kubevirtClient: kubevirtClient,
+		dataStore:      w.dataStore,
+		ctx:            ctx,
+		cancel:         cancel,
+		vms:            make(map[string]*kubevirtv1.VirtualMachine),
+		vmis:           make(map[string]*kubevirtv1.VirtualMachineInstance),
+		nodes:          make(map[string]models.Node),
+		lookups:        newLookupCache(lookupTTL),
+	}
+	cw.debouncer = newDebouncer(defaultDebounceInterval, cw.flushVM)
+
+	return cw, nil
+}
+
+// kubeconfigClients builds the clients for a cluster from its kubeconfig
+func kubeconfigClients(cluster ClusterConfig) (*ClusterClients, error) {
 	// Build absolute path for kubeconfig
 	var kubeconfigPath string
 	if filepath.IsAbs(cluster.Kubeconfig) {
@@ -144,22 +209,18 @@ func (w *VMWatcher) createClusterWatcher(cluster ClusterConfig) (*ClusterWatcher
 		return nil, fmt.Errorf("failed to create kubevirt client: %w", err)
 	}
 
-	ctx, cancel := context.WithCancel(w.ctx)
-
-	return &ClusterWatcher{
-		config:         cluster,
-		k8sClient:      k8sClient,
-		kubevirtClient: kubevirtClient,
-		dataStore:      w.dataStore,
-		ctx:            ctx,
-		cancel:         cancel,
-	}, nil
+	return &ClusterClients{Kubernetes: k8sClient, KubeVirt: kubevirtClient}, nil
 }
 
 // start begins watching VMs in the cluster
 func (cw *ClusterWatcher) start() error {
 	log.Printf("Starting VM watcher for cluster %s", cw.config.Name)
 
+	// Initial sync - get all existing VMIs so VMs can be enriched from the cache
+	if err := cw.syncExistingVMIs(); err != nil {
+		log.Printf("Failed to sync existing VMIs for cluster %s: %v", cw.config.Name, err)
+	}
+
 	// Initial sync - get all existing VMs
 	if err := cw.syncExistingVMs(); err != nil {
 		log.Printf("Failed to sync existing VMs for cluster %s: %v", cw.config.Name, err)
@@ -170,24 +231,33 @@ func (cw *ClusterWatcher) start() error {
 		log.Printf("Failed to sync existing migrations for cluster %s: %v", cw.config.Name, err)
 	}
 
+	// Initial sync - get all existing nodes
+	if err := cw.syncExistingNodes(); err != nil {
+		log.Printf("Failed to sync existing nodes for cluster %s: %v", cw.config.Name, err)
+	}
+
 	// Start watching for VM changes
 	go cw.watchVMs()
 
+	// Start watching for VMI changes (IP, node, phase and guest agent updates)
+	go cw.watchVMIs()
+
 	// Start watching for migration changes
 	go cw.watchMigrations()
 
+	// Start watching for node changes (readiness, cordons, capacity)
+	go cw.watchNodes()
+
+	// Periodically clear migration state that has been finished for a while
+	go cw.clearStaleMigrationStatus()
+
 	return nil
 }
 
 // stop stops the cluster watcher
 func (cw *ClusterWatcher) stop() {
 	cw.cancel()
-	if cw.vmWatcher != nil {
-		cw.vmWatcher.Stop()
-	}
-	if cw.migrationWatcher != nil {
-		cw.migrationWatcher.Stop()
-	}
+	cw.debouncer.Stop()
 }
 
 // syncExistingVMs fetches all existing VMs and updates the database
@@ -201,8 +271,14 @@ func (cw *ClusterWatcher) syncExistingVMs() error {
 
 	log.Printf("Found %d VMs in cluster %s", len(vms.Items), cw.config.Name)
 
-	for _, vm := range vms.Items {
-		modelVM := cw.convertToModelVM(&vm)
+	for i := range vms.Items {
+		vm := &vms.Items[i]
+		cw.record(ResourceVM, watch.Added, vm)
+		cw.cacheMu.Lock()
+		cw.vms[objectKey(vm.Namespace, vm.Name)] = vm
+		cw.cacheMu.Unlock()
+
+		modelVM := cw.convertToModelVM(vm)
 
 		// Include all VMs regardless of status - let frontend handle filtering
 		log.Printf("Syncing VM %s (status: %s) in cluster %s", vm.Name, modelVM.Status, cw.config.Name)
@@ -228,15 +304,13 @@ func (cw *ClusterWatcher) watchVMs() error {
 		}
 
 		// Create a watcher for VirtualMachine resources
-		watcher, err := cw.kubevirtClient.VirtualMachine("").Watch(context.TODO(), metav1.ListOptions{})
+		watcher, err := cw.kubevirtClient.VirtualMachine("").Watch(cw.ctx, metav1.ListOptions{})
 		if err != nil {
 			log.Printf("Failed to create VM watcher for cluster %s: %v", cw.config.Name, err)
 			time.Sleep(30 * time.Second)
 			continue
 		}
 
-		cw.vmWatcher = watcher
-
 		// Process events in a loop
 	eventLoop:
 		for {
@@ -253,6 +327,7 @@ func (cw *ClusterWatcher) watchVMs() error {
 					break eventLoop
 				}
 
+				cw.record(ResourceVM, event.Type, event.Object)
 				if err := cw.handleVMEvent(event); err != nil {
 					log.Printf("Failed to handle VM event for cluster %s: %v", cw.config.Name, err)
 				}
@@ -261,6 +336,74 @@ func (cw *ClusterWatcher) watchVMs() error {
 	}
 }
 
+// syncExistingVMIs fetches all existing VMIs into the instance cache
+func (cw *ClusterWatcher) syncExistingVMIs() error {
+	log.Printf("Syncing existing VMIs for cluster %s", cw.config.Name)
+
+	vmis, err := cw.kubevirtClient.VirtualMachineInstance("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list VMIs: %w", err)
+	}
+
+	log.Printf("Found %d VMIs in cluster %s", len(vmis.Items), cw.config.Name)
+
+	cw.cacheMu.Lock()
+	defer cw.cacheMu.Unlock()
+	for i := range vmis.Items {
+		vmi := &vmis.Items[i]
+		cw.record(ResourceVMI, watch.Added, vmi)
+		cw.vmis[objectKey(vmi.Namespace, vmi.Name)] = vmi
+	}
+	cw.vmisSynced = true
+
+	return nil
+}
+
+// watchVMIs sets up a watch for VMI changes
+func (cw *ClusterWatcher) watchVMIs() error {
+	log.Printf("Starting VMI watch for cluster %s", cw.config.Name)
+
+	for {
+		select {
+		case <-cw.ctx.Done():
+			log.Printf("VMI watcher for cluster %s stopped", cw.config.Name)
+			return nil
+		default:
+		}
+
+		// Create a watcher for VirtualMachineInstance resources
+		watcher, err := cw.kubevirtClient.VirtualMachineInstance("").Watch(cw.ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Printf("Failed to create VMI watcher for cluster %s: %v", cw.config.Name, err)
+			time.Sleep(30 * time.Second)
+			continue
+		}
+
+		// Process events in a loop
+	eventLoop:
+		for {
+			select {
+			case <-cw.ctx.Done():
+				log.Printf("VMI watcher for cluster %s stopped", cw.config.Name)
+				watcher.Stop()
+				return nil
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					log.Printf("VMI watcher channel closed for cluster %s, restarting...", cw.config.Name)
+					watcher.Stop()
+					time.Sleep(5 * time.Second)
+					break eventLoop
+				}
+
+				cw.record(ResourceVMI, event.Type, event.Object)
+				if err := cw.handleVMIEvent(event); err != nil {
+					log.Printf("Failed to handle VMI event for cluster %s: %v", cw.config.Name, err)
+				}
+			}
+		}
+	}
+}
+
 // syncExistingMigrations fetches all existing migrations and updates the database
 func (cw *ClusterWatcher) syncExistingMigrations() error {
 	log.Printf("Syncing existing migrations for cluster %s", cw.config.Name)
@@ -273,6 +416,7 @@ func (cw *ClusterWatcher) syncExistingMigrations() error {
 	log.Printf("Found %d migrations in cluster %s", len(migrations.Items), cw.config.Name)
 
 	for _, migration := range migrations.Items {
+		cw.record(ResourceMigration, watch.Added, &migration)
 		modelMigration := cw.convertToModelMigration(&migration)
 
 		log.Printf("Syncing migration %s (phase: %s) in cluster %s", migration.Name, modelMigration.Phase, cw.config.Name)
@@ -298,15 +442,13 @@ func (cw *ClusterWatcher) watchMigrations() error {
 		}
 
 		// Create a watcher for VirtualMachineInstanceMigration resources
-		watcher, err := cw.kubevirtClient.VirtualMachineInstanceMigration("").Watch(context.TODO(), metav1.ListOptions{})
+		watcher, err := cw.kubevirtClient.VirtualMachineInstanceMigration("").Watch(cw.ctx, metav1.ListOptions{})
 		if err != nil {
 			log.Printf("Failed to create migration watcher for cluster %s: %v", cw.config.Name, err)
 			time.Sleep(30 * time.Second)
 			continue
 		}
 
-		cw.migrationWatcher = watcher
-
 		// Process events in a loop
 	eventLoop:
 		for {
@@ -323,6 +465,7 @@ func (cw *ClusterWatcher) watchMigrations() error {
 					break eventLoop
 				}
 
+				cw.record(ResourceMigration, event.Type, event.Object)
 				if err := cw.handleMigrationEvent(event); err != nil {
 					log.Printf("Failed to handle migration event for cluster %s: %v", cw.config.Name, err)
 				}
@@ -340,14 +483,19 @@ func (cw *ClusterWatcher) handleVMEvent(event watch.Event) error {
 
 	log.Printf("VM event: %s for VM %s in cluster %s", event.Type, vm.Name, cw.config.Name)
 
+	key := objectKey(vm.Namespace, vm.Name)
+
 	switch event.Type {
 	case watch.Added, watch.Modified:
-		modelVM := cw.convertToModelVM(vm)
-
-		// Include all VMs regardless of status - let frontend handle filtering
-		log.Printf("Processing VM %s (status: %s) from cluster %s", vm.Name, modelVM.Status, cw.config.Name)
-		return cw.updateVMInDatabase(modelVM)
+		cw.cacheMu.Lock()
+		cw.vms[key] = vm
+		cw.cacheMu.Unlock()
+		cw.debouncer.Trigger(key)
 	case watch.Deleted:
+		cw.debouncer.Cancel(key)
+		cw.cacheMu.Lock()
+		delete(cw.vms, key)
+		cw.cacheMu.Unlock()
 		return cw.removeVMFromDatabase(vm.Name)
 	default:
 		log.Printf("Unknown event type: %s", event.Type)
@@ -356,18 +504,94 @@ func (cw *ClusterWatcher) handleVMEvent(event watch.Event) error {
 	return nil
 }
 
+// handleVMIEvent processes a VMI watch event. Instance-level changes such as
+// IP assignment, node changes after migration and guest agent updates are
+// merged into the owning VM record through the debouncer.
+func (cw *ClusterWatcher) handleVMIEvent(event watch.Event) error {
+	vmi, ok := event.Object.(*kubevirtv1.VirtualMachineInstance)
+	if !ok {
+		return fmt.Errorf("unexpected object type: %T", event.Object)
+	}
+
+	log.Printf("VMI event: %s for VMI %s in cluster %s", event.Type, vmi.Name, cw.config.Name)
+
+	key := objectKey(vmi.Namespace, vmi.Name)
+
+	cw.cacheMu.Lock()
+	switch event.Type {
+	case watch.Added, watch.Modified:
+		cw.vmis[key] = vmi
+	case watch.Deleted:
+		delete(cw.vmis, key)
+	default:
+		cw.cacheMu.Unlock()
+		log.Printf("Unknown VMI event type: %s", event.Type)
+		return nil
+	}
+	_, hasVM := cw.vms[key]
+	cw.cacheMu.Unlock()
+
+	// Standalone VMIs without a VirtualMachine are not tracked
+	if hasVM {
+		cw.debouncer.Trigger(key)
+	}
+
+	return nil
+}
+
+// flushVM converts the cached VM (merged with its VMI) and writes it to the store
+func (cw *ClusterWatcher) flushVM(key string) {
+	cw.cacheMu.RLock()
+	vm, ok := cw.vms[key]
+	cw.cacheMu.RUnlock()
+	if !ok {
+		return
+	}
+
+	modelVM := cw.convertToModelVM(vm)
+
+	// Include all VMs regardless of status - let frontend handle filtering
+	log.Printf("Processing VM %s (status: %s) from cluster %s", vm.Name, modelVM.Status, cw.config.Name)
+	if err := cw.updateVMInDatabase(modelVM); err != nil {
+		log.Printf("Failed to update VM %s in database: %v", vm.Name, err)
+	}
+}
+
+// objectKey returns the cache key for a namespaced object
+func objectKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
 // convertToModelVM converts a KubeVirt VM to our internal VM model
 func (cw *ClusterWatcher) convertToModelVM(vm *kubevirtv1.VirtualMachine) *models.VM {
+	createdAt := vm.CreationTimestamp.Time
 	modelVM := &models.VM{
-		ID:        vm.Name,
-		Name:      vm.Name,
-		Cluster:   cw.config.Name, // Add cluster information
-		Namespace: vm.Namespace,
-		Age:       cw.formatAge(vm.CreationTimestamp.Time),
+		ID:          vm.Name,
+		Name:        vm.Name,
+		Cluster:     cw.config.Name, // Add cluster information
+		Namespace:   vm.Namespace,
+		CreatedAt:   &createdAt,
+		Labels:      vm.Labels,
+		Annotations: vm.Annotations,
 	}
 
-	// Set default disk size (will be updated from VMI status later)
-	modelVM.Disk = 100
+	if runStrategy, err := vm.RunStrategy(); err == nil {
+		modelVM.RunStrategy = string(runStrategy)
+	}
+
+	for _, condition := range vm.Status.Conditions {
+		modelCondition := models.VMCondition{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		}
+		if !condition.LastTransitionTime.IsZero() {
+			transition := condition.LastTransitionTime.Time
+			modelCondition.LastTransitionTime = &transition
+		}
+		modelVM.Conditions = append(modelVM.Conditions, modelCondition)
+	}
 
 	// Get VM status
 	modelVM.Status = "unknown"
@@ -408,21 +632,46 @@ func (cw *ClusterWatcher) convertToModelVM(vm *kubevirtv1.VirtualMachine) *model
 		}
 	}
 
-	// Try to get VM instance for more detailed info
+	// Try to get VM instance for more detailed info, otherwise derive
+	// resources from the VM spec
+	enriched := false
 	if modelVM.Status == "running" || modelVM.Status == "migrating" || modelVM.Status == "waitingforreceiver" {
-		cw.enrichVMWithInstanceInfo(modelVM)
+		enriched = cw.enrichVMWithInstanceInfo(vm, modelVM)
+	}
+	if !enriched {
+		cw.enrichVMWithSpecInfo(vm, modelVM)
 	}
 
 	return modelVM
 }
 
-// enrichVMWithInstanceInfo adds additional information from the VMI
-func (cw *ClusterWatcher) enrichVMWithInstanceInfo(modelVM *models.VM) {
+// getVMI returns the VMI backing a VM from the watch cache. Only before
+// the VMIs have been listed does it fall back to the API.
+func (cw *ClusterWatcher) getVMI(namespace, name string) (*kubevirtv1.VirtualMachineInstance, error) {
+	cw.cacheMu.RLock()
+	vmi, ok := cw.vmis[objectKey(namespace, name)]
+	synced := cw.vmisSynced
+	cw.cacheMu.RUnlock()
+	if ok {
+		return vmi, nil
+	}
+	if synced {
+		return nil, apierrors.NewNotFound(kubevirtv1.Resource("virtualmachineinstances"), name)
+	}
+
+	return cw.kubevirtClient.VirtualMachineInstance(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// enrichVMWithInstanceInfo adds additional information from the VMI. It
+// returns false when no VMI could be found for the VM.
+func (cw *ClusterWatcher) enrichVMWithInstanceInfo(vm *kubevirtv1.VirtualMachine, modelVM *models.VM) bool {
 	// Get VMI for additional info
-	vmi, err := cw.kubevirtClient.VirtualMachineInstance(modelVM.Namespace).Get(context.TODO(), modelVM.Name, metav1.GetOptions{})
+	vmi, err := cw.getVMI(modelVM.Namespace, modelVM.Name)
 	if err != nil {
-		log.Printf("Failed to get VMI for VM %s: %v", modelVM.Name, err)
-		return
+		if !apierrors.IsNotFound(err) {
+			log.Printf("Failed to get VMI for VM %s: %v", modelVM.Name, err)
+		}
+		return false
 	}
 
 	// Node name
@@ -430,13 +679,36 @@ func (cw *ClusterWatcher) enrichVMWithInstanceInfo(modelVM *models.VM) {
 		modelVM.NodeName = vmi.Status.NodeName
 	}
 
-	// IP addresses
-	if len(vmi.Status.Interfaces) > 0 {
-		for _, iface := range vmi.Status.Interfaces {
-			if iface.IP != "" {
-				modelVM.IP = iface.IP
-				break
+	// Interfaces and IP addresses - the first IP is kept as the primary IP
+	for _, iface := range vmi.Status.Interfaces {
+		ips := iface.IPs
+		if len(ips) == 0 && iface.IP != "" {
+			ips = []string{iface.IP}
+		}
+		modelInterface := models.Interface{
+			Name: iface.Name,
+			MAC:  iface.MAC,
+		}
+		for _, ip := range ips {
+			if ip == "" {
+				continue
+			}
+			if modelVM.IP == "" {
+				modelVM.IP = ip
 			}
+			modelVM.IPs = append(modelVM.IPs, ip)
+			modelInterface.IPs = append(modelInterface.IPs, ip)
+		}
+		modelVM.Interfaces = append(modelVM.Interfaces, modelInterface)
+	}
+
+	// Guest OS information from the guest agent
+	if guestOS := vmi.Status.GuestOSInfo; guestOS.Name != "" || guestOS.ID != "" {
+		modelVM.GuestOS = &models.GuestOSInfo{
+			Name:          guestOS.Name,
+			Version:       guestOS.Version,
+			ID:            guestOS.ID,
+			KernelRelease: guestOS.KernelRelease,
 		}
 	}
 
@@ -446,51 +718,33 @@ func (cw *ClusterWatcher) enrichVMWithInstanceInfo(modelVM *models.VM) {
 		modelVM.Ready = vmi.Status.Phase == kubevirtv1.Running
 	}
 
-	// Extract CPU from VMI spec domain.cpu.sockets
-	if vmi.Spec.Domain.CPU != nil && vmi.Spec.Domain.CPU.Sockets != 0 {
-		modelVM.CPU = int(vmi.Spec.Domain.CPU.Sockets)
-	}
-
-	// Extract Memory from VMI spec domain.memory.guest
-	if vmi.Spec.Domain.Memory != nil && vmi.Spec.Domain.Memory.Guest != nil {
-		if memoryVal, ok := vmi.Spec.Domain.Memory.Guest.AsInt64(); ok {
-			// Convert from bytes to MB
-			modelVM.Memory = int(memoryVal / (1024 * 1024))
-		}
-	}
+	// CPU (sockets x cores x threads) and memory from the VMI domain, which
+	// already has any instancetype applied
+	applyDomainResources(modelVM, &vmi.Spec.Domain)
 
-	// Extract Disk size from VMI status volumeStatus for rootdisk
-	if len(vmi.Status.VolumeStatus) > 0 {
-		for _, volume := range vmi.Status.VolumeStatus {
-			if volume.Name == "rootdisk" && volume.PersistentVolumeClaimInfo != nil {
-				if storage, exists := volume.PersistentVolumeClaimInfo.Capacity["storage"]; exists && !storage.IsZero() {
-					// Use Value() method to get the storage in bytes
-					storageBytes := storage.Value()
-					if storageBytes > 0 {
-						// Convert from bytes to GB
-						modelVM.Disk = int(storageBytes / (1024 * 1024 * 1024))
-					}
-				}
-				break
-			}
-		}
-	}
-}
+	// Every disk, sized from the volume status of the running instance
+	modelVM.Disks = cw.extractDisks(vmi.Namespace, vmi.Spec.Domain.Devices.Disks,
+		vmi.Spec.Volumes, vm.Spec.DataVolumeTemplates, vmi.Status.VolumeStatus, "")
+	modelVM.Disk = totalDiskGB(modelVM.Disks)
 
-// formatAge formats the age of the VM
-func (cw *ClusterWatcher) formatAge(t time.Time) string {
-	age := time.Since(t)
-	if age < time.Hour {
-		return fmt.Sprintf("%dm", int(age.Minutes()))
-	} else if age < 24*time.Hour {
-		return fmt.Sprintf("%dh", int(age.Hours()))
-	} else {
-		return fmt.Sprintf("%dd", int(age.Hours()/24))
-	}
+	return true
 }
 
 // updateVMInDatabase updates or creates a VM in the database
 func (cw *ClusterWatcher) updateVMInDatabase(vm *models.VM) error {
+	// Migration state is owned by migration events - keep what is stored
+	// unless the VM itself reports an ongoing migration
+	if existing, err := cw.dataStore.GetVM(cw.config.DatacenterID, vm.ID); err == nil {
+		if vm.MigrationStatus == "" {
+			vm.MigrationStatus = existing.MigrationStatus
+			vm.MigrationSource = existing.MigrationSource
+			vm.MigrationTarget = existing.MigrationTarget
+		}
+		if vm.LastMigratedAt == nil {
+			vm.LastMigratedAt = existing.LastMigratedAt
+		}
+	}
+
 	// First try to update existing VM with complete VM model
 	_, err := cw.dataStore.UpdateVMComplete(cw.config.DatacenterID, vm.ID, vm)
 	if err != nil {
@@ -578,6 +832,9 @@ func (cw *ClusterWatcher) handleMigrationEvent(event watch.Event) error {
 		log.Printf("Processing migration %s (phase: %s) from cluster %s", migration.Name, modelMigration.Phase, cw.config.Name)
 		return cw.updateMigrationInDatabase(modelMigration)
 	case watch.Deleted:
+		if cw.isAborting(migration.Name) {
+			return cw.finishAbortedMigration(migration.Name)
+		}
 		return cw.removeMigrationFromDatabase(migration.Name)
 	default:
 		log.Printf("Unknown migration event type: %s", event.Type)
@@ -655,7 +912,11 @@ func (cw *ClusterWatcher) convertToModelMigration(migration *kubevirtv1.VirtualM
 	// Check if migration is being deleted (has deletionTimestamp)
 	if migration.DeletionTimestamp != nil {
 		if phase != "Aborted" && phase != "Failed" && phase != "Succeeded" {
+			// Deleting a running migration is how an abort is requested
 			phase = "Terminating"
+			if cw.isAborting(migration.Name) {
+				phase = "Aborted"
+			}
 			completed = true
 		}
 		log.Printf("Migration %s is being deleted (deletionTimestamp: %v)", migration.Name, migration.DeletionTimestamp)
@@ -692,6 +953,33 @@ func (cw *ClusterWatcher) convertToModelMigration(migration *kubevirtv1.VirtualM
 			modelMigration.EndTime = &migState.EndTimestamp.Time
 		}
 
+		if migState.TargetNodeDomainReadyTimestamp != nil {
+			modelMigration.TargetNodeDomainReadyTime = &migState.TargetNodeDomainReadyTimestamp.Time
+		}
+
+		// Outcome, mode and policy
+		modelMigration.Failed = migState.Failed
+		modelMigration.FailureReason = migState.FailureReason
+		modelMigration.AbortRequested = migState.AbortRequested
+		modelMigration.AbortStatus = string(migState.AbortStatus)
+		modelMigration.Mode = string(migState.Mode)
+		if migState.MigrationPolicyName != nil {
+			modelMigration.MigrationPolicyName = *migState.MigrationPolicyName
+		}
+		modelMigration.MigrationConfiguration = convertMigrationConfiguration(migState.MigrationConfiguration)
+
+		if migState.Failed {
+			if modelMigration.Phase != "Aborted" {
+				modelMigration.Phase = "Failed"
+			}
+			completed = true
+			log.Printf("Migration %s failed: %s", migration.Name, migState.FailureReason)
+		}
+		if migState.AbortStatus == kubevirtv1.MigrationAbortSucceeded {
+			modelMigration.Phase = "Aborted"
+			completed = true
+		}
+
 		// Completion status - use our enhanced detection
 		modelMigration.Completed = completed || migState.Completed
 	} else {
@@ -708,12 +996,92 @@ func (cw *ClusterWatcher) convertToModelMigration(migration *kubevirtv1.VirtualM
 			})
 		}
 	}
+	modelMigration.PhaseDurations = migrationPhaseDurations(modelMigration.PhaseTransitions, modelMigration.Completed, modelMigration.UpdatedAt)
+
+	// Keep the raw conditions for troubleshooting
+	for _, condition := range migration.Status.Conditions {
+		modelCondition := models.MigrationCondition{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		}
+		if !condition.LastTransitionTime.IsZero() {
+			transitionTime := condition.LastTransitionTime.Time
+			modelCondition.LastTransitionTime = &transitionTime
+		}
+		modelMigration.Conditions = append(modelMigration.Conditions, modelCondition)
+	}
 
 	return modelMigration
 }
 
+// convertMigrationConfiguration converts the effective KubeVirt migration
+// configuration reported in the migration state
+func convertMigrationConfiguration(config *kubevirtv1.MigrationConfiguration) *models.MigrationConfiguration {
+	if config == nil {
+		return nil
+	}
+	modelConfig := &models.MigrationConfiguration{
+		AllowAutoConverge:                 config.AllowAutoConverge,
+		AllowPostCopy:                     config.AllowPostCopy,
+		AllowWorkloadDisruption:           config.AllowWorkloadDisruption,
+		CompletionTimeoutPerGiB:           config.CompletionTimeoutPerGiB,
+		ProgressTimeout:                   config.ProgressTimeout,
+		ParallelMigrationsPerCluster:      config.ParallelMigrationsPerCluster,
+		ParallelOutboundMigrationsPerNode: config.ParallelOutboundMigrationsPerNode,
+		UnsafeMigrationOverride:           config.UnsafeMigrationOverride,
+		DisableTLS:                        config.DisableTLS,
+	}
+	if config.BandwidthPerMigration != nil {
+		modelConfig.BandwidthPerMigration = config.BandwidthPerMigration.String()
+	}
+	if config.Network != nil {
+		modelConfig.Network = *config.Network
+	}
+	return modelConfig
+}
+
+// migrationPhaseDurations derives the time spent in each phase from the
+// phase transition history. The last phase of a migration that is still
+// running is measured up to now.
+func migrationPhaseDurations(transitions []models.MigrationTransition, completed bool, now time.Time) []models.MigrationPhaseDuration {
+	if len(transitions) == 0 {
+		return nil
+	}
+
+	sorted := make([]models.MigrationTransition, len(transitions))
+	copy(sorted, transitions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	var durations []models.MigrationPhaseDuration
+	for i, transition := range sorted {
+		if i+1 < len(sorted) {
+			durations = append(durations, models.MigrationPhaseDuration{
+				Phase:           transition.Phase,
+				DurationSeconds: sorted[i+1].Timestamp.Sub(transition.Timestamp).Seconds(),
+			})
+			continue
+		}
+		// The final phase of a finished migration is terminal and has no duration
+		if !completed {
+			durations = append(durations, models.MigrationPhaseDuration{
+				Phase:           transition.Phase,
+				DurationSeconds: now.Sub(transition.Timestamp).Seconds(),
+				Current:         true,
+			})
+		}
+	}
+	return durations
+}
+
 // updateMigrationInDatabase updates or creates a migration in the database
 func (cw *ClusterWatcher) updateMigrationInDatabase(migration *models.Migration) error {
+	// Pair cross-cluster migrations with their other half
+	logical := cw.correlateMigration(migration)
+
 	// Try to get existing migration
 	existing, err := cw.dataStore.GetMigration(migration.ID)
 	if err != nil {
@@ -741,27 +1109,173 @@ func (cw *ClusterWatcher) updateMigrationInDatabase(migration *models.Migration)
 		// Don't return error - migration update succeeded, VM update is secondary
 	}
 
+	if logical != nil {
+		DefaultHub.BroadcastEvent("migration:logical", map[string]interface{}{
+			"datacenters": []string{logical.SourceDatacenterID, logical.TargetDatacenterID},
+			"migration":   logical,
+		})
+		cw.moveVMForLogicalMigration(logical)
+	}
+
 	return nil
 }
 
 // updateVMByMigration updates the VM's migration status based on the migration
 func (cw *ClusterWatcher) updateVMByMigration(migration *models.Migration) error {
-	// Find the VM in our datacenter - we need to iterate through all VMs to find the right one
-	// Since there's no direct GetVM method, we'll try to update the VM by getting all VMs first
-
 	dcID := cw.config.DatacenterID
 
-	// For now, let's use a simpler approach and just log that we detected a migration
-	// We'll update this when we need the VM migration status tracking
-	log.Printf("Detected migration event for VM %s in datacenter %s (phase: %s)",
-		migration.VMName, dcID, migration.Phase)
+	vm, err := cw.dataStore.GetVM(dcID, migration.VMName)
+	if err != nil {
+		// The VM may not be known here yet, e.g. the target side of a
+		// cross-cluster migration before its VM object appears
+		log.Printf("VM %s for migration %s not found in datacenter %s, skipping VM update",
+			migration.VMName, migration.ID, dcID)
+		return nil
+	}
+
+	// A newer migration for the same VM owns the migration state
+	if latest := cw.latestMigrationForVM(migration.VMName); latest != nil && latest.ID != migration.ID &&
+		latest.CreatedAt.After(migration.CreatedAt) {
+		return nil
+	}
+
+	status := migrationStatusForPhase(migration)
+	finishedAt := migrationFinishedAt(migration)
+	if status != "migrating" && time.Since(finishedAt) > cw.options.MigrationStatusRetention {
+		// Finished long ago (e.g. during initial sync) - nothing to show
+		status = ""
+	}
+
+	vm.MigrationStatus = status
+	if status == "" {
+		vm.MigrationSource = ""
+		vm.MigrationTarget = ""
+	} else {
+		vm.MigrationSource = firstNonEmpty(migration.SourceCluster, migration.SourceNode)
+		vm.MigrationTarget = firstNonEmpty(migration.TargetCluster, migration.TargetNode)
+	}
+	if migration.Phase == "Succeeded" {
+		vm.LastMigratedAt = &finishedAt
+	}
+
+	if _, err := cw.dataStore.UpdateVMComplete(dcID, vm.ID, vm); err != nil {
+		return fmt.Errorf("failed to update VM %s: %w", vm.ID, err)
+	}
 
-	// The migration tracking is already working through the migration records
-	// VM status will be updated when the VM itself is updated by the VM watcher
+	log.Printf("Updated VM %s in datacenter %s from migration %s (phase: %s, status: %q)",
+		vm.Name, dcID, migration.ID, migration.Phase, status)
+	DefaultHub.BroadcastEvent("vm:updated", map[string]interface{}{"datacenter": dcID, "vm": vm})
 
 	return nil
 }
 
+// clearStaleMigrationStatus periodically clears migration state from VMs
+// whose latest migration finished longer ago than the retention period
+func (cw *ClusterWatcher) clearStaleMigrationStatus() {
+	interval := time.Minute
+	if cw.options.MigrationStatusRetention > 0 && cw.options.MigrationStatusRetention < interval {
+		interval = cw.options.MigrationStatusRetention
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cw.ctx.Done():
+			return
+		case <-ticker.C:
+			cw.clearStaleMigrationStatusOnce()
+		}
+	}
+}
+
+// clearStaleMigrationStatusOnce performs a single stale migration state sweep
+func (cw *ClusterWatcher) clearStaleMigrationStatusOnce() {
+	dcID := cw.config.DatacenterID
+	for _, dc := range cw.dataStore.GetDatacenters().Datacenters {
+		if dc.ID != dcID {
+			continue
+		}
+		for i := range dc.VMs {
+			vm := &dc.VMs[i]
+			if vm.Cluster != cw.config.Name || (vm.MigrationStatus != "completed" && vm.MigrationStatus != "failed") {
+				continue
+			}
+
+			if latest := cw.latestMigrationForVM(vm.Name); latest != nil {
+				if !latest.Completed || time.Since(migrationFinishedAt(latest)) <= cw.options.MigrationStatusRetention {
+					continue
+				}
+			}
+
+			vm.MigrationStatus = ""
+			vm.MigrationSource = ""
+			vm.MigrationTarget = ""
+			if _, err := cw.dataStore.UpdateVMComplete(dcID, vm.ID, vm); err != nil {
+				log.Printf("Failed to clear migration status of VM %s: %v", vm.Name, err)
+				continue
+			}
+			log.Printf("Cleared stale migration status of VM %s in datacenter %s", vm.Name, dcID)
+			DefaultHub.BroadcastEvent("vm:updated", map[string]interface{}{"datacenter": dcID, "vm": vm})
+		}
+	}
+}
+
+// latestMigrationForVM returns the most recently created migration of a VM
+// in this cluster, or nil if there is none
+func (cw *ClusterWatcher) latestMigrationForVM(vmName string) *models.Migration {
+	migrations, err := cw.dataStore.GetMigrationsByVM(vmName)
+	if err != nil {
+		return nil
+	}
+
+	var latest *models.Migration
+	for i := range migrations {
+		migration := &migrations[i]
+		if migration.Cluster != cw.config.Name {
+			continue
+		}
+		if latest == nil || migration.CreatedAt.After(latest.CreatedAt) {
+			latest = migration
+		}
+	}
+	return latest
+}
+
+// migrationStatusForPhase maps a migration to the VM migration status
+// ("migrating", "completed" or "failed")
+func migrationStatusForPhase(migration *models.Migration) string {
+	switch migration.Phase {
+	case "Succeeded":
+		return "completed"
+	case "Failed", "Aborted", "Terminating":
+		return "failed"
+	}
+	if migration.Completed {
+		return "failed"
+	}
+	return "migrating"
+}
+
+// migrationFinishedAt returns when a migration finished, falling back to
+// its last update time when no end timestamp was reported
+func migrationFinishedAt(migration *models.Migration) time.Time {
+	if migration.EndTime != nil {
+		return *migration.EndTime
+	}
+	return migration.UpdatedAt
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 // removeMigrationFromDatabase removes a migration from the database
 func (cw *ClusterWatcher) removeMigrationFromDatabase(migrationName string) error {
 	err := cw.dataStore.RemoveMigration(migrationName)
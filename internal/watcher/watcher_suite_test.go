@@ -0,0 +1,13 @@
+package watcher
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestWatcher(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Watcher Suite")
+}
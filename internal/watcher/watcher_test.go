@@ -0,0 +1,581 @@
+package watcher
+
+import (
+	"context"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/mocks"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+)
+
+var _ = Describe("Resource extraction", func() {
+	Describe("cpuCount", func() {
+		It("should multiply sockets, cores and threads", func() {
+			Expect(cpuCount(&kubevirtv1.CPU{Sockets: 2, Cores: 4, Threads: 2})).To(Equal(16))
+		})
+
+		It("should treat unset values as one", func() {
+			Expect(cpuCount(&kubevirtv1.CPU{Cores: 4})).To(Equal(4))
+		})
+
+		It("should return zero without a topology", func() {
+			Expect(cpuCount(nil)).To(Equal(0))
+		})
+	})
+
+	Describe("memoryMB", func() {
+		It("should prefer guest memory", func() {
+			guest := resource.MustParse("4Gi")
+			domain := &kubevirtv1.DomainSpec{Memory: &kubevirtv1.Memory{Guest: &guest}}
+			Expect(memoryMB(domain)).To(Equal(4096))
+		})
+
+		It("should fall back to the memory request", func() {
+			domain := &kubevirtv1.DomainSpec{Resources: kubevirtv1.ResourceRequirements{
+				Requests: k8sv1.ResourceList{k8sv1.ResourceMemory: resource.MustParse("2Gi")},
+			}}
+			Expect(memoryMB(domain)).To(Equal(2048))
+		})
+	})
+
+	Describe("extractDisks", func() {
+		It("should record every disk with size, storage class and volume type", func() {
+			cw := &ClusterWatcher{config: ClusterConfig{Name: "test"}}
+			storageClass := "ocs-storagecluster-ceph-rbd"
+
+			disks := []kubevirtv1.Disk{{Name: "rootdisk"}, {Name: "scratch"}, {Name: "cloudinitdisk"}}
+			volumes := []kubevirtv1.Volume{
+				{Name: "rootdisk", VolumeSource: kubevirtv1.VolumeSource{DataVolume: &kubevirtv1.DataVolumeSource{Name: "vm-rootdisk"}}},
+				{Name: "scratch", VolumeSource: kubevirtv1.VolumeSource{EmptyDisk: &kubevirtv1.EmptyDiskSource{Capacity: resource.MustParse("10Gi")}}},
+				{Name: "cloudinitdisk", VolumeSource: kubevirtv1.VolumeSource{CloudInitNoCloud: &kubevirtv1.CloudInitNoCloudSource{}}},
+			}
+			templates := []kubevirtv1.DataVolumeTemplateSpec{{
+				ObjectMeta: metav1.ObjectMeta{Name: "vm-rootdisk"},
+				Spec: cdiv1.DataVolumeSpec{Storage: &cdiv1.StorageSpec{
+					StorageClassName: &storageClass,
+					Resources: k8sv1.VolumeResourceRequirements{
+						Requests: k8sv1.ResourceList{k8sv1.ResourceStorage: resource.MustParse("30Gi")},
+					},
+				}},
+			}}
+
+			result := cw.extractDisks("default", disks, volumes, templates, nil, "")
+			Expect(result).To(Equal([]models.Disk{
+				{Name: "rootdisk", SizeGB: 30, StorageClass: storageClass, VolumeType: "dataVolume"},
+				{Name: "scratch", SizeGB: 10, VolumeType: "emptyDisk"},
+				{Name: "cloudinitdisk", VolumeType: "cloudInitNoCloud"},
+			}))
+			Expect(totalDiskGB(result)).To(Equal(40))
+		})
+
+		It("should fetch a backing PVC once for many conversions", func() {
+			storageClass := "lvms-vg1"
+			pvcs := &countingPVCs{pvc: &k8sv1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+				Spec:       k8sv1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+				Status: k8sv1.PersistentVolumeClaimStatus{
+					Capacity: k8sv1.ResourceList{k8sv1.ResourceStorage: resource.MustParse("20Gi")},
+				},
+			}}
+			cw := &ClusterWatcher{config: ClusterConfig{Name: "test"}, k8sClient: &fakeKubernetes{pvcs: pvcs}, lookups: newLookupCache(time.Minute)}
+			disks := []kubevirtv1.Disk{{Name: "data"}, {Name: "missing"}}
+			volumes := []kubevirtv1.Volume{
+				{Name: "data", VolumeSource: kubevirtv1.VolumeSource{PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+					PersistentVolumeClaimVolumeSource: k8sv1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+				}}},
+				{Name: "missing", VolumeSource: kubevirtv1.VolumeSource{PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+					PersistentVolumeClaimVolumeSource: k8sv1.PersistentVolumeClaimVolumeSource{ClaimName: "missing"},
+				}}},
+			}
+
+			for i := 0; i < 3; i++ {
+				result := cw.extractDisks("default", disks, volumes, nil, nil, "")
+				Expect(result).To(Equal([]models.Disk{
+					{Name: "data", SizeGB: 20, StorageClass: storageClass, VolumeType: "persistentVolumeClaim"},
+					{Name: "missing", VolumeType: "persistentVolumeClaim"},
+				}))
+			}
+			Expect(pvcs.gets).To(Equal(map[string]int{"data": 1, "missing": 1}))
+		})
+	})
+
+	Describe("lookupCache", func() {
+		It("should keep results for the TTL and pinned results for good", func() {
+			cache := newLookupCache(20 * time.Millisecond)
+			cache.put("pvc/default/data", "fetched", false)
+			cache.put("instancetype//u1.small@rev-1", "pinned", true)
+			cached := func(key string) func() interface{} {
+				return func() interface{} {
+					value, _ := cache.get(key)
+					return value
+				}
+			}
+
+			Expect(cached("pvc/default/data")()).To(Equal("fetched"))
+			Eventually(cached("pvc/default/data")).Should(BeNil())
+			Expect(cached("instancetype//u1.small@rev-1")()).To(Equal("pinned"))
+		})
+
+		It("should keep nothing when nil", func() {
+			var cache *lookupCache
+			cache.put("pvc/default/data", "fetched", false)
+			_, ok := cache.get("pvc/default/data")
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
+
+// fakeKubernetes serves PersistentVolumeClaims and lists nodes; any other
+// call panics
+type fakeKubernetes struct {
+	kubernetes.Interface
+	pvcs  *countingPVCs
+	nodes *k8sv1.NodeList
+}
+
+func (f *fakeKubernetes) CoreV1() corev1client.CoreV1Interface {
+	return &fakeCoreV1{fake: f}
+}
+
+type fakeCoreV1 struct {
+	corev1client.CoreV1Interface
+	fake *fakeKubernetes
+}
+
+func (f *fakeCoreV1) PersistentVolumeClaims(string) corev1client.PersistentVolumeClaimInterface {
+	return f.fake.pvcs
+}
+
+func (f *fakeCoreV1) Nodes() corev1client.NodeInterface {
+	return &fakeNodes{list: f.fake.nodes}
+}
+
+// fakeNodes lists a fixed set of nodes
+type fakeNodes struct {
+	corev1client.NodeInterface
+	list *k8sv1.NodeList
+}
+
+func (f *fakeNodes) List(context.Context, metav1.ListOptions) (*k8sv1.NodeList, error) {
+	return f.list.DeepCopy(), nil
+}
+
+// countingPVCs serves one PVC and counts the Gets by name
+type countingPVCs struct {
+	corev1client.PersistentVolumeClaimInterface
+	pvc  *k8sv1.PersistentVolumeClaim
+	gets map[string]int
+}
+
+func (c *countingPVCs) Get(_ context.Context, name string, _ metav1.GetOptions) (*k8sv1.PersistentVolumeClaim, error) {
+	if c.gets == nil {
+		c.gets = make(map[string]int)
+	}
+	c.gets[name]++
+	if name != c.pvc.Name {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "persistentvolumeclaims"}, name)
+	}
+	return c.pvc.DeepCopy(), nil
+}
+
+var _ = Describe("Migration driven VM state", func() {
+	var (
+		store *mocks.MockStore
+		cw    *ClusterWatcher
+	)
+
+	BeforeEach(func() {
+		store = mocks.NewMockStore()
+		store.InitializeWithSampleData()
+		_, err := store.UpdateVMComplete("dc-test-1", "vm-001", &models.VM{
+			ID: "vm-001", Name: "vm-001", Status: "running", Cluster: "test-cluster",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		cw = &ClusterWatcher{
+			config:    ClusterConfig{Name: "test-cluster", DatacenterID: "dc-test-1"},
+			options:   Options{MigrationStatusRetention: time.Minute},
+			dataStore: store,
+		}
+	})
+
+	It("should mark the VM as migrating with source and target nodes", func() {
+		migration := &models.Migration{
+			ID: "mig-1", VMName: "vm-001", Cluster: "test-cluster", Phase: "Running",
+			SourceNode: "worker-1", TargetNode: "worker-2", CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}
+		Expect(store.AddMigration(*migration)).To(Succeed())
+		Expect(cw.updateVMByMigration(migration)).To(Succeed())
+
+		vm, err := store.GetVM("dc-test-1", "vm-001")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vm.MigrationStatus).To(Equal("migrating"))
+		Expect(vm.MigrationSource).To(Equal("worker-1"))
+		Expect(vm.MigrationTarget).To(Equal("worker-2"))
+	})
+
+	It("should mark the VM as completed and record the completion time", func() {
+		end := time.Now().Add(-10 * time.Second)
+		migration := &models.Migration{
+			ID: "mig-1", VMName: "vm-001", Cluster: "test-cluster", Phase: "Succeeded", Completed: true,
+			SourceNode: "worker-1", TargetNode: "worker-2", EndTime: &end, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}
+		Expect(store.AddMigration(*migration)).To(Succeed())
+		Expect(cw.updateVMByMigration(migration)).To(Succeed())
+
+		vm, err := store.GetVM("dc-test-1", "vm-001")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vm.MigrationStatus).To(Equal("completed"))
+		Expect(vm.LastMigratedAt).NotTo(BeNil())
+		Expect(vm.LastMigratedAt.Equal(end)).To(BeTrue())
+	})
+
+	It("should clear migration state once the retention has passed", func() {
+		end := time.Now().Add(-2 * time.Minute)
+		migration := models.Migration{
+			ID: "mig-1", VMName: "vm-001", Cluster: "test-cluster", Phase: "Failed", Completed: true,
+			EndTime: &end, CreatedAt: end, UpdatedAt: end,
+		}
+		Expect(store.AddMigration(migration)).To(Succeed())
+		_, err := store.UpdateVMComplete("dc-test-1", "vm-001", &models.VM{
+			ID: "vm-001", Name: "vm-001", Status: "running", Cluster: "test-cluster",
+			MigrationStatus: "failed", MigrationSource: "worker-1", MigrationTarget: "worker-2",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		cw.clearStaleMigrationStatusOnce()
+
+		vm, err := store.GetVM("dc-test-1", "vm-001")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vm.MigrationStatus).To(BeEmpty())
+		Expect(vm.MigrationSource).To(BeEmpty())
+		Expect(vm.MigrationTarget).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Cross-cluster migration correlation", func() {
+	var (
+		store *mocks.MockStore
+		cw    *ClusterWatcher
+	)
+
+	BeforeEach(func() {
+		store = mocks.NewMockStore()
+		store.InitializeWithSampleData()
+		cw = &ClusterWatcher{
+			config:    ClusterConfig{Name: "vulcan", DatacenterID: "dc-test-2"},
+			options:   DefaultOptions(),
+			dataStore: store,
+		}
+	})
+
+	It("should fill in both sides and move the VM when the migration succeeds", func() {
+		outgoing := models.Migration{
+			ID: "out-1", VMName: "test-vm-1", Cluster: "coruscant", DatacenterID: "dc-test-1",
+			Direction: "outgoing", MigrationID: "mig-abc", Phase: "Succeeded", Completed: true, CreatedAt: time.Now(),
+		}
+		Expect(store.AddMigration(outgoing)).To(Succeed())
+
+		incoming := &models.Migration{
+			ID: "in-1", VMName: "test-vm-1", Cluster: "vulcan", DatacenterID: "dc-test-2",
+			Direction: "incoming", MigrationID: "mig-abc", Phase: "Succeeded", Completed: true, CreatedAt: time.Now(),
+		}
+		logical := cw.correlateMigration(incoming)
+		Expect(logical).NotTo(BeNil())
+		Expect(incoming.SourceCluster).To(Equal("coruscant"))
+		Expect(incoming.SourceDatacenterID).To(Equal("dc-test-1"))
+
+		peer, err := store.GetMigration("out-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(peer.TargetCluster).To(Equal("vulcan"))
+		Expect(peer.TargetDatacenterID).To(Equal("dc-test-2"))
+
+		// The sample VM vm-001 is named test-vm-1 but keyed by ID; add one keyed by name
+		_, err = store.AddVM("dc-test-1", models.VM{ID: "test-vm-1", Name: "test-vm-1", Status: "running"})
+		Expect(err).NotTo(HaveOccurred())
+
+		cw.moveVMForLogicalMigration(logical)
+
+		_, err = store.GetVM("dc-test-1", "test-vm-1")
+		Expect(err).To(HaveOccurred())
+		vm, err := store.GetVM("dc-test-2", "test-vm-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vm.LastMigratedAt).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("Migration conversion", func() {
+	It("should capture failure reason, mode, policy, configuration and conditions", func() {
+		cw := &ClusterWatcher{config: ClusterConfig{Name: "vulcan", DatacenterID: "dc-solna"}}
+		start := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+		scheduled := metav1.NewTime(start.Add(30 * time.Second))
+		failed := metav1.NewTime(start.Add(90 * time.Second))
+		policy := "fast-migrations"
+		bandwidth := resource.MustParse("64Mi")
+		allowPostCopy := true
+
+		migration := &kubevirtv1.VirtualMachineInstanceMigration{
+			ObjectMeta: metav1.ObjectMeta{Name: "mig-1", Namespace: "demo"},
+			Spec:       kubevirtv1.VirtualMachineInstanceMigrationSpec{VMIName: "vm-1"},
+			Status: kubevirtv1.VirtualMachineInstanceMigrationStatus{
+				Phase: kubevirtv1.MigrationRunning,
+				Conditions: []kubevirtv1.VirtualMachineInstanceMigrationCondition{{
+					Type:               "Ready",
+					Status:             k8sv1.ConditionFalse,
+					Reason:             "TargetPodFailed",
+					Message:            "target pod was evicted",
+					LastTransitionTime: failed,
+				}},
+				PhaseTransitionTimestamps: []kubevirtv1.VirtualMachineInstanceMigrationPhaseTransitionTimestamp{
+					{Phase: kubevirtv1.MigrationPending, PhaseTransitionTimestamp: start},
+					{Phase: kubevirtv1.MigrationScheduled, PhaseTransitionTimestamp: scheduled},
+					{Phase: kubevirtv1.MigrationFailed, PhaseTransitionTimestamp: failed},
+				},
+				MigrationState: &kubevirtv1.VirtualMachineInstanceMigrationState{
+					Failed:              true,
+					FailureReason:       "target pod was evicted",
+					Mode:                kubevirtv1.MigrationPostCopy,
+					MigrationPolicyName: &policy,
+					MigrationConfiguration: &kubevirtv1.MigrationConfiguration{
+						BandwidthPerMigration: &bandwidth,
+						AllowPostCopy:         &allowPostCopy,
+					},
+				},
+			},
+		}
+
+		modelMigration := cw.convertToModelMigration(migration)
+
+		Expect(modelMigration.Phase).To(Equal("Failed"))
+		Expect(modelMigration.Completed).To(BeTrue())
+		Expect(modelMigration.Failed).To(BeTrue())
+		Expect(modelMigration.FailureReason).To(Equal("target pod was evicted"))
+		Expect(modelMigration.Mode).To(Equal("PostCopy"))
+		Expect(modelMigration.MigrationPolicyName).To(Equal("fast-migrations"))
+		Expect(modelMigration.MigrationConfiguration).NotTo(BeNil())
+		Expect(modelMigration.MigrationConfiguration.BandwidthPerMigration).To(Equal("64Mi"))
+		Expect(*modelMigration.MigrationConfiguration.AllowPostCopy).To(BeTrue())
+		Expect(modelMigration.Conditions).To(HaveLen(1))
+		Expect(modelMigration.Conditions[0].Reason).To(Equal("TargetPodFailed"))
+
+		Expect(modelMigration.PhaseDurations).To(Equal([]models.MigrationPhaseDuration{
+			{Phase: "Pending", DurationSeconds: 30},
+			{Phase: "Scheduled", DurationSeconds: 60},
+		}))
+	})
+
+	It("should measure the current phase of a running migration up to now", func() {
+		now := time.Now()
+		transitions := []models.MigrationTransition{
+			{Phase: "Running", Timestamp: now.Add(-10 * time.Second)},
+			{Phase: "Pending", Timestamp: now.Add(-15 * time.Second)},
+		}
+
+		durations := migrationPhaseDurations(transitions, false, now)
+
+		Expect(durations).To(Equal([]models.MigrationPhaseDuration{
+			{Phase: "Pending", DurationSeconds: 5},
+			{Phase: "Running", DurationSeconds: 10, Current: true},
+		}))
+	})
+})
+
+var _ = Describe("Node tracking", func() {
+	var (
+		store *mocks.MockStore
+		cw    *ClusterWatcher
+		node  *k8sv1.Node
+	)
+
+	BeforeEach(func() {
+		store = mocks.NewMockStore()
+		store.InitializeWithSampleData()
+		cw = &ClusterWatcher{
+			config:    ClusterConfig{Name: "alpha", DatacenterID: "dc-test-1"},
+			dataStore: store,
+			nodes:     make(map[string]models.Node),
+		}
+		node = &k8sv1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "worker-1",
+				Labels: map[string]string{
+					"topology.kubernetes.io/zone":    "zone-1",
+					"node-role.kubernetes.io/worker": "",
+				},
+			},
+			Spec: k8sv1.NodeSpec{Unschedulable: true},
+			Status: k8sv1.NodeStatus{
+				Allocatable: k8sv1.ResourceList{
+					k8sv1.ResourceCPU:    resource.MustParse("31500m"),
+					k8sv1.ResourceMemory: resource.MustParse("64Gi"),
+				},
+				Conditions: []k8sv1.NodeCondition{{Type: k8sv1.NodeReady, Status: k8sv1.ConditionTrue}},
+			},
+		}
+	})
+
+	It("should capture readiness, schedulability, capacity and zone", func() {
+		modelNode := cw.convertToModelNode(node)
+		Expect(modelNode.Cluster).To(Equal("alpha"))
+		Expect(modelNode.DatacenterID).To(Equal("dc-test-1"))
+		Expect(modelNode.Ready).To(BeTrue())
+		Expect(modelNode.Schedulable).To(BeFalse())
+		Expect(modelNode.AllocatableCPU).To(Equal(31))
+		Expect(modelNode.AllocatableMemory).To(Equal(65536))
+		Expect(modelNode.Zone).To(Equal("zone-1"))
+		Expect(modelNode.Roles).To(Equal([]string{"worker"}))
+	})
+
+	It("should store nodes with the VMs placed on them and forget deleted ones", func() {
+		_, err := store.AddVM("dc-test-1", models.VM{ID: "vm-a", Name: "vm-a", Cluster: "alpha", NodeName: "worker-1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(cw.handleNodeEvent(watch.Event{Type: watch.Added, Object: node})).To(Succeed())
+		nodes, err := store.GetNodes("alpha")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes).To(HaveLen(1))
+		Expect(nodes[0].VMs).To(ConsistOf("vm-a"))
+
+		Expect(cw.handleNodeEvent(watch.Event{Type: watch.Deleted, Object: node})).To(Succeed())
+		nodes, err = store.GetNodes("alpha")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes).To(BeEmpty())
+	})
+
+	It("should forget stored nodes that are gone from the cluster on sync", func() {
+		Expect(store.UpsertNode(models.Node{Name: "worker-9", Cluster: "alpha", DatacenterID: "dc-test-1"})).To(Succeed())
+		Expect(store.UpsertNode(models.Node{Name: "worker-1", Cluster: "beta", DatacenterID: "dc-test-2"})).To(Succeed())
+		cw.k8sClient = &fakeKubernetes{nodes: &k8sv1.NodeList{Items: []k8sv1.Node{*node}}}
+
+		Expect(cw.syncExistingNodes()).To(Succeed())
+		nodes, err := store.GetNodes("alpha")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes).To(ConsistOf(HaveField("Name", "worker-1")))
+		// Other clusters keep their nodes
+		nodes, err = store.GetNodes("beta")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("VM and VMI merging", func() {
+	var (
+		store *mocks.MockStore
+		cw    *ClusterWatcher
+		vm    *kubevirtv1.VirtualMachine
+		vmi   *kubevirtv1.VirtualMachineInstance
+	)
+
+	BeforeEach(func() {
+		store = mocks.NewMockStore()
+		store.InitializeWithSampleData()
+		cw = &ClusterWatcher{
+			config:    ClusterConfig{Name: "alpha", DatacenterID: "dc-test-1"},
+			dataStore: store,
+			vms:       make(map[string]*kubevirtv1.VirtualMachine),
+			vmis:      make(map[string]*kubevirtv1.VirtualMachineInstance),
+		}
+		cw.debouncer = newDebouncer(20*time.Millisecond, cw.flushVM)
+		DeferCleanup(cw.debouncer.Stop)
+
+		vm = &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "demo"},
+			Status:     kubevirtv1.VirtualMachineStatus{PrintableStatus: kubevirtv1.VirtualMachineStatusRunning},
+		}
+		vmi = &kubevirtv1.VirtualMachineInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "demo"},
+			Status: kubevirtv1.VirtualMachineInstanceStatus{
+				Phase:    kubevirtv1.Running,
+				NodeName: "worker-2",
+				Interfaces: []kubevirtv1.VirtualMachineInstanceNetworkInterface{
+					{Name: "default", MAC: "02:00:00:00:00:01", IPs: []string{"10.0.0.7", "fd00::7"}},
+				},
+				GuestOSInfo: kubevirtv1.VirtualMachineInstanceGuestOSInfo{Name: "Fedora Linux", ID: "fedora", Version: "40"},
+			},
+		}
+	})
+
+	storedVM := func() (*models.VM, error) {
+		return store.GetVM("dc-test-1", "web-1")
+	}
+	missing := func() error {
+		_, err := storedVM()
+		return err
+	}
+
+	// writes counts the store writes of web-1, which each broadcast an event
+	writes := func() func() int {
+		ch := DefaultHub.RegisterFiltered(Filter{Types: []string{"vm:added", "vm:updated"}, Datacenters: []string{"dc-test-1"}})
+		DeferCleanup(DefaultHub.Unregister, ch)
+		count := 0
+		return func() int {
+			for {
+				select {
+				case <-ch:
+					count++
+				default:
+					return count
+				}
+			}
+		}
+	}
+
+	It("should write a burst of VM and VMI events once, with the VMI fields merged in", func() {
+		count := writes()
+		Expect(cw.handleVMEvent(watch.Event{Type: watch.Added, Object: vm})).To(Succeed())
+		Expect(cw.handleVMIEvent(watch.Event{Type: watch.Added, Object: vmi})).To(Succeed())
+		Expect(cw.handleVMIEvent(watch.Event{Type: watch.Modified, Object: vmi})).To(Succeed())
+		Expect(cw.handleVMEvent(watch.Event{Type: watch.Modified, Object: vm})).To(Succeed())
+
+		Eventually(storedVM).Should(And(
+			HaveField("Status", "running"),
+			HaveField("Phase", "Running"),
+			HaveField("NodeName", "worker-2"),
+			HaveField("IP", "10.0.0.7"),
+			HaveField("IPs", []string{"10.0.0.7", "fd00::7"}),
+			HaveField("GuestOS", HaveField("ID", "fedora")),
+		))
+		Consistently(count, 60*time.Millisecond).Should(Equal(1))
+	})
+
+	It("should trust the VMI cache once the VMIs are synced", func() {
+		// Without a kubevirt client, asking the API for the VMI would panic
+		cw.vmisSynced = true
+		Expect(cw.handleVMEvent(watch.Event{Type: watch.Added, Object: vm})).To(Succeed())
+
+		Eventually(storedVM).Should(And(
+			HaveField("Status", "running"),
+			HaveField("NodeName", BeEmpty()),
+		))
+	})
+
+	It("should ignore VMIs without a VM", func() {
+		Expect(cw.handleVMIEvent(watch.Event{Type: watch.Added, Object: vmi})).To(Succeed())
+		Consistently(missing, 60*time.Millisecond).Should(HaveOccurred())
+	})
+
+	It("should remove a deleted VM at once and drop its pending write", func() {
+		Expect(cw.handleVMIEvent(watch.Event{Type: watch.Added, Object: vmi})).To(Succeed())
+		Expect(cw.handleVMEvent(watch.Event{Type: watch.Added, Object: vm})).To(Succeed())
+		Eventually(storedVM).ShouldNot(BeNil())
+
+		Expect(cw.handleVMEvent(watch.Event{Type: watch.Modified, Object: vm})).To(Succeed())
+		Expect(cw.handleVMEvent(watch.Event{Type: watch.Deleted, Object: vm})).To(Succeed())
+		Expect(missing()).To(HaveOccurred())
+		Consistently(missing, 60*time.Millisecond).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,578 @@
+// Package webhooks delivers the events of the event hub to registered HTTP
+// endpoints. Each delivery POSTs the event as JSON, signed with an HMAC of
+// the webhook's secret, and is retried with exponential backoff until the
+// endpoint answers 2xx or the attempts run out. The recent deliveries of
+// every webhook are kept in memory as its delivery log.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+)
+
+// logSize is how many deliveries the log of a webhook keeps
+const logSize = 50
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Summit-Event"     // The event type
+	HeaderDelivery  = "X-Summit-Delivery"  // The delivery ID, the same for every attempt
+	HeaderSignature = "X-Summit-Signature" // "sha256=" and the hex HMAC-SHA256 of the body
+)
+
+// TestEvent is the type of the event sent by Test
+const TestEvent = "webhook:test"
+
+// LostEvent is the type of the failed delivery recorded for events the
+// hub dropped before they could be delivered
+const LostEvent = "webhook:lost"
+
+// ErrNotFound is returned for a webhook that doesn't exist
+var ErrNotFound = errors.New("webhook not found")
+
+// ErrEmptySecret is returned for an update that clears a webhook's
+// secret, which deliveries are signed with
+var ErrEmptySecret = errors.New("webhook secret cannot be empty")
+
+// Store is where the dispatcher keeps the webhooks
+type Store interface {
+	SaveWebhook(webhook models.Webhook) error
+	GetWebhooks() ([]models.Webhook, error)
+	RemoveWebhook(id string) error
+}
+
+// catchUpInterval is how often the dispatcher checks for events the hub
+// dropped while no new events arrive
+const catchUpInterval = time.Second
+
+// Hub is the source of the events, such as watcher.DefaultHub. The events
+// the hub drops for the dispatcher are replayed from its buffer.
+type Hub interface {
+	RegisterSince(lastID uint64, filter watcher.Filter) (ch chan watcher.Event, missed []watcher.Event, ok bool)
+	Unregister(ch chan watcher.Event)
+	Dropped(ch chan watcher.Event) uint64
+	Since(lastID uint64) (missed []watcher.Event, ok bool)
+	LastID() uint64
+}
+
+// Options tune the deliveries
+type Options struct {
+	// MaxAttempts is how often a delivery is tried
+	MaxAttempts int
+	// Backoff is the wait before the second attempt; it doubles after
+	// every attempt up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout bounds a single attempt
+	Timeout time.Duration
+}
+
+// DefaultOptions returns the default delivery options
+func DefaultOptions() Options {
+	return Options{
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Minute,
+		Timeout:     10 * time.Second,
+	}
+}
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"   // Not yet answered with 2xx, attempts left
+	DeliverySucceeded = "succeeded" // Answered with 2xx
+	DeliveryFailed    = "failed"    // Out of attempts
+)
+
+// Attempt is one try of a delivery
+type Attempt struct {
+	Time       time.Time `json:"time"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// Delivery is an event sent to a webhook, with its attempts
+type Delivery struct {
+	ID          string     `json:"id"`
+	WebhookID   string     `json:"webhookId"`
+	Event       string     `json:"event"`
+	State       string     `json:"state"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	NextAttempt *time.Time `json:"nextAttempt,omitempty"`
+	Attempts    []Attempt  `json:"attempts"`
+}
+
+// Dispatcher delivers the hub's events to the webhooks
+type Dispatcher struct {
+	store   Store
+	options Options
+	client  *http.Client
+
+	mu         sync.Mutex
+	deliveries map[string][]*Delivery // By webhook, newest first
+	ctx        context.Context
+	cancel     context.CancelFunc
+	done       chan struct{}
+	inFlight   sync.WaitGroup
+}
+
+// New creates a stopped dispatcher
+func New(store Store, options Options) *Dispatcher {
+	if options.MaxAttempts < 1 {
+		options.MaxAttempts = 1
+	}
+	return &Dispatcher{
+		store:      store,
+		options:    options,
+		client:     &http.Client{},
+		deliveries: make(map[string][]*Delivery),
+		ctx:        context.Background(),
+	}
+}
+
+// Validate checks the URL and event patterns of a webhook
+func Validate(webhook models.Webhook) error {
+	u, err := url.Parse(webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	for _, pattern := range webhook.Events {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid event pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether a webhook receives events of a type
+func Matches(webhook models.Webhook, eventType string) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, pattern := range webhook.Events {
+		if ok, _ := path.Match(pattern, eventType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the signature of a body as sent in HeaderSignature
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Create validates and saves a new webhook. A random secret is generated
+// when none is given.
+func (d *Dispatcher) Create(webhook models.Webhook) (models.Webhook, error) {
+	if err := Validate(webhook); err != nil {
+		return models.Webhook{}, err
+	}
+	webhook.ID = "wh-" + randomHex(6)
+	webhook.CreatedAt = time.Now().UTC()
+	if webhook.Secret == "" {
+		webhook.Secret = randomHex(32)
+	}
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	if err := d.store.SaveWebhook(webhook); err != nil {
+		return models.Webhook{}, err
+	}
+	log.Printf("Created webhook %s for %s", webhook.ID, webhook.URL)
+	return webhook, nil
+}
+
+// Update validates and saves a changed webhook. The secret cannot be
+// cleared.
+func (d *Dispatcher) Update(webhook models.Webhook) (models.Webhook, error) {
+	if _, err := d.Get(webhook.ID); err != nil {
+		return models.Webhook{}, err
+	}
+	if err := Validate(webhook); err != nil {
+		return models.Webhook{}, err
+	}
+	if webhook.Secret == "" {
+		return models.Webhook{}, ErrEmptySecret
+	}
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	if err := d.store.SaveWebhook(webhook); err != nil {
+		return models.Webhook{}, err
+	}
+	return webhook, nil
+}
+
+// List returns all webhooks, oldest first
+func (d *Dispatcher) List() ([]models.Webhook, error) {
+	webhooks, err := d.store.GetWebhooks()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+		}
+		return webhooks[i].ID < webhooks[j].ID
+	})
+	return webhooks, nil
+}
+
+// Get returns a webhook by ID
+func (d *Dispatcher) Get(id string) (models.Webhook, error) {
+	webhooks, err := d.store.GetWebhooks()
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	for _, webhook := range webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+	return models.Webhook{}, ErrNotFound
+}
+
+// Delete removes a webhook and its delivery log. Deliveries in flight are
+// still attempted.
+func (d *Dispatcher) Delete(id string) error {
+	if _, err := d.Get(id); err != nil {
+		return err
+	}
+	if err := d.store.RemoveWebhook(id); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	delete(d.deliveries, id)
+	d.mu.Unlock()
+
+	log.Printf("Deleted webhook %s", id)
+	return nil
+}
+
+// Deliveries returns the delivery log of a webhook, newest first
+func (d *Dispatcher) Deliveries(id string) ([]Delivery, error) {
+	if _, err := d.Get(id); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deliveries := make([]Delivery, 0, len(d.deliveries[id]))
+	for _, delivery := range d.deliveries[id] {
+		copied := *delivery
+		copied.Attempts = append([]Attempt{}, delivery.Attempts...)
+		deliveries = append(deliveries, copied)
+	}
+	return deliveries, nil
+}
+
+// Test sends a webhook:test event to a webhook once, whether it is enabled
+// and subscribed to the event or not, and returns the delivery
+func (d *Dispatcher) Test(ctx context.Context, id string) (Delivery, error) {
+	webhook, err := d.Get(id)
+	if err != nil {
+		return Delivery{}, err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"type":      TestEvent,
+		"payload":   map[string]string{"webhookId": webhook.ID, "msg": "test delivery"},
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	delivery := d.record(webhook.ID, TestEvent)
+	d.deliver(ctx, webhook, delivery, body, 1)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := *delivery
+	result.Attempts = append([]Attempt{}, delivery.Attempts...)
+	return result, nil
+}
+
+// Dispatch delivers a hub event to the enabled webhooks subscribed to it.
+// The deliveries run in the background.
+func (d *Dispatcher) Dispatch(event watcher.Event) {
+	webhooks, err := d.store.GetWebhooks()
+	if err != nil {
+		log.Printf("Webhooks: failed to load webhooks: %v", err)
+		return
+	}
+
+	d.mu.Lock()
+	ctx := d.ctx
+	d.mu.Unlock()
+
+	for _, webhook := range webhooks {
+		if !webhook.Enabled || !Matches(webhook, event.Type) {
+			continue
+		}
+		delivery := d.record(webhook.ID, event.Type)
+		d.inFlight.Add(1)
+		go func(webhook models.Webhook) {
+			defer d.inFlight.Done()
+			d.deliver(ctx, webhook, delivery, []byte(event.Data), d.options.MaxAttempts)
+		}(webhook)
+	}
+}
+
+// Start delivers the events of hub until Stop is called
+func (d *Dispatcher) Start(hub Hub) {
+	d.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lastID := hub.LastID()
+	ch, missed, _ := hub.RegisterSince(lastID, watcher.Filter{})
+	d.mu.Lock()
+	d.ctx, d.cancel, d.done = ctx, cancel, done
+	d.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		defer hub.Unregister(ch)
+		ticker := time.NewTicker(catchUpInterval)
+		defer ticker.Stop()
+
+		f := &feed{dispatcher: d, hub: hub, ch: ch, lastID: lastID}
+		f.dispatch(missed...)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-ch:
+				if !ok {
+					return
+				}
+				f.catchUp()
+				f.dispatch(event)
+			case <-ticker.C:
+				f.catchUp()
+			}
+		}
+	}()
+}
+
+// feed passes the events of a hub to the dispatcher in order, filling in
+// the events the hub dropped for it
+type feed struct {
+	dispatcher *Dispatcher
+	hub        Hub
+	ch         chan watcher.Event
+	lastID     uint64 // The last event dispatched or skipped
+	dropped    uint64 // The hub's dropped count for ch at the last check
+}
+
+// dispatch delivers the events not seen before
+func (f *feed) dispatch(events ...watcher.Event) {
+	for _, event := range events {
+		if event.ID <= f.lastID {
+			continue
+		}
+		f.lastID = event.ID
+		// The replica an event happened on delivers it
+		if event.Origin != "" {
+			continue
+		}
+		f.dispatcher.Dispatch(event)
+	}
+}
+
+// catchUp replays the events the hub dropped since the last check. Events
+// that already left the hub's buffer are recorded as lost.
+func (f *feed) catchUp() {
+	dropped := f.hub.Dropped(f.ch)
+	if dropped == f.dropped {
+		return
+	}
+	f.dropped = dropped
+	missed, ok := f.hub.Since(f.lastID)
+	if !ok {
+		next := f.hub.LastID() + 1
+		if len(missed) > 0 {
+			next = missed[0].ID
+		}
+		f.dispatcher.lost(next - f.lastID - 1)
+	}
+	f.dispatch(missed...)
+}
+
+// lost records a failed delivery of count events for every enabled webhook
+func (d *Dispatcher) lost(count uint64) {
+	webhooks, err := d.store.GetWebhooks()
+	if err != nil {
+		log.Printf("Webhooks: failed to load webhooks: %v", err)
+		return
+	}
+	log.Printf("Webhooks: %d events were dropped before they could be delivered", count)
+
+	for _, webhook := range webhooks {
+		if !webhook.Enabled {
+			continue
+		}
+		delivery := d.record(webhook.ID, LostEvent)
+		d.mu.Lock()
+		delivery.State = DeliveryFailed
+		delivery.Attempts = append(delivery.Attempts, Attempt{
+			Time:  time.Now().UTC(),
+			Error: fmt.Sprintf("%d events were dropped before they could be delivered", count),
+		})
+		d.mu.Unlock()
+	}
+}
+
+// Stop stops delivering events and waits for the deliveries in flight,
+// which give up their remaining retries
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	cancel, done := d.cancel, d.done
+	d.cancel, d.done = nil, nil
+	d.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	d.inFlight.Wait()
+
+	d.mu.Lock()
+	d.ctx = context.Background()
+	d.mu.Unlock()
+}
+
+// record adds a new delivery to the log of a webhook
+func (d *Dispatcher) record(webhookID, eventType string) *Delivery {
+	delivery := &Delivery{
+		ID:        "dlv-" + randomHex(6),
+		WebhookID: webhookID,
+		Event:     eventType,
+		State:     DeliveryPending,
+		CreatedAt: time.Now().UTC(),
+		Attempts:  []Attempt{},
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entries := append([]*Delivery{delivery}, d.deliveries[webhookID]...)
+	if len(entries) > logSize {
+		entries = entries[:logSize]
+	}
+	d.deliveries[webhookID] = entries
+	return delivery
+}
+
+// deliver tries a delivery up to attempts times, backing off in between
+func (d *Dispatcher) deliver(ctx context.Context, webhook models.Webhook, delivery *Delivery, body []byte, attempts int) {
+	backoff := d.options.Backoff
+	for attempt := 1; ; attempt++ {
+		result := d.attempt(ctx, webhook, delivery.ID, delivery.Event, body)
+
+		d.mu.Lock()
+		delivery.Attempts = append(delivery.Attempts, result)
+		delivery.NextAttempt = nil
+		switch {
+		case result.Error == "":
+			delivery.State = DeliverySucceeded
+		case attempt >= attempts:
+			delivery.State = DeliveryFailed
+		default:
+			next := time.Now().UTC().Add(backoff)
+			delivery.NextAttempt = &next
+		}
+		state := delivery.State
+		d.mu.Unlock()
+
+		if state != DeliveryPending {
+			if state == DeliveryFailed {
+				log.Printf("Webhook %s: delivery %s of %s failed after %d attempts: %s", webhook.ID, delivery.ID, delivery.Event, attempt, result.Error)
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			d.mu.Lock()
+			delivery.State = DeliveryFailed
+			delivery.NextAttempt = nil
+			d.mu.Unlock()
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if d.options.MaxBackoff > 0 && backoff > d.options.MaxBackoff {
+			backoff = d.options.MaxBackoff
+		}
+	}
+}
+
+// attempt POSTs a delivery once; anything but a 2xx answer is an error
+func (d *Dispatcher) attempt(ctx context.Context, webhook models.Webhook, deliveryID, eventType string, body []byte) Attempt {
+	start := time.Now()
+	result := Attempt{Time: start.UTC()}
+
+	if d.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.options.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "summit-connect-webhooks")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		result.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return result
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,292 @@
+package webhooks_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/models"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/watcher"
+	"github.com/cldmnky/summit-connect-stockholm-2025/internal/webhooks"
+)
+
+// fakeStore keeps webhooks in memory
+type fakeStore struct {
+	mu       sync.Mutex
+	webhooks map[string]models.Webhook
+}
+
+func (f *fakeStore) SaveWebhook(webhook models.Webhook) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (f *fakeStore) GetWebhooks() ([]models.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	webhooks := []models.Webhook{}
+	for _, webhook := range f.webhooks {
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+func (f *fakeStore) RemoveWebhook(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.webhooks, id)
+	return nil
+}
+
+// received is a request the receiver got
+type received struct {
+	event     string
+	delivery  string
+	signature string
+	body      []byte
+}
+
+// receiver is an endpoint that answers with the given statuses in turn,
+// then 200
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []received
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, received{
+		event:     req.Header.Get(webhooks.HeaderEvent),
+		delivery:  req.Header.Get(webhooks.HeaderDelivery),
+		signature: req.Header.Get(webhooks.HeaderSignature),
+		body:      body,
+	})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (r *receiver) received() []received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]received{}, r.requests...)
+}
+
+func event(eventType string) watcher.Event {
+	return watcher.Event{ID: 1, Type: eventType, Data: `{"id":1,"type":"` + eventType + `","payload":{}}`}
+}
+
+var _ = Describe("Dispatcher", func() {
+	var (
+		store      *fakeStore
+		dispatcher *webhooks.Dispatcher
+		endpoint   *receiver
+		server     *httptest.Server
+	)
+
+	BeforeEach(func() {
+		store = &fakeStore{webhooks: map[string]models.Webhook{}}
+		dispatcher = webhooks.New(store, webhooks.Options{
+			MaxAttempts: 3,
+			Backoff:     10 * time.Millisecond,
+			MaxBackoff:  20 * time.Millisecond,
+			Timeout:     time.Second,
+		})
+		endpoint = &receiver{}
+		server = httptest.NewServer(endpoint)
+		DeferCleanup(server.Close)
+	})
+
+	create := func(events ...string) models.Webhook {
+		webhook, err := dispatcher.Create(models.Webhook{URL: server.URL, Events: events, Enabled: true})
+		Expect(err).NotTo(HaveOccurred())
+		return webhook
+	}
+
+	deliveries := func(id string) func() []webhooks.Delivery {
+		return func() []webhooks.Delivery {
+			deliveries, err := dispatcher.Deliveries(id)
+			Expect(err).NotTo(HaveOccurred())
+			return deliveries
+		}
+	}
+
+	It("validates webhooks", func() {
+		_, err := dispatcher.Create(models.Webhook{URL: "ftp://example.com"})
+		Expect(err).To(HaveOccurred())
+		_, err = dispatcher.Create(models.Webhook{URL: "/hooks"})
+		Expect(err).To(HaveOccurred())
+		_, err = dispatcher.Create(models.Webhook{URL: "https://example.com", Events: []string{"vm:["}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("generates a secret when none is given", func() {
+		webhook := create()
+		Expect(webhook.ID).To(HavePrefix("wh-"))
+		Expect(webhook.Secret).To(HaveLen(64))
+	})
+
+	It("delivers signed events that match the filters", func() {
+		webhook := create("migration:*", "vm:power")
+
+		dispatcher.Dispatch(event("migration:updated"))
+		dispatcher.Dispatch(event("vm:updated"))
+		dispatcher.Dispatch(event("vm:power"))
+
+		Eventually(deliveries(webhook.ID)).Should(And(
+			HaveLen(2),
+			HaveEach(HaveField("State", webhooks.DeliverySucceeded)),
+		))
+		requests := endpoint.received()
+		Expect(requests).To(HaveLen(2))
+		for _, req := range requests {
+			Expect(req.event).To(BeElementOf("migration:updated", "vm:power"))
+			Expect(req.delivery).To(HavePrefix("dlv-"))
+			Expect(req.signature).To(Equal(webhooks.Sign(webhook.Secret, req.body)))
+		}
+	})
+
+	It("skips disabled webhooks", func() {
+		webhook := create()
+		webhook.Enabled = false
+		_, err := dispatcher.Update(webhook)
+		Expect(err).NotTo(HaveOccurred())
+
+		dispatcher.Dispatch(event("vm:added"))
+		Consistently(endpoint.received, 50*time.Millisecond).Should(BeEmpty())
+	})
+
+	It("keeps webhooks from losing their secret", func() {
+		webhook := create()
+		secret := webhook.Secret
+		webhook.Secret = ""
+		_, err := dispatcher.Update(webhook)
+		Expect(err).To(MatchError(webhooks.ErrEmptySecret))
+
+		stored, err := dispatcher.Get(webhook.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Secret).To(Equal(secret))
+	})
+
+	It("retries failed deliveries with backoff", func() {
+		endpoint.statuses = []int{http.StatusInternalServerError, http.StatusServiceUnavailable}
+		webhook := create()
+
+		dispatcher.Dispatch(event("vm:added"))
+
+		Eventually(deliveries(webhook.ID)).Should(ContainElement(HaveField("State", webhooks.DeliverySucceeded)))
+		delivery := deliveries(webhook.ID)()[0]
+		Expect(delivery.Attempts).To(HaveLen(3))
+		Expect(delivery.Attempts[0].StatusCode).To(Equal(http.StatusInternalServerError))
+		Expect(delivery.Attempts[0].Error).To(ContainSubstring("500"))
+		Expect(delivery.Attempts[2].Error).To(BeEmpty())
+		Expect(delivery.Attempts[1].Time.Sub(delivery.Attempts[0].Time)).To(BeNumerically(">=", 10*time.Millisecond))
+
+		requests := endpoint.received()
+		Expect(requests[0].delivery).To(Equal(requests[2].delivery))
+	})
+
+	It("gives up after the last attempt", func() {
+		endpoint.statuses = []int{500, 500, 500, 500}
+		webhook := create()
+
+		dispatcher.Dispatch(event("vm:added"))
+
+		Eventually(deliveries(webhook.ID)).Should(ContainElement(HaveField("State", webhooks.DeliveryFailed)))
+		Expect(deliveries(webhook.ID)()[0].Attempts).To(HaveLen(3))
+	})
+
+	It("test-fires a webhook once", func() {
+		endpoint.statuses = []int{http.StatusBadGateway}
+		webhook := create("vm:*")
+
+		delivery, err := dispatcher.Test(context.Background(), webhook.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(delivery.Event).To(Equal(webhooks.TestEvent))
+		Expect(delivery.State).To(Equal(webhooks.DeliveryFailed))
+		Expect(delivery.Attempts).To(HaveLen(1))
+
+		_, err = dispatcher.Test(context.Background(), "wh-missing")
+		Expect(err).To(MatchError(webhooks.ErrNotFound))
+	})
+
+	It("delivers the events of a hub until stopped", func() {
+		hub := watcher.NewEventHub()
+		webhook := create()
+
+		dispatcher.Start(hub)
+		hub.BroadcastEvent("vm:migrated", map[string]string{"vmId": "vm-1"})
+		Eventually(deliveries(webhook.ID)).Should(HaveLen(1))
+
+		dispatcher.Stop()
+		hub.BroadcastEvent("vm:migrated", map[string]string{"vmId": "vm-2"})
+		Consistently(deliveries(webhook.ID), 50*time.Millisecond).Should(HaveLen(1))
+	})
+
+	It("leaves the events of other replicas to them", func() {
+		hub := watcher.NewEventHub()
+		webhook := create()
+
+		dispatcher.Start(hub)
+		hub.BroadcastFrom("replica-b", "vm:migrated", map[string]string{"vmId": "vm-1"})
+		hub.BroadcastEvent("vm:migrated", map[string]string{"vmId": "vm-2"})
+		Eventually(deliveries(webhook.ID)).Should(HaveLen(1))
+		Consistently(deliveries(webhook.ID), 50*time.Millisecond).Should(HaveLen(1))
+	})
+
+	It("replays the events the hub dropped during a burst", func() {
+		hub := watcher.NewEventHub()
+		webhook := create()
+		dispatcher.Start(hub)
+
+		// Holding the store stalls the dispatcher, so the hub drops events
+		store.mu.Lock()
+		for i := 0; i < 40; i++ {
+			hub.BroadcastEvent("vm:updated", map[string]int{"n": i})
+		}
+		Expect(hub.Stats().Clients[0].Dropped).NotTo(BeZero())
+		store.mu.Unlock()
+
+		Eventually(deliveries(webhook.ID)).Should(HaveLen(40))
+		Eventually(endpoint.received).Should(HaveLen(40))
+	})
+
+	It("records the events that left the hub's buffer as lost", func() {
+		hub := watcher.NewEventHubWithBuffer(20)
+		webhook := create()
+		dispatcher.Start(hub)
+
+		store.mu.Lock()
+		for i := 0; i < 40; i++ {
+			hub.BroadcastEvent("vm:updated", map[string]int{"n": i})
+		}
+		store.mu.Unlock()
+
+		Eventually(deliveries(webhook.ID)).Should(ContainElement(And(
+			HaveField("Event", webhooks.LostEvent),
+			HaveField("State", webhooks.DeliveryFailed),
+		)))
+		// The buffered events are still delivered
+		Eventually(func() int { return len(endpoint.received()) }).Should(BeNumerically(">=", 20))
+	})
+
+	It("drops the delivery log of deleted webhooks", func() {
+		webhook := create()
+		Expect(dispatcher.Delete(webhook.ID)).To(Succeed())
+		_, err := dispatcher.Deliveries(webhook.ID)
+		Expect(err).To(MatchError(webhooks.ErrNotFound))
+	})
+})